@@ -0,0 +1,209 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// SlowSubscriberPolicy selects what a Broadcaster does when a subscriber's
+// buffer is full.
+type SlowSubscriberPolicy uint8
+
+const (
+	// SlowSubscriberBlock waits until the subscriber has room. One stalled
+	// subscriber then holds up delivery to every other subscriber and,
+	// once the upstream buffer fills, the SSE stream itself.
+	SlowSubscriberBlock SlowSubscriberPolicy = iota
+
+	// SlowSubscriberDrop discards the event for that subscriber only and
+	// counts it in Broadcaster.Dropped.
+	SlowSubscriberDrop
+)
+
+type broadcastOptions struct {
+	buffer int
+	policy SlowSubscriberPolicy
+}
+
+// BroadcastOption configures a Broadcaster.
+type BroadcastOption func(*broadcastOptions)
+
+// WithSubscriberBuffer sets the channel buffer size of each subscriber.
+func WithSubscriberBuffer(n int) BroadcastOption {
+	return func(o *broadcastOptions) {
+		o.buffer = n
+	}
+}
+
+// WithSlowSubscriberPolicy sets how a subscriber with a full buffer is
+// handled. The default is SlowSubscriberBlock.
+func WithSlowSubscriberPolicy(p SlowSubscriberPolicy) BroadcastOption {
+	return func(o *broadcastOptions) {
+		o.policy = p
+	}
+}
+
+// Broadcaster fans a single SSE subscription out to any number of
+// subscribers, each with its own channel, so several consumers in one process
+// share one connection to the server.
+type Broadcaster struct {
+	ctx     context.Context
+	url     string
+	sopts   []SubscribeOption
+	options broadcastOptions
+
+	mu      sync.Mutex
+	subs    map[*broadcastSub]struct{}
+	started bool
+	ended   bool
+	errs    <-chan error
+
+	dropped atomic.Uint64
+}
+
+type broadcastSub struct {
+	ch   chan Event
+	gone chan struct{} // closed on unsubscribe to release a blocked send
+
+	mu     sync.Mutex // serializes sends with closing ch
+	closed bool
+	once   sync.Once
+}
+
+// NewBroadcaster prepares a shared subscription to the SSE endpoint at url.
+// It does not connect until Start is called, so subscribers added before then
+// see every event. The subscription runs until ctx is canceled.
+func NewBroadcaster(ctx context.Context, url string, bopts []BroadcastOption, sopts ...SubscribeOption) *Broadcaster {
+	options := broadcastOptions{buffer: defaultEventBuffer}
+	for _, opt := range bopts {
+		opt(&options)
+	}
+	return &Broadcaster{
+		ctx:     ctx,
+		url:     url,
+		sopts:   sopts,
+		options: options,
+		subs:    make(map[*broadcastSub]struct{}),
+	}
+}
+
+// Start opens the upstream subscription. Calls after the first have no
+// effect.
+func (b *Broadcaster) Start() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.started {
+		return
+	}
+	b.started = true
+
+	events, errs := SubscribeEvents(b.ctx, b.url, b.sopts...)
+	b.errs = errs
+	go b.run(events)
+}
+
+// Errors returns the upstream subscription's error channel, or nil before
+// Start. It has a single consumer like the one from SubscribeEvents.
+func (b *Broadcaster) Errors() <-chan error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.errs
+}
+
+// Subscribe adds a subscriber and returns its event channel together with a
+// function that removes it. The channel is closed after unsubscribing or when
+// the upstream subscription ends. A subscriber added after Start sees only
+// the events received from then on. Subscribing after the upstream has ended
+// returns an already closed channel. The unsubscribe function may be called
+// more than once and from any goroutine.
+func (b *Broadcaster) Subscribe() (<-chan Event, func()) {
+	sub := &broadcastSub{
+		ch:   make(chan Event, b.options.buffer),
+		gone: make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	if b.ended {
+		b.mu.Unlock()
+		sub.close()
+		return sub.ch, func() {}
+	}
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	return sub.ch, func() {
+		b.mu.Lock()
+		delete(b.subs, sub)
+		b.mu.Unlock()
+		sub.close()
+	}
+}
+
+// Dropped returns how many deliveries SlowSubscriberDrop has discarded,
+// summed over all subscribers.
+func (b *Broadcaster) Dropped() uint64 {
+	return b.dropped.Load()
+}
+
+func (b *Broadcaster) run(events <-chan Event) {
+	var subs []*broadcastSub
+	for ev := range events {
+		b.mu.Lock()
+		subs = subs[:0]
+		for sub := range b.subs {
+			subs = append(subs, sub)
+		}
+		b.mu.Unlock()
+
+		for _, sub := range subs {
+			b.deliver(sub, ev)
+		}
+	}
+
+	b.mu.Lock()
+	b.ended = true
+	remaining := b.subs
+	b.subs = nil
+	b.mu.Unlock()
+	for sub := range remaining {
+		sub.close()
+	}
+}
+
+func (b *Broadcaster) deliver(sub *broadcastSub, ev Event) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	if sub.closed {
+		return
+	}
+
+	if b.options.policy == SlowSubscriberDrop {
+		select {
+		case sub.ch <- ev:
+		default:
+			b.dropped.Add(1)
+		}
+		return
+	}
+
+	select {
+	case sub.ch <- ev:
+	case <-sub.gone:
+	case <-b.ctx.Done():
+	}
+}
+
+// close releases any blocked delivery and then closes the channel.
+func (s *broadcastSub) close() {
+	s.once.Do(func() {
+		close(s.gone)
+		s.mu.Lock()
+		s.closed = true
+		close(s.ch)
+		s.mu.Unlock()
+	})
+}
@@ -0,0 +1,149 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// startEventServer serves n numbered events and then holds the stream open
+// until the client goes away. If gate is non-nil, each event waits for a
+// value on gate.
+func startEventServer(t *testing.T, n int, gate <-chan struct{}) string {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher, _ := w.(http.Flusher)
+		for i := 1; i <= n; i++ {
+			if gate != nil {
+				select {
+				case <-gate:
+				case <-r.Context().Done():
+					return
+				}
+			}
+			_, _ = fmt.Fprintf(w, "id: %d\ndata: %d\n\n", i, i)
+			flusher.Flush()
+		}
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func receiveN(t *testing.T, ch <-chan Event, n int) []string {
+	t.Helper()
+
+	var ids []string
+	deadline := time.After(2 * time.Second)
+	for len(ids) < n {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				t.Fatalf("channel closed after %d events", len(ids))
+			}
+			ids = append(ids, ev.ID)
+		case <-deadline:
+			t.Fatalf("timed out after %d of %d events", len(ids), n)
+		}
+	}
+	return ids
+}
+
+func TestBroadcasterDropsForSlowSubscriber(t *testing.T) {
+	t.Parallel()
+
+	const total = 50
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Release one event at a time so the fast readers are always caught up.
+	gate := make(chan struct{})
+	b := NewBroadcaster(ctx, startEventServer(t, total, gate), []BroadcastOption{
+		WithSubscriberBuffer(2),
+		WithSlowSubscriberPolicy(SlowSubscriberDrop),
+	})
+	fast1, unsub1 := b.Subscribe()
+	fast2, _ := b.Subscribe()
+	slow, _ := b.Subscribe() // never read until the end
+	b.Start()
+
+	for i := 1; i <= total; i++ {
+		gate <- struct{}{}
+		want := fmt.Sprint(i)
+		if got := receiveN(t, fast1, 1)[0]; got != want {
+			t.Fatalf("fast1: got event %s, want %s", got, want)
+		}
+		if got := receiveN(t, fast2, 1)[0]; got != want {
+			t.Fatalf("fast2: got event %s, want %s", got, want)
+		}
+	}
+
+	if len(slow) != 2 {
+		t.Fatalf("slow subscriber buffered %d events, want 2", len(slow))
+	}
+	if dropped := b.Dropped(); dropped != total-2 {
+		t.Fatalf("expected %d drops, got %d", total-2, dropped)
+	}
+
+	unsub1()
+	unsub1()
+	if _, ok := <-fast1; ok {
+		t.Fatal("expected unsubscribed channel to be closed")
+	}
+
+	cancel()
+	for range slow {
+	}
+	if _, ok := <-fast2; ok {
+		t.Fatal("expected channels to close when the subscription ends")
+	}
+	if ch, _ := b.Subscribe(); ch != nil {
+		if _, ok := <-ch; ok {
+			t.Fatal("expected a closed channel after the subscription ended")
+		}
+	}
+}
+
+func TestBroadcasterBlockPolicy(t *testing.T) {
+	t.Parallel()
+
+	const total = 20
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := NewBroadcaster(ctx, startEventServer(t, total, nil), []BroadcastOption{WithSubscriberBuffer(1)})
+	fast, _ := b.Subscribe()
+	slow, _ := b.Subscribe()
+	blocked, unsubBlocked := b.Subscribe()
+	b.Start()
+
+	// A subscriber that never reads stalls delivery to everyone until it
+	// unsubscribes.
+	time.Sleep(20 * time.Millisecond)
+	unsubBlocked()
+	for range blocked {
+	}
+
+	// A slow reader holds the others back but loses nothing.
+	for i := 1; i <= total; i++ {
+		want := fmt.Sprint(i)
+		if got := receiveN(t, fast, 1)[0]; got != want {
+			t.Fatalf("fast: got event %s, want %s", got, want)
+		}
+		time.Sleep(time.Millisecond)
+		if got := receiveN(t, slow, 1)[0]; got != want {
+			t.Fatalf("slow: got event %s, want %s", got, want)
+		}
+	}
+	if b.Dropped() != 0 {
+		t.Fatalf("block policy dropped %d events", b.Dropped())
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// CanonicalizeEventData rewrites an event payload as canonical JSON: object
+// keys sorted, insignificant whitespace removed, and HTML characters left
+// unescaped. Two payloads that differ only in key order or formatting produce
+// identical bytes, making the result suitable for content-based dedupe and
+// idempotency keys, for example after SSE redelivers events on reconnect.
+//
+// Numbers are kept in their original textual form, so 1 and 1.0 remain
+// distinct.
+func CanonicalizeEventData(data json.RawMessage) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("canonicalize event data: %w", err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("canonicalize event data: trailing data after JSON value")
+	}
+
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("canonicalize event data: %w", err)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCanonicalizeEventData(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "key order and whitespace",
+			input: "{ \"turn_id\": \"2\",\n  \"context_id\": \"1\" }",
+			want:  `{"context_id":"1","turn_id":"2"}`,
+		},
+		{
+			name:  "nested objects",
+			input: `{"b":{"z":1,"a":{"y":true,"x":null}},"a":"<&>"}`,
+			want:  `{"a":"<&>","b":{"a":{"x":null,"y":true},"z":1}}`,
+		},
+		{
+			name:  "arrays keep order",
+			input: `[ {"b":2,"a":1}, 3, ["c", "a"] ]`,
+			want:  `[{"a":1,"b":2},3,["c","a"]]`,
+		},
+		{
+			name:  "large numbers preserved",
+			input: `{"id":18446744073709551615,"f":1.50}`,
+			want:  `{"f":1.50,"id":18446744073709551615}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CanonicalizeEventData(json.RawMessage(tt.input))
+			if err != nil {
+				t.Fatalf("CanonicalizeEventData: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("got %s want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalizeEventDataEquivalence(t *testing.T) {
+	t.Parallel()
+
+	a, err := CanonicalizeEventData(json.RawMessage(`{"context_id":"1","depth":2,"turn_id":"3"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := CanonicalizeEventData(json.RawMessage("{\"depth\": 2, \"turn_id\": \"3\", \"context_id\": \"1\"}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(a) != string(b) {
+		t.Fatalf("expected equivalent payloads to canonicalize identically: %s vs %s", a, b)
+	}
+}
+
+func TestCanonicalizeEventDataInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, input := range []string{``, `{"a":`, `{"a":1} {"b":2}`} {
+		if _, err := CanonicalizeEventData(json.RawMessage(input)); err == nil {
+			t.Fatalf("expected error for %q", input)
+		}
+	}
+}
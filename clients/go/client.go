@@ -41,6 +41,11 @@
 // For local development, use plain TCP:
 //
 //	client, err := cxdb.Dial("localhost:9009")
+//
+// To test TLS against a server with a self-signed certificate, skip
+// certificate verification (never in production):
+//
+//	client, err := cxdb.DialTLS("localhost:9009", cxdb.WithInsecureSkipVerify(true))
 package cxdb
 
 import (
@@ -50,10 +55,13 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
 	"sync"
 	"sync/atomic"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Binary protocol message types
@@ -70,9 +78,9 @@ const (
 
 // Encoding and compression constants
 const (
-	EncodingMsgpack   uint32 = 1
-	CompressionNone   uint32 = 0
-	CompressionZstd   uint32 = 1
+	EncodingMsgpack uint32        = 1
+	CompressionNone CompressionID = 0
+	CompressionZstd CompressionID = 1
 )
 
 // Default timeouts
@@ -81,15 +89,38 @@ const (
 	DefaultRequestTimeout = 30 * time.Second
 )
 
+// DefaultHTTPPort is the port of the server's HTTP API that Client.EventsURL
+// assumes when WithHTTPBaseURL is not given.
+const DefaultHTTPPort = "9010"
+
+// ProtocolVersion is the newest binary protocol version this client speaks.
+// It is offered to the server in the HELLO handshake.
+const ProtocolVersion uint16 = 1
+
+// minProtocolVersion is the oldest server protocol version this client accepts.
+const minProtocolVersion uint16 = 1
+
+// MaxClientTagLength is the longest client tag, in bytes, that Dial and
+// DialTLS accept.
+const MaxClientTagLength = 256
+
 // Client handles binary protocol communication with the CXDB server.
 type Client struct {
-	conn      net.Conn
-	mu        sync.Mutex
-	reqID     atomic.Uint64
-	timeout   time.Duration
-	closed    bool
-	sessionID uint64    // Assigned by server on HELLO
-	clientTag string    // Client's identifying tag
+	conn            net.Conn
+	mu              sync.Mutex
+	reqID           atomic.Uint64
+	timeout         time.Duration
+	closed          bool
+	sessionID       uint64 // Assigned by server on HELLO
+	clientTag       string // Client's identifying tag
+	protocolVersion uint16 // Negotiated on HELLO
+	httpBase        string // HTTP API base URL; see WithHTTPBaseURL
+
+	// Keep-alive state; see WithKeepAlive.
+	lastUsed  time.Time // End of the most recent request
+	broken    error     // Set when a ping fails
+	done      chan struct{}
+	keepAlive sync.WaitGroup
 }
 
 // Option configures client behavior.
@@ -99,6 +130,9 @@ type clientOptions struct {
 	dialTimeout    time.Duration
 	requestTimeout time.Duration
 	clientTag      string
+	keepAlive      time.Duration
+	httpBaseURL    string
+	insecureTLS    bool
 }
 
 // WithDialTimeout sets the connection timeout.
@@ -117,12 +151,52 @@ func WithRequestTimeout(d time.Duration) Option {
 
 // WithClientTag sets the client identifier tag sent in the HELLO handshake.
 // This allows the server to associate sessions with client types (e.g., "dotrunner", "claude-code").
+// The tag must be valid UTF-8, contain only printable characters, and be at
+// most MaxClientTagLength bytes; otherwise Dial fails with ErrInvalidClientTag.
 func WithClientTag(tag string) Option {
 	return func(o *clientOptions) {
 		o.clientTag = tag
 	}
 }
 
+// WithKeepAlive makes the client Ping the server whenever the connection has
+// been idle for interval, so that intermediaries do not drop long-lived idle
+// connections and dead links are noticed before the next real request. If a
+// ping is not answered within the request timeout the connection is closed
+// and every later request fails with an error wrapping ErrKeepAliveFailed,
+// which IsConnectionError reports as recoverable. Pings never interleave with
+// other requests. Zero, the default, disables keep-alive.
+func WithKeepAlive(interval time.Duration) Option {
+	return func(o *clientOptions) {
+		o.keepAlive = interval
+	}
+}
+
+// WithHTTPBaseURL sets the base URL of the server's HTTP API, such as
+// "https://cxdb.example.com", used by EventsURL and HTTPBaseURL. Set it when
+// the HTTP API is not served on the dial host at DefaultHTTPPort, for example
+// behind a gateway. It must be an absolute http or https URL; otherwise Dial
+// fails without connecting.
+func WithHTTPBaseURL(baseURL string) Option {
+	return func(o *clientOptions) {
+		o.httpBaseURL = baseURL
+	}
+}
+
+// WithInsecureSkipVerify makes DialTLS accept any certificate the server
+// presents, for local testing against a server with a self-signed
+// certificate.
+//
+// WARNING: this disables all verification of the server's identity, so the
+// connection is open to man-in-the-middle attacks. Never use it in
+// production. DialTLS logs a warning on every dial while it is set. Dial
+// ignores it.
+func WithInsecureSkipVerify(skip bool) Option {
+	return func(o *clientOptions) {
+		o.insecureTLS = skip
+	}
+}
+
 // Dial connects to a CXDB server at the given address using plain TCP.
 // For production use with TLS, use DialTLS instead.
 func Dial(addr string, opts ...Option) (*Client, error) {
@@ -133,6 +207,13 @@ func Dial(addr string, opts ...Option) (*Client, error) {
 	for _, opt := range opts {
 		opt(&options)
 	}
+	if err := validateClientTag(options.clientTag); err != nil {
+		return nil, fmt.Errorf("cxdb dial: %w", err)
+	}
+	httpBase, err := httpBaseURL(addr, "http", options.httpBaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("cxdb dial: %w", err)
+	}
 
 	conn, err := net.DialTimeout("tcp", addr, options.dialTimeout)
 	if err != nil {
@@ -143,6 +224,8 @@ func Dial(addr string, opts ...Option) (*Client, error) {
 		conn:      conn,
 		timeout:   options.requestTimeout,
 		clientTag: options.clientTag,
+		httpBase:  httpBase,
+		done:      make(chan struct{}),
 	}
 
 	// Send HELLO to establish session
@@ -150,6 +233,7 @@ func Dial(addr string, opts ...Option) (*Client, error) {
 		_ = conn.Close()
 		return nil, fmt.Errorf("cxdb hello: %w", err)
 	}
+	client.startKeepAlive(options.keepAlive)
 
 	return client, nil
 }
@@ -164,9 +248,20 @@ func DialTLS(addr string, opts ...Option) (*Client, error) {
 	for _, opt := range opts {
 		opt(&options)
 	}
+	if err := validateClientTag(options.clientTag); err != nil {
+		return nil, fmt.Errorf("cxdb dial tls: %w", err)
+	}
+	httpBase, err := httpBaseURL(addr, "https", options.httpBaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("cxdb dial tls: %w", err)
+	}
+
+	if options.insecureTLS {
+		slog.Warn("[cxdb] TLS certificate verification disabled by WithInsecureSkipVerify", "addr", addr)
+	}
 
 	dialer := &net.Dialer{Timeout: options.dialTimeout}
-	conn, err := tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{})
+	conn, err := tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{InsecureSkipVerify: options.insecureTLS})
 	if err != nil {
 		return nil, fmt.Errorf("cxdb dial tls: %w", err)
 	}
@@ -175,6 +270,8 @@ func DialTLS(addr string, opts ...Option) (*Client, error) {
 		conn:      conn,
 		timeout:   options.requestTimeout,
 		clientTag: options.clientTag,
+		httpBase:  httpBase,
+		done:      make(chan struct{}),
 	}
 
 	// Send HELLO to establish session
@@ -182,19 +279,108 @@ func DialTLS(addr string, opts ...Option) (*Client, error) {
 		_ = conn.Close()
 		return nil, fmt.Errorf("cxdb hello: %w", err)
 	}
+	client.startKeepAlive(options.keepAlive)
 
 	return client, nil
 }
 
-// Close closes the connection to the server.
+// validateClientTag rejects tags that would not survive the HELLO frame or
+// would corrupt line-oriented server logs.
+func validateClientTag(tag string) error {
+	if len(tag) > MaxClientTagLength {
+		return fmt.Errorf("%w: %d bytes exceeds limit of %d", ErrInvalidClientTag, len(tag), MaxClientTagLength)
+	}
+	if !utf8.ValidString(tag) {
+		return fmt.Errorf("%w: not valid UTF-8", ErrInvalidClientTag)
+	}
+	for i, r := range tag {
+		if !unicode.IsPrint(r) {
+			return fmt.Errorf("%w: non-printable character %U at byte %d", ErrInvalidClientTag, r, i)
+		}
+	}
+	return nil
+}
+
+// Close closes the connection to the server and stops keep-alive pings.
 func (c *Client) Close() error {
 	c.mu.Lock()
-	defer c.mu.Unlock()
 	if c.closed {
+		c.mu.Unlock()
 		return nil
 	}
 	c.closed = true
-	return c.conn.Close()
+	if c.done != nil {
+		close(c.done)
+	}
+	err := c.conn.Close()
+	if c.broken != nil {
+		// The keep-alive already closed the connection.
+		err = nil
+	}
+	c.mu.Unlock()
+
+	c.keepAlive.Wait()
+	return err
+}
+
+// Ping checks that the server is still answering on this connection by
+// repeating the HELLO handshake, which the server answers without side
+// effects. It fails like any other request if no answer arrives within the
+// request timeout or ctx's deadline.
+func (c *Client) Ping(ctx context.Context) error {
+	resp, err := c.sendRequest(ctx, msgHello, helloPayload(c.clientTag))
+	if err != nil {
+		return fmt.Errorf("ping: %w", err)
+	}
+	if resp.msgType != msgHello {
+		return fmt.Errorf("ping: unexpected response type: %d", resp.msgType)
+	}
+	return nil
+}
+
+// startKeepAlive starts the WithKeepAlive goroutine, which pings whenever no
+// request has completed for interval. It stops on Close or after a failed
+// ping, which marks the client broken and closes the connection.
+func (c *Client) startKeepAlive(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	c.mu.Lock()
+	c.lastUsed = time.Now()
+	c.mu.Unlock()
+
+	c.keepAlive.Add(1)
+	go func() {
+		defer c.keepAlive.Done()
+		timer := time.NewTimer(interval)
+		defer timer.Stop()
+		for {
+			select {
+			case <-c.done:
+				return
+			case <-timer.C:
+			}
+
+			c.mu.Lock()
+			idle := time.Since(c.lastUsed)
+			c.mu.Unlock()
+			if idle < interval {
+				timer.Reset(interval - idle)
+				continue
+			}
+
+			if err := c.Ping(context.Background()); err != nil {
+				c.mu.Lock()
+				if !c.closed && c.broken == nil {
+					c.broken = fmt.Errorf("%w: %v", ErrKeepAliveFailed, err)
+					_ = c.conn.Close()
+				}
+				c.mu.Unlock()
+				return
+			}
+			timer.Reset(interval)
+		}
+	}()
 }
 
 // SessionID returns the session ID assigned by the server during the HELLO handshake.
@@ -202,6 +388,12 @@ func (c *Client) SessionID() uint64 {
 	return c.sessionID
 }
 
+// ProtocolVersion returns the protocol version the server agreed to speak
+// during the HELLO handshake.
+func (c *Client) ProtocolVersion() uint16 {
+	return c.protocolVersion
+}
+
 // ClientTag returns the client tag used for this connection.
 func (c *Client) ClientTag() string {
 	return c.clientTag
@@ -215,11 +407,7 @@ func (c *Client) sendHello(clientTag string) error {
 	// client_tag_len: u16
 	// client_tag: [bytes]
 	// client_meta_json_len: u32 (0)
-	payload := &bytes.Buffer{}
-	_ = binary.Write(payload, binary.LittleEndian, uint16(1)) // protocol version
-	_ = binary.Write(payload, binary.LittleEndian, uint16(len(clientTag)))
-	payload.WriteString(clientTag)
-	_ = binary.Write(payload, binary.LittleEndian, uint32(0)) // no JSON metadata
+	payload := helloPayload(clientTag)
 
 	// Set deadline for handshake
 	if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
@@ -228,7 +416,7 @@ func (c *Client) sendHello(clientTag string) error {
 	defer func() { _ = c.conn.SetDeadline(time.Time{}) }()
 
 	reqID := c.reqID.Add(1)
-	if err := c.writeFrame(msgHello, reqID, payload.Bytes()); err != nil {
+	if err := c.writeFrame(msgHello, reqID, payload); err != nil {
 		return err
 	}
 
@@ -245,14 +433,32 @@ func (c *Client) sendHello(clientTag string) error {
 		return fmt.Errorf("unexpected response type: %d", resp.msgType)
 	}
 
-	// Parse response: session_id (u64) + protocol_version (u16)
+	// Parse response: session_id (u64) + protocol_version (u16). Servers that
+	// predate version negotiation omit the version and speak version 1.
 	if len(resp.payload) >= 8 {
 		c.sessionID = binary.LittleEndian.Uint64(resp.payload[0:8])
 	}
+	c.protocolVersion = 1
+	if len(resp.payload) >= 10 {
+		c.protocolVersion = binary.LittleEndian.Uint16(resp.payload[8:10])
+	}
+	if c.protocolVersion < minProtocolVersion || c.protocolVersion > ProtocolVersion {
+		return &ProtocolVersionError{Client: ProtocolVersion, Server: c.protocolVersion}
+	}
 
 	return nil
 }
 
+// helloPayload encodes a HELLO request payload.
+func helloPayload(clientTag string) []byte {
+	payload := &bytes.Buffer{}
+	_ = binary.Write(payload, binary.LittleEndian, ProtocolVersion)
+	_ = binary.Write(payload, binary.LittleEndian, uint16(len(clientTag)))
+	payload.WriteString(clientTag)
+	_ = binary.Write(payload, binary.LittleEndian, uint32(0)) // no JSON metadata
+	return payload.Bytes()
+}
+
 // frame represents a binary protocol frame.
 type frame struct {
 	msgType uint16
@@ -267,6 +473,10 @@ func (c *Client) sendRequest(ctx context.Context, msgType uint16, payload []byte
 	if c.closed {
 		return nil, ErrClientClosed
 	}
+	if c.broken != nil {
+		return nil, c.broken
+	}
+	defer func() { c.lastUsed = time.Now() }()
 
 	// Set deadline for this request
 	deadline := time.Now().Add(c.timeout)
@@ -308,15 +518,11 @@ func (c *Client) writeFrame(msgType uint16, reqID uint64, payload []byte) error
 }
 
 func (c *Client) readFrame() (*frame, error) {
-	header := make([]byte, 16)
-	if _, err := io.ReadFull(c.conn, header); err != nil {
-		return nil, fmt.Errorf("read header: %w", err)
+	msgType, reqID, length, err := c.readFrameHeader()
+	if err != nil {
+		return nil, err
 	}
 
-	length := binary.LittleEndian.Uint32(header[0:4])
-	msgType := binary.LittleEndian.Uint16(header[4:6])
-	reqID := binary.LittleEndian.Uint64(header[8:16])
-
 	payload := make([]byte, length)
 	if _, err := io.ReadFull(c.conn, payload); err != nil {
 		return nil, fmt.Errorf("read payload: %w", err)
@@ -325,6 +531,19 @@ func (c *Client) readFrame() (*frame, error) {
 	return &frame{msgType: msgType, reqID: reqID, payload: payload}, nil
 }
 
+// readFrameHeader reads a frame header, leaving the payload unread.
+func (c *Client) readFrameHeader() (msgType uint16, reqID uint64, length uint32, err error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(c.conn, header); err != nil {
+		return 0, 0, 0, fmt.Errorf("read header: %w", err)
+	}
+
+	length = binary.LittleEndian.Uint32(header[0:4])
+	msgType = binary.LittleEndian.Uint16(header[4:6])
+	reqID = binary.LittleEndian.Uint64(header[8:16])
+	return msgType, reqID, length, nil
+}
+
 func parseServerError(payload []byte) error {
 	if len(payload) < 8 {
 		return &ServerError{Code: 0, Detail: "unknown error"}
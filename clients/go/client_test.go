@@ -0,0 +1,331 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stubHandler answers a single request frame with a response type and payload.
+type stubHandler func(msgType uint16, payload []byte) (uint16, []byte)
+
+// startStubServer runs a binary-protocol server on a loopback listener that
+// answers HELLO itself and passes every other frame to handler. It returns the
+// listener address.
+func startStubServer(t *testing.T, handler stubHandler) string {
+	t.Helper()
+	return startStubServerHello(t, encodeHelloResp(1, ProtocolVersion), handler)
+}
+
+// startStubServerHello is startStubServer with a custom HELLO response payload.
+func startStubServerHello(t *testing.T, hello []byte, handler stubHandler) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	return serveStubListener(t, ln, hello, handler)
+}
+
+// serveStubListener serves the stub protocol on ln until the test ends.
+func serveStubListener(t *testing.T, ln net.Listener, hello []byte, handler stubHandler) string {
+	t.Helper()
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveStubConn(conn, hello, handler)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func serveStubConn(conn net.Conn, hello []byte, handler stubHandler) {
+	defer conn.Close()
+
+	header := make([]byte, 16)
+	for {
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		length := binary.LittleEndian.Uint32(header[0:4])
+		msgType := binary.LittleEndian.Uint16(header[4:6])
+		reqID := binary.LittleEndian.Uint64(header[8:16])
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(conn, payload); err != nil {
+			return
+		}
+
+		var respType uint16
+		var resp []byte
+		if msgType == msgHello {
+			respType, resp = msgHello, hello
+		} else {
+			respType, resp = handler(msgType, payload)
+		}
+
+		out := &bytes.Buffer{}
+		_ = binary.Write(out, binary.LittleEndian, uint32(len(resp)))
+		_ = binary.Write(out, binary.LittleEndian, respType)
+		_ = binary.Write(out, binary.LittleEndian, uint16(0))
+		_ = binary.Write(out, binary.LittleEndian, reqID)
+		out.Write(resp)
+		if _, err := conn.Write(out.Bytes()); err != nil {
+			return
+		}
+	}
+}
+
+// encodeHelloResp encodes a HELLO response: session ID and protocol version.
+func encodeHelloResp(sessionID uint64, version uint16) []byte {
+	resp := binary.LittleEndian.AppendUint64(nil, sessionID)
+	return binary.LittleEndian.AppendUint16(resp, version)
+}
+
+// encodeTurnRecords encodes records in the GET_LAST response layout.
+func encodeTurnRecords(records ...TurnRecord) []byte {
+	out := &bytes.Buffer{}
+	_ = binary.Write(out, binary.LittleEndian, uint32(len(records)))
+	for _, rec := range records {
+		_ = binary.Write(out, binary.LittleEndian, rec.TurnID)
+		_ = binary.Write(out, binary.LittleEndian, rec.ParentID)
+		_ = binary.Write(out, binary.LittleEndian, rec.Depth)
+		_ = binary.Write(out, binary.LittleEndian, uint32(len(rec.TypeID)))
+		out.WriteString(rec.TypeID)
+		_ = binary.Write(out, binary.LittleEndian, rec.TypeVersion)
+		_ = binary.Write(out, binary.LittleEndian, rec.Encoding)
+		_ = binary.Write(out, binary.LittleEndian, rec.Compression)
+		_ = binary.Write(out, binary.LittleEndian, uint32(len(rec.Payload)))
+		out.Write(rec.PayloadHash[:])
+		_ = binary.Write(out, binary.LittleEndian, uint32(len(rec.Payload)))
+		out.Write(rec.Payload)
+	}
+	return out.Bytes()
+}
+
+// encodeServerError encodes an ERROR frame payload.
+func encodeServerError(code uint32, detail string) []byte {
+	out := binary.LittleEndian.AppendUint32(nil, code)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(detail)))
+	return append(out, detail...)
+}
+
+func TestDialRejectsInvalidClientTag(t *testing.T) {
+	t.Parallel()
+
+	addr := startStubServer(t, func(msgType uint16, payload []byte) (uint16, []byte) {
+		return msgError, encodeServerError(422, "unexpected")
+	})
+
+	tests := []struct {
+		name string
+		tag  string
+	}{
+		{name: "too long", tag: strings.Repeat("a", MaxClientTagLength+1)},
+		{name: "embedded newline", tag: "dotrunner\nforged log line"},
+		{name: "control character", tag: "tag\x00"},
+		{name: "invalid utf8", tag: "tag\xff"},
+	}
+	for _, tt := range tests {
+		if _, err := Dial(addr, WithClientTag(tt.tag)); !errors.Is(err, ErrInvalidClientTag) {
+			t.Errorf("%s: Dial err = %v, want ErrInvalidClientTag", tt.name, err)
+		}
+		if _, err := DialTLS(addr, WithClientTag(tt.tag)); !errors.Is(err, ErrInvalidClientTag) {
+			t.Errorf("%s: DialTLS err = %v, want ErrInvalidClientTag", tt.name, err)
+		}
+	}
+
+	client, err := Dial(addr, WithClientTag(strings.Repeat("a", MaxClientTagLength)))
+	if err != nil {
+		t.Fatalf("Dial with max-length tag: %v", err)
+	}
+	_ = client.Close()
+}
+
+func TestDialProtocolVersion(t *testing.T) {
+	t.Parallel()
+
+	handler := func(msgType uint16, payload []byte) (uint16, []byte) {
+		return msgError, encodeServerError(422, "unexpected")
+	}
+
+	client, err := Dial(startStubServer(t, handler))
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	if got := client.ProtocolVersion(); got != ProtocolVersion {
+		t.Fatalf("ProtocolVersion() = %d, want %d", got, ProtocolVersion)
+	}
+	_ = client.Close()
+
+	// A server that predates negotiation sends only the session ID.
+	legacy := binary.LittleEndian.AppendUint64(nil, 1)
+	client, err = Dial(startStubServerHello(t, legacy, handler))
+	if err != nil {
+		t.Fatalf("Dial legacy server: %v", err)
+	}
+	if got := client.ProtocolVersion(); got != 1 {
+		t.Fatalf("legacy ProtocolVersion() = %d, want 1", got)
+	}
+	_ = client.Close()
+
+	_, err = Dial(startStubServerHello(t, encodeHelloResp(1, ProtocolVersion+1), handler))
+	if !errors.Is(err, ErrProtocolVersionMismatch) {
+		t.Fatalf("expected ErrProtocolVersionMismatch, got %v", err)
+	}
+	var verErr *ProtocolVersionError
+	if !errors.As(err, &verErr) || verErr.Client != ProtocolVersion || verErr.Server != ProtocolVersion+1 {
+		t.Fatalf("expected *ProtocolVersionError with both versions, got %v", err)
+	}
+}
+
+func TestDialTLSInsecureSkipVerify(t *testing.T) {
+	t.Parallel()
+
+	// Borrow httptest's self-signed certificate for a binary-protocol server.
+	certSrv := httptest.NewTLSServer(http.NotFoundHandler())
+	certSrv.Close()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	tlsLn := tls.NewListener(ln, &tls.Config{Certificates: certSrv.TLS.Certificates})
+	addr := serveStubListener(t, tlsLn, encodeHelloResp(1, ProtocolVersion), func(uint16, []byte) (uint16, []byte) {
+		return msgError, encodeServerError(422, "unexpected")
+	})
+
+	var certErr *tls.CertificateVerificationError
+	if _, err := DialTLS(addr); !errors.As(err, &certErr) {
+		t.Fatalf("DialTLS without skip: err = %v, want a certificate verification error", err)
+	}
+	if _, err := DialTLS(addr, WithInsecureSkipVerify(false)); !errors.As(err, &certErr) {
+		t.Fatalf("DialTLS with skip false: err = %v, want a certificate verification error", err)
+	}
+
+	client, err := DialTLS(addr, WithInsecureSkipVerify(true))
+	if err != nil {
+		t.Fatalf("DialTLS with skip: %v", err)
+	}
+	if got := client.ProtocolVersion(); got != ProtocolVersion {
+		t.Fatalf("ProtocolVersion() = %d, want %d", got, ProtocolVersion)
+	}
+	_ = client.Close()
+}
+
+func TestClientKeepAlive(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	// Answer the handshake and two pings, then go silent like a dead link.
+	var hellos atomic.Int32
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		header := make([]byte, 16)
+		for {
+			if _, err := io.ReadFull(conn, header); err != nil {
+				return
+			}
+			payload := make([]byte, binary.LittleEndian.Uint32(header[0:4]))
+			if _, err := io.ReadFull(conn, payload); err != nil {
+				return
+			}
+			if binary.LittleEndian.Uint16(header[4:6]) != msgHello || hellos.Add(1) > 3 {
+				continue
+			}
+			resp := encodeHelloResp(1, ProtocolVersion)
+			out := binary.LittleEndian.AppendUint32(nil, uint32(len(resp)))
+			out = binary.LittleEndian.AppendUint16(out, msgHello)
+			out = binary.LittleEndian.AppendUint16(out, 0)
+			out = append(out, header[8:16]...)
+			if _, err := conn.Write(append(out, resp...)); err != nil {
+				return
+			}
+		}
+	}()
+
+	client, err := Dial(ln.Addr().String(), WithKeepAlive(10*time.Millisecond), WithRequestTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		client.mu.Lock()
+		broken := client.broken
+		client.mu.Unlock()
+		if broken != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("keep-alive did not detect the silent server")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if got := hellos.Load(); got < 4 {
+		t.Fatalf("expected the handshake and at least 3 pings, got %d HELLO frames", got)
+	}
+	_, err = client.GetHead(context.Background(), 1)
+	if !errors.Is(err, ErrKeepAliveFailed) || !IsConnectionError(err) {
+		t.Fatalf("expected a recoverable ErrKeepAliveFailed, got %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestClientKeepAliveStopsOnClose(t *testing.T) {
+	t.Parallel()
+
+	client, err := Dial(startStubServer(t, func(uint16, []byte) (uint16, []byte) {
+		return msgError, encodeServerError(422, "unexpected")
+	}), WithKeepAlive(time.Millisecond))
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	// Close waits for the keep-alive goroutine, so no ping can follow it.
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := client.Ping(context.Background()); !errors.Is(err, ErrClientClosed) {
+		t.Fatalf("expected ErrClientClosed, got %v", err)
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.broken != nil {
+		t.Fatalf("unexpected keep-alive failure: %v", client.broken)
+	}
+}
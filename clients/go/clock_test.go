@@ -0,0 +1,151 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock. Timers fire only when Advance moves
+// the clock past their deadline.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(1700000000, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) clockTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{clock: c, ch: make(chan time.Time, 1)}
+	c.timers = append(c.timers, t)
+	t.resetLocked(d)
+	return t
+}
+
+// Advance moves the clock forward and fires every timer that comes due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	c.fireLocked()
+}
+
+// activeTimers returns how many timers are waiting to fire.
+func (c *fakeClock) activeTimers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := 0
+	for _, t := range c.timers {
+		if t.active {
+			n++
+		}
+	}
+	return n
+}
+
+// waitForTimers blocks until at least n timers are armed, so a test can
+// advance the clock only once the code under test is waiting on it.
+func (c *fakeClock) waitForTimers(t *testing.T, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for c.activeTimers() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d armed timers", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (c *fakeClock) fireLocked() {
+	for _, t := range c.timers {
+		if t.active && !t.when.After(c.now) {
+			t.active = false
+			select {
+			case t.ch <- c.now:
+			default:
+			}
+		}
+	}
+}
+
+type fakeTimer struct {
+	clock  *fakeClock
+	ch     chan time.Time
+	when   time.Time
+	active bool
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	wasActive := t.active
+	t.active = false
+	return wasActive
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	wasActive := t.active
+	t.resetLocked(d)
+	return wasActive
+}
+
+func (t *fakeTimer) resetLocked(d time.Duration) {
+	t.when = t.clock.now.Add(d)
+	t.active = true
+	t.clock.fireLocked()
+}
+
+func TestFakeClockTimer(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClock()
+	timer := c.NewTimer(time.Minute)
+
+	c.Advance(59 * time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+
+	c.Advance(time.Second)
+	select {
+	case <-timer.C():
+	default:
+		t.Fatal("timer did not fire at its deadline")
+	}
+
+	if timer.Stop() {
+		t.Fatal("Stop reported an active timer after it fired")
+	}
+	if timer.Reset(time.Second) {
+		t.Fatal("Reset reported an active timer after it fired")
+	}
+	if !timer.Stop() {
+		t.Fatal("Stop reported an inactive timer after Reset")
+	}
+}
@@ -6,20 +6,23 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	cxdb "github.com/strongdm/ai-cxdb/clients/go"
 	"github.com/strongdm/ai-cxdb/clients/go/types"
 )
 
 type eventOutput struct {
-	Kind string          `json:"kind"`
-	Type string          `json:"type"`
-	Data json.RawMessage `json:"data"`
+	Kind       string          `json:"kind"`
+	Type       string          `json:"type"`
+	Data       json.RawMessage `json:"data"`
+	ReceivedAt *time.Time      `json:"received_at,omitempty"`
 }
 
 type turnOutput struct {
@@ -31,6 +34,17 @@ type turnOutput struct {
 	DeclaredTypeVer uint32                  `json:"declared_type_version,omitempty"`
 	Item            *types.ConversationItem `json:"item,omitempty"`
 	DecodeError     string                  `json:"decode_error,omitempty"`
+	DecodeErrorKind string                  `json:"decode_error_kind,omitempty"`
+}
+
+// gapOutput is printed on stdout in place of the stderr "follow error" line
+// when --emit-gaps is set and FollowTurns reports that the turns at depths
+// FromDepth through ToDepth of a context were skipped.
+type gapOutput struct {
+	Kind      string `json:"kind"`
+	ContextID uint64 `json:"context_id"`
+	FromDepth uint32 `json:"from_depth"`
+	ToDepth   uint32 `json:"to_depth"`
 }
 
 func main() {
@@ -43,6 +57,11 @@ func main() {
 		maxEvents int
 		maxTurns  int
 		maxErrors int
+		lifetime  time.Duration
+		showRecv  bool
+		format    string
+		validate  bool
+		emitGaps  bool
 	)
 
 	flag.StringVar(&eventsURL, "cxdb-events-url", "", "CXDB SSE events URL (required)")
@@ -53,8 +72,18 @@ func main() {
 	flag.IntVar(&maxEvents, "max-events", 0, "Stop after N SSE events (0 = no limit)")
 	flag.IntVar(&maxTurns, "max-turns", 0, "Stop after N decoded turns (0 = no limit)")
 	flag.IntVar(&maxErrors, "max-errors", 0, "Stop after N errors (0 = no limit)")
+	flag.DurationVar(&lifetime, "lifetime", 0, "Stop the subscription after this long (0 = no limit)")
+	flag.BoolVar(&showRecv, "received-at", false, "Include each event's client receive time in the output")
+	flag.StringVar(&format, "format", "json", "Event output format: json (one JSON object per line) or pretty")
+	flag.BoolVar(&validate, "validate", false, "Check that events decode and turn_appended depths are contiguous per context; print a summary to stderr and exit 1 on failure (with --max-events, only the first N events are checked)")
+	flag.BoolVar(&emitGaps, "emit-gaps", false, "With --follow-turns, print depth gaps as {\"kind\":\"gap\"} JSON lines on stdout instead of follow errors on stderr")
 	flag.Parse()
 
+	if format != "json" && format != "pretty" {
+		fmt.Fprintf(os.Stderr, "--format must be json or pretty, got %q\n", format)
+		os.Exit(2)
+	}
+
 	if eventsURL == "" {
 		fmt.Fprintln(os.Stderr, "--cxdb-events-url is required")
 		os.Exit(2)
@@ -63,12 +92,14 @@ func main() {
 		fmt.Fprintln(os.Stderr, "--cxdb-bin-addr is required when --follow-turns is set")
 		os.Exit(2)
 	}
+	if emitGaps && !follow {
+		fmt.Fprintln(os.Stderr, "--emit-gaps requires --follow-turns")
+		os.Exit(2)
+	}
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	events, errs := cxdb.SubscribeEvents(ctx, eventsURL)
-
 	var client *cxdb.Client
 	if follow {
 		var err error
@@ -86,42 +117,48 @@ func main() {
 		}()
 	}
 
-	eventOut := events
-	var followEvents <-chan cxdb.Event
+	var v *validator
+	if validate {
+		v = newValidator()
+	}
 
 	if follow {
-		teeOut := make(chan cxdb.Event, 128)
-		teeFollow := make(chan cxdb.Event, 128)
-		followEvents = teeFollow
-		eventOut = teeOut
-
-		go func() {
-			defer close(teeOut)
-			defer close(teeFollow)
-			for ev := range events {
-				select {
-				case <-ctx.Done():
-					return
-				case teeOut <- ev:
-				}
-				select {
-				case <-ctx.Done():
-					return
-				case teeFollow <- ev:
-				}
-			}
-		}()
+		// One connection feeds both the printed events and the follower.
+		b := cxdb.NewBroadcaster(ctx, eventsURL, nil, cxdb.WithLifetime(lifetime))
+		eventOut, _ := b.Subscribe()
+		followEvents, _ := b.Subscribe()
+		b.Start()
 
-		turns, turnErrs := cxdb.FollowTurns(ctx, followEvents, client)
-		errorCount := consume(ctx, cancel, eventOut, errs, turnErrs, turns, maxEvents, maxTurns, maxErrors)
-		if maxErrors > 0 && errorCount >= maxErrors {
-			os.Exit(1)
+		var followOpts []cxdb.FollowOption
+		if validate || emitGaps {
+			// The reorder buffer makes FollowTurns report gaps in the turns
+			// it fetches as *GapError.
+			followOpts = append(followOpts, cxdb.WithReorderBuffer(gapReorderSize, 0))
 		}
+		turns, turnErrs := cxdb.FollowTurns(ctx, followEvents, client, followOpts...)
+		errorCount := consume(ctx, cancel, eventOut, b.Errors(), turnErrs, turns, maxEvents, maxTurns, maxErrors, showRecv, format == "pretty", emitGaps, v)
+		finish(v, maxErrors > 0 && errorCount >= maxErrors)
 		return
 	}
 
-	errorCount := consume(ctx, cancel, eventOut, errs, nil, nil, maxEvents, maxTurns, maxErrors)
-	if maxErrors > 0 && errorCount >= maxErrors {
+	events, errs := cxdb.SubscribeEvents(ctx, eventsURL, cxdb.WithLifetime(lifetime))
+	errorCount := consume(ctx, cancel, events, errs, nil, nil, maxEvents, maxTurns, maxErrors, showRecv, format == "pretty", false, v)
+	finish(v, maxErrors > 0 && errorCount >= maxErrors)
+}
+
+// gapReorderSize is the reorder buffer FollowTurns uses with --validate or
+// --emit-gaps.
+const gapReorderSize = 64
+
+// finish prints the validation summary, if validating, and exits non-zero
+// if too many errors were seen or validation failed.
+func finish(v *validator, tooManyErrors bool) {
+	failed := tooManyErrors
+	if v != nil {
+		v.summarize(os.Stderr)
+		failed = failed || v.failed()
+	}
+	if failed {
 		os.Exit(1)
 	}
 }
@@ -136,6 +173,10 @@ func consume(
 	maxEvents int,
 	maxTurns int,
 	maxErrors int,
+	showRecv bool,
+	pretty bool,
+	emitGaps bool,
+	v *validator,
 ) int {
 	eventCount := 0
 	turnCount := 0
@@ -167,7 +208,14 @@ func consume(
 				events = nil
 				break
 			}
-			printEvent(ev)
+			if pretty {
+				printPrettyEvent(ev, showRecv)
+			} else {
+				printEvent(ev, showRecv)
+			}
+			if v != nil {
+				v.checkEvent(os.Stderr, ev)
+			}
 			eventCount++
 			stopIfDone()
 		case err, ok := <-errs:
@@ -186,7 +234,15 @@ func consume(
 				break
 			}
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "follow error: %v\n", err)
+				var gapErr *cxdb.GapError
+				if emitGaps && errors.As(err, &gapErr) {
+					printGap(gapErr)
+				} else {
+					fmt.Fprintf(os.Stderr, "follow error: %v\n", err)
+				}
+				if v != nil {
+					v.checkFollowError(os.Stderr, err)
+				}
 				errorCount++
 				stopIfDone()
 			}
@@ -206,8 +262,11 @@ func consume(
 	}
 }
 
-func printEvent(ev cxdb.Event) {
+func printEvent(ev cxdb.Event, showRecv bool) {
 	out := eventOutput{Kind: "event", Type: ev.Type, Data: ev.Data}
+	if showRecv {
+		out.ReceivedAt = &ev.ReceivedAt
+	}
 	data, err := json.Marshal(out)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "encode event: %v\n", err)
@@ -216,6 +275,42 @@ func printEvent(ev cxdb.Event) {
 	_, _ = fmt.Fprintln(os.Stdout, string(data))
 }
 
+func printPrettyEvent(ev cxdb.Event, showRecv bool) {
+	if showRecv {
+		_, _ = fmt.Fprintf(os.Stdout, "# received %s\n", ev.ReceivedAt.Format(time.RFC3339Nano))
+	}
+	if err := cxdb.DumpEvent(os.Stdout, ev); err != nil {
+		fmt.Fprintf(os.Stderr, "print event: %v\n", err)
+	}
+}
+
+func printGap(gap *cxdb.GapError) {
+	data, err := json.Marshal(gapOutput{
+		Kind:      "gap",
+		ContextID: gap.ContextID,
+		FromDepth: gap.FromDepth,
+		ToDepth:   gap.ToDepth,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "encode gap: %v\n", err)
+		return
+	}
+	_, _ = fmt.Fprintln(os.Stdout, string(data))
+}
+
+// decodeErrorKind names the class of a payload decode error for the
+// decode_error_kind output field.
+func decodeErrorKind(err error) string {
+	switch {
+	case errors.Is(err, cxdb.ErrPayloadTruncated):
+		return "truncated"
+	case errors.Is(err, cxdb.ErrPayloadTypeMismatch):
+		return "type_mismatch"
+	default:
+		return "other"
+	}
+}
+
 func printTurn(turn cxdb.FollowTurn) {
 	result := turnOutput{
 		Kind:            "turn",
@@ -228,12 +323,15 @@ func printTurn(turn cxdb.FollowTurn) {
 
 	if turn.Turn.Encoding != cxdb.EncodingMsgpack {
 		result.DecodeError = "unsupported encoding"
-	} else if turn.Turn.Compression != cxdb.CompressionNone {
-		result.DecodeError = "unsupported compression"
+		result.DecodeErrorKind = "unsupported_encoding"
+	} else if payload, err := cxdb.DecodeTurnPayload(turn.Turn); err != nil {
+		result.DecodeError = err.Error()
+		result.DecodeErrorKind = "compression"
 	} else {
 		var item types.ConversationItem
-		if err := cxdb.DecodeMsgpackInto(turn.Turn.Payload, &item); err != nil {
+		if err := cxdb.DecodeMsgpackInto(payload, &item); err != nil {
 			result.DecodeError = err.Error()
+			result.DecodeErrorKind = decodeErrorKind(err)
 		} else {
 			result.Item = &item
 		}
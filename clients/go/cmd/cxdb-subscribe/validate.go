@@ -0,0 +1,125 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	cxdb "github.com/strongdm/ai-cxdb/clients/go"
+)
+
+// validator checks a stream for --validate: every event of a known type must
+// decode with its typed decoder, and the turn_appended events of each context
+// must have contiguous depths. Events of unknown types are counted but not
+// checked, so a newer server does not fail validation.
+//
+// Only the events consumed before the CLI stops are checked, so with
+// --max-events N exactly N events are validated, and a gap that would show up
+// in the next event is not seen.
+type validator struct {
+	events   int
+	failures map[string]int
+
+	// last holds the last turn_appended seen per context.
+	last map[uint64]cxdb.TurnAppendedEvent
+}
+
+func newValidator() *validator {
+	return &validator{
+		failures: make(map[string]int),
+		last:     make(map[uint64]cxdb.TurnAppendedEvent),
+	}
+}
+
+// checkEvent validates one SSE event, reporting failures to w.
+func (v *validator) checkEvent(w io.Writer, ev cxdb.Event) {
+	v.events++
+
+	var err error
+	switch ev.Type {
+	case cxdb.EventContextCreated:
+		_, err = cxdb.DecodeContextCreated(ev.Data)
+	case cxdb.EventContextMetadataUpdated:
+		_, err = cxdb.DecodeContextMetadataUpdated(ev.Data)
+	case cxdb.EventContextClosed:
+		var closed cxdb.ContextClosedEvent
+		if closed, err = cxdb.DecodeContextClosed(ev.Data); err == nil {
+			delete(v.last, closed.ContextID)
+		}
+	case cxdb.EventTurnAppended:
+		var turn cxdb.TurnAppendedEvent
+		if turn, err = cxdb.DecodeTurnAppended(ev.Data); err == nil {
+			v.checkDepth(w, turn)
+		}
+	case cxdb.EventClientConnected:
+		_, err = cxdb.DecodeClientConnected(ev.Data)
+	case cxdb.EventClientDisconnected:
+		_, err = cxdb.DecodeClientDisconnected(ev.Data)
+	default:
+		return
+	}
+	if err != nil {
+		v.fail(w, "decode", fmt.Errorf("decode %s: %w", ev.Type, err))
+	}
+}
+
+// checkDepth reports a turn whose depth does not follow the previous turn
+// of its context. A repeat of the previous turn, as sent again after a
+// reconnect, is not a failure.
+func (v *validator) checkDepth(w io.Writer, turn cxdb.TurnAppendedEvent) {
+	prev, ok := v.last[turn.ContextID]
+	switch {
+	case !ok || turn.Depth == prev.Depth+1:
+	case turn.TurnID == prev.TurnID:
+		return
+	case turn.Depth > prev.Depth+1:
+		v.fail(w, "gap", fmt.Errorf("context %d: turn %d at depth %d skips depths %d-%d", turn.ContextID, turn.TurnID, turn.Depth, prev.Depth+1, turn.Depth-1))
+	default:
+		v.fail(w, "order", fmt.Errorf("context %d: turn %d at depth %d after depth %d", turn.ContextID, turn.TurnID, turn.Depth, prev.Depth))
+	}
+	v.last[turn.ContextID] = turn
+}
+
+// checkFollowError counts the gaps FollowTurns finds in the turns it
+// fetched. Other errors are not validation failures.
+func (v *validator) checkFollowError(w io.Writer, err error) {
+	var gapErr *cxdb.GapError
+	if errors.As(err, &gapErr) {
+		v.fail(w, "follow_gap", err)
+	}
+}
+
+func (v *validator) fail(w io.Writer, kind string, err error) {
+	v.failures[kind]++
+	_, _ = fmt.Fprintf(w, "validate: %v\n", err)
+}
+
+// failed reports whether any check failed.
+func (v *validator) failed() bool {
+	return len(v.failures) > 0
+}
+
+// summarize writes a one-line JSON summary of the checks to w.
+func (v *validator) summarize(w io.Writer) {
+	total := 0
+	for _, n := range v.failures {
+		total += n
+	}
+
+	summary := struct {
+		Kind     string         `json:"kind"`
+		Events   int            `json:"events"`
+		Failures int            `json:"failures"`
+		ByKind   map[string]int `json:"by_kind,omitempty"`
+	}{Kind: "validation", Events: v.events, Failures: total, ByKind: v.failures}
+	data, err := json.Marshal(summary)
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "encode validation summary: %v\n", err)
+		return
+	}
+	_, _ = fmt.Fprintln(w, string(data))
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// CompressionID identifies a payload compression scheme, as carried in
+// TurnRecord.Compression. Only CompressionNone has a built-in codec besides
+// CompressionGzip; there is none for CompressionZstd (ID 1), so decoding zstd
+// payloads needs a codec installed with RegisterCompression.
+type CompressionID uint32
+
+// CompressionGzip marks a gzip-compressed payload. The protocol reserves ID 2
+// for gzip, but servers neither accept it on append nor return it today; the
+// codec is built in so payloads compressed by other tools decode.
+const CompressionGzip CompressionID = 2
+
+// ErrUnsupportedCompression is returned by DecodeTurnPayload when no codec is
+// registered for a record's compression ID.
+var ErrUnsupportedCompression = errors.New("cxdb: unsupported compression")
+
+// Decompressor wraps a compressed payload stream in a reader that yields the
+// uncompressed bytes.
+type Decompressor func(r io.Reader) (io.Reader, error)
+
+var (
+	compressionMu sync.RWMutex
+	customGzip    bool // CompressionGzip has been re-registered
+	compressors   = map[CompressionID]Decompressor{
+		CompressionNone: func(r io.Reader) (io.Reader, error) { return r, nil },
+		CompressionGzip: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+	}
+)
+
+// RegisterCompression installs the decompressor used by DecodeTurnPayload for
+// id, replacing any existing registration. It lets codecs such as zstd or lz4
+// be plugged in without the core package depending on them. It is safe to
+// call concurrently, but is typically called from an init function.
+func RegisterCompression(id CompressionID, fn Decompressor) {
+	compressionMu.Lock()
+	defer compressionMu.Unlock()
+	compressors[id] = fn
+	if id == CompressionGzip {
+		customGzip = true
+	}
+}
+
+// DecodeTurnPayload returns the uncompressed payload of rec, using the codec
+// registered for rec.Compression. Uncompressed payloads are returned without
+// copying. It returns an error wrapping ErrUnsupportedCompression if no codec
+// is registered.
+func DecodeTurnPayload(rec TurnRecord) ([]byte, error) {
+	if rec.Compression == CompressionNone {
+		return rec.Payload, nil
+	}
+
+	fn, _, ok := lookupCompression(rec.Compression)
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedCompression, rec.Compression)
+	}
+
+	r, err := fn(bytes.NewReader(rec.Payload))
+	if err != nil {
+		return nil, fmt.Errorf("decompress payload: %w", err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("decompress payload: %w", err)
+	}
+	return data, nil
+}
+
+// lookupCompression returns the decompressor registered for id. builtinGzip
+// reports that id is CompressionGzip and still uses the built-in codec, which
+// lets TurnDecoder reuse a gzip.Reader.
+func lookupCompression(id CompressionID) (fn Decompressor, builtinGzip, ok bool) {
+	compressionMu.RLock()
+	defer compressionMu.RUnlock()
+	fn, ok = compressors[id]
+	return fn, ok && id == CompressionGzip && !customGzip, ok
+}
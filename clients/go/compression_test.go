@@ -0,0 +1,62 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecodeTurnPayloadBuiltins(t *testing.T) {
+	t.Parallel()
+
+	got, err := DecodeTurnPayload(TurnRecord{Compression: CompressionNone, Payload: []byte("plain")})
+	if err != nil || string(got) != "plain" {
+		t.Fatalf("none: got %q, %v", got, err)
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write([]byte("zipped"))
+	_ = zw.Close()
+
+	got, err = DecodeTurnPayload(TurnRecord{Compression: CompressionGzip, Payload: buf.Bytes()})
+	if err != nil || string(got) != "zipped" {
+		t.Fatalf("gzip: got %q, %v", got, err)
+	}
+}
+
+func TestDecodeTurnPayloadUnsupported(t *testing.T) {
+	t.Parallel()
+
+	_, err := DecodeTurnPayload(TurnRecord{Compression: 0xfffe, Payload: []byte("x")})
+	if !errors.Is(err, ErrUnsupportedCompression) {
+		t.Fatalf("expected ErrUnsupportedCompression, got %v", err)
+	}
+}
+
+func TestRegisterCompression(t *testing.T) {
+	t.Parallel()
+
+	const upper = CompressionID(0xffff)
+	RegisterCompression(upper, func(r io.Reader) (io.Reader, error) {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return strings.NewReader(strings.ToLower(string(data))), nil
+	})
+
+	got, err := DecodeTurnPayload(TurnRecord{Compression: upper, Payload: []byte("HELLO")})
+	if err != nil {
+		t.Fatalf("DecodeTurnPayload: %v", err)
+	}
+	if string(got) != "hello" {
+		t.Fatalf("expected custom codec output %q, got %q", "hello", got)
+	}
+}
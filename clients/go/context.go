@@ -45,14 +45,15 @@ func (c *Client) ForkContext(ctx context.Context, baseTurnID uint64) (*ContextHe
 	return parseContextHead(resp.payload)
 }
 
-// GetHead retrieves the current head of a context.
+// GetHead retrieves the current head of a context. It returns an error
+// wrapping a *ContextNotFoundError if the context does not exist.
 func (c *Client) GetHead(ctx context.Context, contextID uint64) (*ContextHead, error) {
 	payload := make([]byte, 8)
 	binary.LittleEndian.PutUint64(payload, contextID)
 
 	resp, err := c.sendRequest(ctx, msgGetHead, payload)
 	if err != nil {
-		return nil, fmt.Errorf("get head: %w", err)
+		return nil, fmt.Errorf("get head: %w", contextNotFound(contextID, err))
 	}
 
 	return parseContextHead(resp.payload)
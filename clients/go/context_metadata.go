@@ -0,0 +1,152 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ContextMetadata is the metadata the server extracts from the first turn of
+// a context. Contexts whose first turn carries no metadata have only
+// ContextID set.
+type ContextMetadata struct {
+	ContextID uint64
+	ClientTag string
+	Title     string
+	Labels    []string
+}
+
+// HasLabel reports whether label is one of the context's labels.
+func (m ContextMetadata) HasLabel(label string) bool {
+	for _, l := range m.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// ContextMetadataFunc looks up the metadata of a context.
+type ContextMetadataFunc func(ctx context.Context, contextID uint64) (ContextMetadata, error)
+
+type contextMetadataPayload struct {
+	ContextID sseUint64 `json:"context_id"`
+	ClientTag string    `json:"client_tag"`
+	Title     string    `json:"title"`
+	Labels    []string  `json:"labels"`
+}
+
+// HTTPContextMetadata returns a ContextMetadataFunc that reads metadata from
+// the CXDB HTTP API at baseURL (for example "http://localhost:9010") with
+// GET /v1/contexts/{id}. The binary protocol has no metadata request. If
+// client is nil, http.DefaultClient is used. A missing context is reported
+// as a *ContextNotFoundError.
+func HTTPContextMetadata(baseURL string, client *http.Client) ContextMetadataFunc {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	base := strings.TrimRight(strings.TrimSpace(baseURL), "/")
+
+	return func(ctx context.Context, contextID uint64) (ContextMetadata, error) {
+		u := base + "/v1/contexts/" + strconv.FormatUint(contextID, 10) + "?" + url.Values{
+			"include_provenance": {"0"},
+			"include_lineage":    {"0"},
+		}.Encode()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return ContextMetadata{}, fmt.Errorf("context metadata: build request: %w", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return ContextMetadata{}, fmt.Errorf("context metadata: request failed: %w", err)
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		switch resp.StatusCode {
+		case http.StatusOK:
+		case http.StatusNotFound:
+			return ContextMetadata{}, fmt.Errorf("context metadata: %w", &ContextNotFoundError{ContextID: contextID})
+		default:
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return ContextMetadata{}, fmt.Errorf("context metadata: unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+
+		var payload contextMetadataPayload
+		if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+			return ContextMetadata{}, fmt.Errorf("context metadata: decode: %w", err)
+		}
+		return ContextMetadata{
+			ContextID: payload.ContextID.Value,
+			ClientTag: payload.ClientTag,
+			Title:     payload.Title,
+			Labels:    payload.Labels,
+		}, nil
+	}
+}
+
+// ContextListFunc lists context IDs, for example to choose which contexts
+// FollowTurns backfills at startup.
+type ContextListFunc func(ctx context.Context) ([]uint64, error)
+
+type contextListPayload struct {
+	Contexts []struct {
+		ContextID sseUint64 `json:"context_id"`
+	} `json:"contexts"`
+}
+
+// HTTPRecentContexts returns a ContextListFunc that lists up to limit of the
+// most recently active contexts from the CXDB HTTP API at baseURL with
+// GET /v1/contexts. A limit of 0 or less uses the server default of 20. If
+// client is nil, http.DefaultClient is used.
+func HTTPRecentContexts(baseURL string, client *http.Client, limit int) ContextListFunc {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	base := strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	query := url.Values{
+		"include_provenance": {"0"},
+		"include_lineage":    {"0"},
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	return func(ctx context.Context) ([]uint64, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/v1/contexts?"+query.Encode(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("list contexts: build request: %w", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("list contexts: request failed: %w", err)
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return nil, fmt.Errorf("list contexts: unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+
+		var payload contextListPayload
+		if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+			return nil, fmt.Errorf("list contexts: decode: %w", err)
+		}
+		ids := make([]uint64, 0, len(payload.Contexts))
+		for _, c := range payload.Contexts {
+			ids = append(ids, c.ContextID.Value)
+		}
+		return ids, nil
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestHTTPContextMetadata(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("include_provenance") != "0" {
+			t.Errorf("expected include_provenance=0, got %q", r.URL.RawQuery)
+		}
+		switch r.URL.Path {
+		case "/v1/contexts/7":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"context_id":"7","head_turn_id":"9","client_tag":"dotrunner","title":"Deploy","labels":["production","urgent"]}`))
+		case "/v1/contexts/8":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	fetch := HTTPContextMetadata(srv.URL+"/", nil)
+	ctx := context.Background()
+
+	meta, err := fetch(ctx, 7)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	want := ContextMetadata{ContextID: 7, ClientTag: "dotrunner", Title: "Deploy", Labels: []string{"production", "urgent"}}
+	if !reflect.DeepEqual(meta, want) {
+		t.Fatalf("got %+v want %+v", meta, want)
+	}
+	if !meta.HasLabel("urgent") || meta.HasLabel("dev") {
+		t.Fatalf("unexpected HasLabel results for %v", meta.Labels)
+	}
+
+	if _, err := fetch(ctx, 8); err == nil || errors.Is(err, ErrContextNotFound) {
+		t.Fatalf("expected a status error, got %v", err)
+	}
+	if _, err := fetch(ctx, 9); !errors.Is(err, ErrContextNotFound) {
+		t.Fatalf("expected ErrContextNotFound, got %v", err)
+	}
+}
+
+func TestHTTPRecentContexts(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/contexts" {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.URL.Query().Get("limit"); got != "2" {
+			t.Errorf("expected limit=2, got %q", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"contexts":[{"context_id":"12","head_turn_id":"4"},{"context_id":3}],"count":2}`))
+	}))
+	defer srv.Close()
+
+	ids, err := HTTPRecentContexts(srv.URL, nil, 2)(context.Background())
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if want := []uint64{12, 3}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("got %v want %v", ids, want)
+	}
+
+	if _, err := HTTPRecentContexts(srv.URL+"/missing", nil, 0)(context.Background()); err == nil {
+		t.Fatal("expected an error for a 404 response")
+	}
+}
@@ -5,6 +5,11 @@ package cxdb
 
 import (
 	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
 
 	"github.com/vmihailenco/msgpack/v5"
 )
@@ -26,12 +31,156 @@ func EncodeMsgpack(v any) ([]byte, error) {
 func DecodeMsgpack(data []byte) (map[uint64]any, error) {
 	var result map[uint64]any
 	if err := msgpack.Unmarshal(data, &result); err != nil {
-		return nil, err
+		return nil, classifyDecodeError(err)
 	}
 	return result, nil
 }
 
-// DecodeMsgpackInto decodes msgpack data into the provided value.
+// DecodeMsgpackInto decodes msgpack data into the provided value. Decoded
+// strings and byte slices are copies that never alias data, so the caller
+// may reuse or overwrite data as soon as it returns. The msgpack codec has no
+// mode that decodes by reference; followers decoding many turns can save
+// allocations with a TurnDecoder instead.
+//
+// A failed decode returns a *PayloadDecodeError, which matches
+// ErrPayloadTruncated or ErrPayloadTypeMismatch with errors.Is when the
+// failure falls in one of those classes.
 func DecodeMsgpackInto(data []byte, v any) error {
-	return msgpack.Unmarshal(data, v)
+	return classifyDecodeError(msgpack.Unmarshal(data, v))
+}
+
+// PayloadDecodeError is returned by DecodeMsgpack, DecodeMsgpackInto, and
+// TurnDecoder.Decode when msgpack decoding fails. Kind is
+// ErrPayloadTruncated or ErrPayloadTypeMismatch, which the error matches with
+// errors.Is, or nil for other failures, such as the reserved code 0xc1 in a
+// corrupt payload or an error from a type's own DecodeMsgpack method. Err is
+// the codec's error.
+type PayloadDecodeError struct {
+	Kind error
+	Err  error
+}
+
+func (e *PayloadDecodeError) Error() string {
+	return "decode payload: " + e.Err.Error()
+}
+
+func (e *PayloadDecodeError) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
+
+func (e *PayloadDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// classifyDecodeError wraps a msgpack codec error in a *PayloadDecodeError.
+// The codec reports both classes with plain errors, so they are told apart
+// by message: running out of input surfaces as io.EOF or
+// io.ErrUnexpectedEOF, and a well-formed value of the wrong type as an
+// "invalid code" or "unexpected code" error naming the type it expected.
+func classifyDecodeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	decodeErr := &PayloadDecodeError{Err: err}
+	msg := err.Error()
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		decodeErr.Kind = ErrPayloadTruncated
+	case strings.Contains(msg, "code=c1 "):
+		// 0xc1 is never used by the format, so the payload is corrupt.
+	case strings.HasPrefix(msg, "msgpack: invalid code="), strings.HasPrefix(msg, "msgpack: unexpected code="):
+		decodeErr.Kind = ErrPayloadTypeMismatch
+	}
+	return decodeErr
+}
+
+// TurnDecoder decodes turn payloads like DecodeTurnPayload followed by
+// DecodeMsgpackInto, but keeps its msgpack decoder, readers, and decompression
+// buffer between calls instead of allocating them for every turn. The
+// built-in gzip codec's state is reused too; codecs installed with
+// RegisterCompression are called once per turn as usual. It suits
+// followers that decode many turns in a row.
+//
+// A TurnDecoder is not safe for concurrent use. Give each goroutine its own,
+// or guard a shared one with a mutex. Decoded values never alias the
+// decoder's buffers, so they stay valid after later calls.
+type TurnDecoder struct {
+	dec *msgpack.Decoder
+	rd  bytes.Reader
+	src bytes.Reader // compressed input
+	gz  *gzip.Reader
+	buf bytes.Buffer // decompressed payload
+}
+
+// TurnDecoderOption configures a TurnDecoder.
+type TurnDecoderOption func(*TurnDecoder)
+
+// WithPayloadSizeHint preallocates n bytes for decompressed payloads, so the
+// first compressed turns up to that size decode without growing the buffer.
+// The buffer grows as needed either way.
+func WithPayloadSizeHint(n int) TurnDecoderOption {
+	return func(d *TurnDecoder) {
+		if n > 0 {
+			d.buf.Grow(n)
+		}
+	}
+}
+
+// NewTurnDecoder returns a TurnDecoder ready for use.
+func NewTurnDecoder(opts ...TurnDecoderOption) *TurnDecoder {
+	d := &TurnDecoder{dec: msgpack.NewDecoder(nil)}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Decode decompresses turn's payload with the codec registered for
+// turn.Compression and decodes the msgpack result into v. It returns an error
+// wrapping ErrUnsupportedCompression if no codec is registered, and a
+// *PayloadDecodeError, as DecodeMsgpackInto does, if decoding fails.
+func (d *TurnDecoder) Decode(turn TurnRecord, v any) error {
+	data := turn.Payload
+	if turn.Compression != CompressionNone {
+		r, err := d.decompressor(turn)
+		if err != nil {
+			return err
+		}
+		d.buf.Reset()
+		if _, err := d.buf.ReadFrom(r); err != nil {
+			return fmt.Errorf("decompress payload: %w", err)
+		}
+		data = d.buf.Bytes()
+	}
+
+	d.rd.Reset(data)
+	d.dec.Reset(&d.rd)
+	d.dec.UsePreallocateValues(true)
+	return classifyDecodeError(d.dec.Decode(v))
+}
+
+// decompressor returns a reader over turn's uncompressed payload.
+func (d *TurnDecoder) decompressor(turn TurnRecord) (io.Reader, error) {
+	fn, builtinGzip, ok := lookupCompression(turn.Compression)
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedCompression, turn.Compression)
+	}
+
+	d.src.Reset(turn.Payload)
+	var r io.Reader
+	var err error
+	switch {
+	case builtinGzip && d.gz != nil:
+		err = d.gz.Reset(&d.src)
+		r = d.gz
+	case builtinGzip:
+		d.gz, err = gzip.NewReader(&d.src)
+		r = d.gz
+	default:
+		r, err = fn(&d.src)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decompress payload: %w", err)
+	}
+	return r, nil
 }
@@ -0,0 +1,200 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type decodeTestPayload struct {
+	Role    string            `msgpack:"1"`
+	Text    string            `msgpack:"2"`
+	Tags    []string          `msgpack:"3"`
+	Attrs   map[string]string `msgpack:"4"`
+	Payload []byte            `msgpack:"5"`
+}
+
+func decodeTestTurns(t testing.TB, n int) ([]TurnRecord, []decodeTestPayload) {
+	t.Helper()
+	turns := make([]TurnRecord, n)
+	want := make([]decodeTestPayload, n)
+	for i := range turns {
+		want[i] = decodeTestPayload{
+			Role:    "assistant",
+			Text:    strings.Repeat("turn text ", 20+i%7),
+			Tags:    []string{"a", "b"},
+			Attrs:   map[string]string{"model": "m", "index": strings.Repeat("x", i%5)},
+			Payload: []byte{byte(i), byte(i >> 8)},
+		}
+		data, err := EncodeMsgpack(want[i])
+		if err != nil {
+			t.Fatalf("EncodeMsgpack: %v", err)
+		}
+		turns[i] = TurnRecord{TurnID: uint64(i + 1), Encoding: EncodingMsgpack, Payload: data}
+	}
+	return turns, want
+}
+
+func TestTurnDecoder(t *testing.T) {
+	t.Parallel()
+
+	turns, want := decodeTestTurns(t, 20)
+
+	// Compress every other payload so both paths share one decoder.
+	compressed := gzipTurns(turns)
+	for i := 0; i < len(turns); i += 2 {
+		turns[i] = compressed[i]
+	}
+
+	dec := NewTurnDecoder(WithPayloadSizeHint(1024))
+	got := make([]decodeTestPayload, len(turns))
+	for i, turn := range turns {
+		if err := dec.Decode(turn, &got[i]); err != nil {
+			t.Fatalf("Decode turn %d: %v", turn.TurnID, err)
+		}
+	}
+	// Earlier results must not be overwritten by later decodes.
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("decoded payloads differ from the originals")
+	}
+
+	var v decodeTestPayload
+	if err := dec.Decode(TurnRecord{Compression: 99, Payload: turns[1].Payload}, &v); !errors.Is(err, ErrUnsupportedCompression) {
+		t.Fatalf("expected ErrUnsupportedCompression, got %v", err)
+	}
+	if err := dec.Decode(TurnRecord{Payload: []byte{0xc1}}, &v); err == nil {
+		t.Fatal("expected an error for an invalid payload")
+	}
+	// The decoder recovers after a failed call.
+	if err := dec.Decode(turns[1], &v); err != nil || !reflect.DeepEqual(v, want[1]) {
+		t.Fatalf("Decode after failure: %v", err)
+	}
+}
+
+func TestDecodeMsgpackIntoDoesNotAlias(t *testing.T) {
+	t.Parallel()
+
+	_, want := decodeTestTurns(t, 1)
+	data, err := EncodeMsgpack(want[0])
+	if err != nil {
+		t.Fatalf("EncodeMsgpack: %v", err)
+	}
+
+	var got decodeTestPayload
+	if err := DecodeMsgpackInto(data, &got); err != nil {
+		t.Fatalf("DecodeMsgpackInto: %v", err)
+	}
+	// Overwrite the buffer as a caller reusing it for the next turn would.
+	for i := range data {
+		data[i] = 0xff
+	}
+	if !reflect.DeepEqual(got, want[0]) {
+		t.Fatalf("decoded strings or bytes changed with the input buffer: %+v", got)
+	}
+}
+
+func TestDecodeMsgpackErrorClassification(t *testing.T) {
+	t.Parallel()
+
+	_, want := decodeTestTurns(t, 1)
+	data, err := EncodeMsgpack(want[0])
+	if err != nil {
+		t.Fatalf("EncodeMsgpack: %v", err)
+	}
+
+	// Cut short at every length: each failure is a truncation.
+	for n := 0; n < len(data); n++ {
+		var v decodeTestPayload
+		err := DecodeMsgpackInto(data[:n], &v)
+		if !errors.Is(err, ErrPayloadTruncated) || errors.Is(err, ErrPayloadTypeMismatch) {
+			t.Fatalf("truncated to %d bytes: got %v, want ErrPayloadTruncated", n, err)
+		}
+	}
+
+	// A string where the schema has a list.
+	mismatched, err := EncodeMsgpack(map[string]any{"1": "assistant", "3": "not a list"})
+	if err != nil {
+		t.Fatalf("EncodeMsgpack: %v", err)
+	}
+	var v decodeTestPayload
+	err = DecodeMsgpackInto(mismatched, &v)
+	if !errors.Is(err, ErrPayloadTypeMismatch) || errors.Is(err, ErrPayloadTruncated) {
+		t.Fatalf("type mismatch: got %v, want ErrPayloadTypeMismatch", err)
+	}
+	var decodeErr *PayloadDecodeError
+	if !errors.As(err, &decodeErr) || decodeErr.Err == nil {
+		t.Fatalf("expected a *PayloadDecodeError, got %T", err)
+	}
+
+	// The never-used code 0xc1 is corrupt, not a type mismatch.
+	err = DecodeMsgpackInto([]byte{0xc1}, &v)
+	if !errors.As(err, &decodeErr) || decodeErr.Kind != nil {
+		t.Fatalf("corrupt payload: got %v, want an unclassified *PayloadDecodeError", err)
+	}
+
+	tagged, err := EncodeMsgpack(map[uint64]any{1: "assistant", 2: "some text"})
+	if err != nil {
+		t.Fatalf("EncodeMsgpack: %v", err)
+	}
+	if _, err := DecodeMsgpack(tagged[:len(tagged)-3]); !errors.Is(err, ErrPayloadTruncated) {
+		t.Fatalf("DecodeMsgpack: got %v, want ErrPayloadTruncated", err)
+	}
+	dec := NewTurnDecoder()
+	if err := dec.Decode(TurnRecord{Payload: mismatched}, &v); !errors.Is(err, ErrPayloadTypeMismatch) {
+		t.Fatalf("TurnDecoder: got %v, want ErrPayloadTypeMismatch", err)
+	}
+}
+
+func gzipTurns(turns []TurnRecord) []TurnRecord {
+	out := make([]TurnRecord, len(turns))
+	for i, turn := range turns {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, _ = zw.Write(turn.Payload)
+		_ = zw.Close()
+		turn.Compression = CompressionGzip
+		turn.Payload = buf.Bytes()
+		out[i] = turn
+	}
+	return out
+}
+
+// BenchmarkTurnDecode compares per-call decoding with DecodeTurnPayload and
+// DecodeMsgpackInto against a reused TurnDecoder.
+func BenchmarkTurnDecode(b *testing.B) {
+	plain, _ := decodeTestTurns(b, 1000)
+	inputs := map[string][]TurnRecord{"plain": plain, "gzip": gzipTurns(plain)}
+
+	for _, name := range []string{"plain", "gzip"} {
+		turns := inputs[name]
+		b.Run(name+"/per-call", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				data, err := DecodeTurnPayload(turns[i%len(turns)])
+				if err != nil {
+					b.Fatal(err)
+				}
+				var v decodeTestPayload
+				if err := DecodeMsgpackInto(data, &v); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(name+"/TurnDecoder", func(b *testing.B) {
+			dec := NewTurnDecoder()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var v decodeTestPayload
+				if err := dec.Decode(turns[i%len(turns)], &v); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
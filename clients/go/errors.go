@@ -13,7 +13,8 @@ var (
 	// ErrClientClosed is returned when operations are attempted on a closed client.
 	ErrClientClosed = errors.New("cxdb: client closed")
 
-	// ErrContextNotFound is returned when a context ID doesn't exist.
+	// ErrContextNotFound is returned when a context ID doesn't exist. The
+	// client wraps it in a *ContextNotFoundError that names the context.
 	ErrContextNotFound = errors.New("cxdb: context not found")
 
 	// ErrTurnNotFound is returned when a turn ID doesn't exist.
@@ -21,8 +22,145 @@ var (
 
 	// ErrInvalidResponse is returned when the server response is malformed.
 	ErrInvalidResponse = errors.New("cxdb: invalid response")
+
+	// ErrProtocolVersionMismatch is returned by Dial and DialTLS, wrapped in a
+	// *ProtocolVersionError, when the server answers the handshake with a
+	// protocol version this client does not support.
+	ErrProtocolVersionMismatch = errors.New("cxdb: protocol version mismatch")
+
+	// ErrInvalidClientTag is returned by Dial and DialTLS when the WithClientTag
+	// value is too long or contains non-printable characters.
+	ErrInvalidClientTag = errors.New("cxdb: invalid client tag")
+
+	// ErrKeepAliveFailed is returned by every request on a Client whose
+	// WithKeepAlive ping went unanswered. The connection has been closed; dial
+	// a new client.
+	ErrKeepAliveFailed = errors.New("cxdb: keep-alive failed")
+
+	// ErrContextAbandoned is reported by FollowTurns when it stops following
+	// a context that reached the WithFollowErrorThreshold limit.
+	ErrContextAbandoned = errors.New("cxdb: context abandoned")
+
+	// ErrPayloadTruncated is matched by a *PayloadDecodeError when a msgpack
+	// payload ends before the value it encodes is complete, as when it was
+	// cut short in storage or transit.
+	ErrPayloadTruncated = errors.New("cxdb: payload truncated")
+
+	// ErrPayloadTypeMismatch is matched by a *PayloadDecodeError when a
+	// msgpack payload is well formed but holds a value of a different type
+	// than the target field, as when the payload was written with a
+	// different schema.
+	ErrPayloadTypeMismatch = errors.New("cxdb: payload type mismatch")
+
+	// ErrHandshakeTimeout is reported when an SSE connection attempt does not
+	// receive response headers within the WithHandshakeTimeout deadline.
+	ErrHandshakeTimeout = errors.New("cxdb: handshake timeout")
+
+	// ErrEmitTimeout is reported when the consumer does not accept an event
+	// within the WithEmitTimeout deadline. The subscription then reconnects.
+	ErrEmitTimeout = errors.New("cxdb: emit timeout")
+
+	// ErrByteLimitExceeded is reported, as the final error, when a subscription
+	// stops because it reached the WithTotalByteLimit cap.
+	ErrByteLimitExceeded = errors.New("cxdb: total byte limit exceeded")
+
+	// ErrSubscriptionExpired is reported, as the final error, when a
+	// subscription stops because its WithLifetime duration elapsed.
+	ErrSubscriptionExpired = errors.New("cxdb: subscription expired")
+
+	// ErrRetryBudgetExhausted is reported, as the final error, when a
+	// subscription stops because it spent its WithRetryBudget failing to
+	// connect.
+	ErrRetryBudgetExhausted = errors.New("cxdb: retry budget exhausted")
+
+	// ErrTooManyRedirects is reported when an SSE connection attempt is
+	// redirected more times than WithMaxRedirects allows.
+	ErrTooManyRedirects = errors.New("cxdb: too many redirects")
+
+	// ErrRedirectLoop is reported when an SSE connection attempt is redirected
+	// back to a URL it has already visited.
+	ErrRedirectLoop = errors.New("cxdb: redirect loop")
 )
 
+// StreamParseError is returned when an SSE stream contains a line that cannot
+// be parsed as a field. Line is the 1-based line number within the current
+// connection's stream and Raw is the offending line without its terminator.
+type StreamParseError struct {
+	Line  int
+	Field string
+	Raw   string
+}
+
+func (e *StreamParseError) Error() string {
+	return fmt.Sprintf("cxdb subscribe: malformed field %q at line %d", e.Field, e.Line)
+}
+
+// HTTPStatusError is reported when an SSE connection attempt receives a
+// response status other than 200 OK. Body holds up to the first 1KB of the
+// response body.
+type HTTPStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("cxdb subscribe: unexpected status %d: %s", e.StatusCode, e.Body)
+}
+
+// ProtocolVersionError reports the versions involved in a failed protocol
+// negotiation. It wraps ErrProtocolVersionMismatch.
+type ProtocolVersionError struct {
+	Client uint16 // newest version the client speaks
+	Server uint16 // version the server answered with
+}
+
+func (e *ProtocolVersionError) Error() string {
+	return fmt.Sprintf("%v: client speaks version %d, server answered %d", ErrProtocolVersionMismatch, e.Client, e.Server)
+}
+
+func (e *ProtocolVersionError) Unwrap() error {
+	return ErrProtocolVersionMismatch
+}
+
+// ContextNotFoundError reports which context a failed request named. It
+// matches ErrContextNotFound with errors.Is, so existing sentinel checks keep
+// working. Err is the underlying error, such as the server's *ServerError, and
+// may be nil.
+type ContextNotFoundError struct {
+	ContextID uint64
+	Err       error
+}
+
+func (e *ContextNotFoundError) Error() string {
+	return fmt.Sprintf("%v: context %d", ErrContextNotFound, e.ContextID)
+}
+
+func (e *ContextNotFoundError) Is(target error) bool {
+	return target == ErrContextNotFound
+}
+
+func (e *ContextNotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// contextNotFound returns err as a *ContextNotFoundError for contextID if it
+// reports a missing context: the server's 404 "context" error, or
+// ErrContextNotFound without a context ID. Other errors are returned as is.
+func contextNotFound(contextID uint64, err error) error {
+	var notFound *ContextNotFoundError
+	if err == nil || errors.As(err, &notFound) {
+		return err
+	}
+	var se *ServerError
+	if errors.As(err, &se) && se.Code == 404 && se.Detail == "context" {
+		return &ContextNotFoundError{ContextID: contextID, Err: err}
+	}
+	if errors.Is(err, ErrContextNotFound) {
+		return &ContextNotFoundError{ContextID: contextID, Err: err}
+	}
+	return err
+}
+
 // ServerError represents an error returned by the CXDB server.
 type ServerError struct {
 	Code   uint32
@@ -4,9 +4,48 @@
 package cxdb
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"unicode/utf8"
 )
 
+// EventContextClosed is the SSE event type announcing that a context has
+// reached a terminal state and will receive no further turns. FollowTurns
+// releases a context's state when it sees one.
+const EventContextClosed = "context_closed"
+
+// EventContextCreated is the SSE event type announcing a new context.
+// FollowTurns acts on it only with WithFollowNewContextsOnly.
+const EventContextCreated = "context_created"
+
+// EventContextMetadataUpdated is the SSE event type announcing a context's
+// title, labels, and client tag, sent once the server has extracted them
+// from its first turn and again if they change. FollowTurns acts on it only
+// with WithContextTitle or WithContextTitleRegexp.
+const EventContextMetadataUpdated = "context_metadata_updated"
+
+// EventTurnAppended is the SSE event type announcing a new turn in a context.
+// FollowTurns treats it as a hint to fetch the context's new turns.
+const EventTurnAppended = "turn_appended"
+
+// EventClientConnected is the SSE event type announcing that a binary
+// protocol client has connected.
+const EventClientConnected = "client_connected"
+
+// EventClientDisconnected is the SSE event type announcing that a binary
+// protocol client has disconnected.
+const EventClientDisconnected = "client_disconnected"
+
+// EventPreviewBytes is how many bytes of an event's data Event.String and
+// DumpEvent show before truncating it with "...". Zero or less shows all of
+// it. It is read on every call, so it may be changed at any time before
+// events are rendered, but not concurrently with rendering.
+var EventPreviewBytes = 120
+
 // ContextCreatedEvent represents a context_created SSE event payload.
 type ContextCreatedEvent struct {
 	ContextID uint64
@@ -24,6 +63,12 @@ type ContextMetadataUpdatedEvent struct {
 	Labels        []string
 }
 
+// ContextClosedEvent represents a context_closed SSE event payload.
+type ContextClosedEvent struct {
+	ContextID uint64
+	ClosedAt  int64
+}
+
 // TurnAppendedEvent represents a turn_appended SSE event payload.
 type TurnAppendedEvent struct {
 	ContextID           uint64
@@ -64,6 +109,11 @@ type contextMetadataUpdatedPayload struct {
 	Labels        []string  `json:"labels"`
 }
 
+type contextClosedPayload struct {
+	ContextID sseUint64 `json:"context_id"`
+	ClosedAt  sseInt64  `json:"closed_at"`
+}
+
 type turnAppendedPayload struct {
 	ContextID       sseUint64  `json:"context_id"`
 	TurnID          sseUint64  `json:"turn_id"`
@@ -113,6 +163,18 @@ func DecodeContextMetadataUpdated(data json.RawMessage) (ContextMetadataUpdatedE
 	}, nil
 }
 
+// DecodeContextClosed decodes a context_closed payload into a typed event.
+func DecodeContextClosed(data json.RawMessage) (ContextClosedEvent, error) {
+	var payload contextClosedPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return ContextClosedEvent{}, err
+	}
+	return ContextClosedEvent{
+		ContextID: payload.ContextID.Value,
+		ClosedAt:  payload.ClosedAt.Value,
+	}, nil
+}
+
 // DecodeTurnAppended decodes a turn_appended payload into a typed event.
 func DecodeTurnAppended(data json.RawMessage) (TurnAppendedEvent, error) {
 	var payload turnAppendedPayload
@@ -153,3 +215,87 @@ func DecodeClientDisconnected(data json.RawMessage) (ClientDisconnectedEvent, er
 	}
 	return ClientDisconnectedEvent(payload), nil
 }
+
+// String renders the event on one line for debugging: its type, its ID if
+// it has one, a partial marker, and its data truncated to EventPreviewBytes.
+func (e Event) String() string {
+	var b strings.Builder
+	b.WriteString(e.Type)
+	if e.Type == "" {
+		b.WriteString("message")
+	}
+	if e.ID != "" {
+		b.WriteString(" id=")
+		b.WriteString(e.ID)
+	}
+	if e.Partial {
+		b.WriteString(" partial")
+	}
+	b.WriteString(" data=")
+	b.WriteString(previewData(e.Data))
+	return b.String()
+}
+
+// DumpEvent writes a multi-line, human-readable form of e to w: the line
+// from Event.String, followed by one indented line per field of the typed
+// event when e.Type is recognized and its data decodes. Other events are
+// followed by their data as indented JSON, truncated to EventPreviewBytes.
+func DumpEvent(w io.Writer, e Event) error {
+	if _, err := fmt.Fprintln(w, e.String()); err != nil {
+		return err
+	}
+
+	if typed, ok := decodeTypedEvent(e); ok {
+		v := reflect.ValueOf(typed)
+		for i := 0; i < v.NumField(); i++ {
+			if _, err := fmt.Fprintf(w, "  %s: %v\n", v.Type().Field(i).Name, v.Field(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var pretty bytes.Buffer
+	if len(e.Data) == 0 || json.Indent(&pretty, e.Data, "  ", "  ") != nil {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "  %s\n", previewData(pretty.Bytes()))
+	return err
+}
+
+// decodeTypedEvent decodes e with the decoder for its type, reporting false
+// if the type is not recognized or the data does not decode.
+func decodeTypedEvent(e Event) (any, bool) {
+	var v any
+	var err error
+	switch e.Type {
+	case EventContextCreated:
+		v, err = DecodeContextCreated(e.Data)
+	case EventContextMetadataUpdated:
+		v, err = DecodeContextMetadataUpdated(e.Data)
+	case EventContextClosed:
+		v, err = DecodeContextClosed(e.Data)
+	case EventTurnAppended:
+		v, err = DecodeTurnAppended(e.Data)
+	case EventClientConnected:
+		v, err = DecodeClientConnected(e.Data)
+	case EventClientDisconnected:
+		v, err = DecodeClientDisconnected(e.Data)
+	default:
+		return nil, false
+	}
+	return v, err == nil
+}
+
+// previewData returns data as a string, cut to EventPreviewBytes on a rune
+// boundary.
+func previewData(data []byte) string {
+	max := EventPreviewBytes
+	if max <= 0 || len(data) <= max {
+		return string(data)
+	}
+	for max > 0 && !utf8.RuneStart(data[max]) {
+		max--
+	}
+	return string(data[:max]) + "..."
+}
@@ -5,7 +5,9 @@ package cxdb
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestDecodeContextCreated(t *testing.T) {
@@ -30,6 +32,22 @@ func TestDecodeContextCreated(t *testing.T) {
 	}
 }
 
+func TestDecodeContextClosed(t *testing.T) {
+	t.Parallel()
+
+	input := json.RawMessage(`{"context_id":"42","closed_at":1739481600000}`)
+	ev, err := DecodeContextClosed(input)
+	if err != nil {
+		t.Fatalf("DecodeContextClosed: %v", err)
+	}
+	if ev.ContextID != 42 {
+		t.Fatalf("ContextID = %d, want 42", ev.ContextID)
+	}
+	if ev.ClosedAt != 1739481600000 {
+		t.Fatalf("ClosedAt = %d, want 1739481600000", ev.ClosedAt)
+	}
+}
+
 func TestDecodeTurnAppendedOptionalFields(t *testing.T) {
 	t.Parallel()
 
@@ -45,3 +63,61 @@ func TestDecodeTurnAppendedOptionalFields(t *testing.T) {
 		t.Fatal("expected no declared type fields")
 	}
 }
+
+func TestEventString(t *testing.T) {
+	t.Parallel()
+
+	ev := Event{
+		Type: "turn_appended",
+		ID:   "7",
+		Data: json.RawMessage(`{"context_id":"1","turn_id":"2","parent_turn_id":"1","depth":1,"declared_type_id":"cxdb.ConversationItem"}`),
+	}
+	want := `turn_appended id=7 data={"context_id":"1","turn_id":"2","parent_turn_id":"1","depth":1,"declared_type_id":"cxdb.ConversationItem"}`
+	if got := ev.String(); got != want {
+		t.Fatalf("String() = %q, want %q", got, want)
+	}
+
+	long := Event{Data: json.RawMessage(`"` + strings.Repeat("é", EventPreviewBytes) + `"`), Partial: true}
+	got := long.String()
+	if !strings.HasPrefix(got, "message partial data=\"é") || !strings.HasSuffix(got, "...") {
+		t.Fatalf("String() = %q, want a truncated partial message", got)
+	}
+	if preview := strings.TrimSuffix(strings.TrimPrefix(got, "message partial data="), "..."); len(preview) > EventPreviewBytes || !utf8.ValidString(preview) {
+		t.Fatalf("preview %q is not cut to %d bytes on a rune boundary", preview, EventPreviewBytes)
+	}
+}
+
+func TestDumpEvent(t *testing.T) {
+	t.Parallel()
+
+	var b strings.Builder
+	err := DumpEvent(&b, Event{
+		Type: "turn_appended",
+		ID:   "7",
+		Data: json.RawMessage(`{"context_id":"1","turn_id":"2","parent_turn_id":"1","depth":1,"declared_type_id":"cxdb.ConversationItem"}`),
+	})
+	if err != nil {
+		t.Fatalf("DumpEvent: %v", err)
+	}
+	want := `turn_appended id=7 data={"context_id":"1","turn_id":"2","parent_turn_id":"1","depth":1,"declared_type_id":"cxdb.ConversationItem"}
+  ContextID: 1
+  TurnID: 2
+  ParentTurnID: 1
+  Depth: 1
+  DeclaredTypeID: cxdb.ConversationItem
+  DeclaredTypeVersion: 0
+  HasDeclaredTypeID: true
+  HasDeclaredTypeVer: false
+`
+	if b.String() != want {
+		t.Fatalf("DumpEvent output:\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	b.Reset()
+	if err := DumpEvent(&b, Event{Type: "custom", Data: json.RawMessage(`{"a":1}`)}); err != nil {
+		t.Fatalf("DumpEvent: %v", err)
+	}
+	if want := "custom data={\"a\":1}\n  {\n    \"a\": 1\n  }\n"; b.String() != want {
+		t.Fatalf("DumpEvent output = %q, want %q", b.String(), want)
+	}
+}
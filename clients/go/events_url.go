@@ -0,0 +1,140 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// EventQuery holds query parameters for an SSE events URL.
+type EventQuery struct {
+	// ContextIDs asks for the events of these contexts only. Encoded as a
+	// comma-separated context_ids parameter. Current servers ignore it;
+	// SubscribeAndFollow applies it on the client instead.
+	ContextIDs []uint64
+
+	// Types restricts the stream to these event types (e.g. "turn_appended").
+	// Encoded as a comma-separated types parameter.
+	Types []string
+
+	// Since resumes the stream after the given event ID. Encoded as since.
+	Since string
+
+	// Extra holds additional parameters. Values here are set after the known
+	// parameters above and replace them if the names collide.
+	Extra url.Values
+}
+
+// BuildEventsURL returns base with the parameters of q added to its query
+// string. base must be an absolute http or https URL. Existing query
+// parameters are kept unless q sets a parameter of the same name, in which
+// case q's value replaces them. Empty fields in q are omitted.
+//
+// Whether a parameter has any effect depends on the server; servers that do
+// not recognize a parameter ignore it and stream every event.
+func BuildEventsURL(base string, q EventQuery) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(base))
+	if err != nil {
+		return "", fmt.Errorf("cxdb events url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("cxdb events url: unsupported scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("cxdb events url: missing host")
+	}
+
+	values := u.Query()
+	if len(q.ContextIDs) > 0 {
+		ids := make([]string, len(q.ContextIDs))
+		for i, id := range q.ContextIDs {
+			ids[i] = strconv.FormatUint(id, 10)
+		}
+		values.Set("context_ids", strings.Join(ids, ","))
+	}
+	if len(q.Types) > 0 {
+		values.Set("types", strings.Join(q.Types, ","))
+	}
+	if q.Since != "" {
+		values.Set("since", q.Since)
+	}
+	for key, vals := range q.Extra {
+		values[key] = append([]string(nil), vals...)
+	}
+
+	u.RawQuery = values.Encode()
+	return u.String(), nil
+}
+
+// HTTPBaseURL returns the base URL of the server's HTTP API, without a
+// trailing slash, for use with HTTPContextMetadata, HTTPRecentContexts, and
+// BuildEventsURL. It is the URL given with WithHTTPBaseURL, or else the dial
+// host at DefaultHTTPPort, with http for Dial and https for DialTLS.
+func (c *Client) HTTPBaseURL() string {
+	return c.httpBase
+}
+
+// EventsURL returns the SSE endpoint, HTTPBaseURL plus /v1/events, for
+// following contextID with SubscribeAndFollow or SubscribeEvents. A contextID
+// of 0 returns the endpoint for every context. Use BuildEventsURL with
+// HTTPBaseURL for other parameters.
+//
+// The context is named in a context_ids parameter, but current servers
+// ignore it and stream the events of every context. SubscribeAndFollow
+// applies the parameter itself and follows only contextID; a caller of
+// SubscribeEvents receives every context's events and must filter them.
+func (c *Client) EventsURL(contextID uint64) string {
+	var q EventQuery
+	if contextID != 0 {
+		q.ContextIDs = []uint64{contextID}
+	}
+	// The base was validated by Dial, so this cannot fail.
+	u, _ := BuildEventsURL(c.httpBase+"/v1/events", q)
+	return u
+}
+
+// eventsURLContextIDs returns the IDs in the context_ids parameter of
+// eventsURL, skipping any that do not parse.
+func eventsURLContextIDs(eventsURL string) []uint64 {
+	u, err := url.Parse(strings.TrimSpace(eventsURL))
+	if err != nil {
+		return nil
+	}
+	var ids []uint64
+	for _, field := range strings.Split(u.Query().Get("context_ids"), ",") {
+		if id, err := strconv.ParseUint(strings.TrimSpace(field), 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// httpBaseURL returns override, validated and without a trailing slash, or
+// if it is empty the HTTP API URL assumed for a server dialed at addr.
+func httpBaseURL(addr, scheme, override string) (string, error) {
+	if override != "" {
+		u, err := url.Parse(strings.TrimSpace(override))
+		if err != nil {
+			return "", fmt.Errorf("http base url: %w", err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "", fmt.Errorf("http base url %q: want an absolute http or https URL", override)
+		}
+		u.RawQuery, u.Fragment = "", ""
+		return strings.TrimSuffix(u.String(), "/"), nil
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	return scheme + "://" + net.JoinHostPort(host, DefaultHTTPPort), nil
+}
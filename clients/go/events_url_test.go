@@ -0,0 +1,107 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestBuildEventsURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		base string
+		q    EventQuery
+		want string
+	}{
+		{
+			name: "no params",
+			base: "http://localhost:9010/v1/events",
+			want: "http://localhost:9010/v1/events",
+		},
+		{
+			name: "known params",
+			base: "https://cxdb.example.com/v1/events",
+			q: EventQuery{
+				ContextIDs: []uint64{1, 22},
+				Types:      []string{"turn_appended", "context_created"},
+				Since:      "evt 7",
+			},
+			want: "https://cxdb.example.com/v1/events?context_ids=1%2C22&since=evt+7&types=turn_appended%2Ccontext_created",
+		},
+		{
+			name: "existing query is kept and overridden",
+			base: "http://localhost:9010/v1/events?token=abc&since=old",
+			q: EventQuery{
+				Since: "new",
+				Extra: url.Values{"trace": {"1"}},
+			},
+			want: "http://localhost:9010/v1/events?since=new&token=abc&trace=1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := BuildEventsURL(tt.base, tt.q)
+			if err != nil {
+				t.Fatalf("BuildEventsURL: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("BuildEventsURL = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildEventsURLInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, base := range []string{"", "localhost:9010/v1/events", "ftp://host/events", "http:///events", "http://host/%zz"} {
+		if got, err := BuildEventsURL(base, EventQuery{}); err == nil {
+			t.Errorf("BuildEventsURL(%q) = %q, expected error", base, got)
+		}
+	}
+}
+
+func TestClientEventsURL(t *testing.T) {
+	t.Parallel()
+
+	addr := startStubServer(t, func(msgType uint16, payload []byte) (uint16, []byte) {
+		return msgError, encodeServerError(422, "unexpected")
+	})
+
+	client, err := Dial(addr)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+	if got, want := client.HTTPBaseURL(), "http://127.0.0.1:9010"; got != want {
+		t.Errorf("HTTPBaseURL = %q, want %q", got, want)
+	}
+	if got, want := client.EventsURL(42), "http://127.0.0.1:9010/v1/events?context_ids=42"; got != want {
+		t.Errorf("EventsURL = %q, want %q", got, want)
+	}
+	if got, want := client.EventsURL(0), "http://127.0.0.1:9010/v1/events"; got != want {
+		t.Errorf("EventsURL(0) = %q, want %q", got, want)
+	}
+
+	client, err = Dial(addr, WithHTTPBaseURL("https://gateway.example.com/cxdb/"))
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+	if got, want := client.EventsURL(7), "https://gateway.example.com/cxdb/v1/events?context_ids=7"; got != want {
+		t.Errorf("EventsURL = %q, want %q", got, want)
+	}
+
+	for _, base := range []string{"gateway.example.com", "ftp://gateway.example.com", "http://"} {
+		if _, err := Dial(addr, WithHTTPBaseURL(base)); err == nil {
+			t.Errorf("Dial with base %q: expected an error", base)
+		}
+	}
+}
@@ -4,10 +4,17 @@
 package cxdb
 
 import (
+	"container/list"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
 // TurnClient defines the subset of client methods needed by FollowTurns.
@@ -19,6 +26,31 @@ type TurnClient interface {
 type followOptions struct {
 	bufferSize        int
 	maxSeenPerContext int
+	initialBackfill   InitialBackfill
+	reorderSize       int
+	reorderTimeout    time.Duration
+	metrics           *Metrics
+	maxTrackedCtx     int
+	includePayload    bool
+	reusePayloads     bool
+	retry             FollowRetryPolicy
+	maxSyncs          int
+	assertOrdering    bool
+	labelFilter       *labelFilter
+	signalCompletion  bool
+	startupContexts   []uint64
+	startupList       ContextListFunc
+	appended          []*AppendResult
+	syncOrder         SyncOrder
+	errorThreshold    int
+	abandoned         chan<- uint64
+	newContextsOnly   bool
+	onlyContexts      map[uint64]bool
+	titleMatch        func(title string) bool
+	stateHook         func(map[uint64]FollowCheckpoint)
+	resume            map[uint64]FollowCheckpoint
+	clientFactory     func() (TurnClient, error)
+	clock             clock
 }
 
 // FollowOption configures FollowTurns behavior.
@@ -38,22 +70,514 @@ func WithMaxSeenPerContext(limit int) FollowOption {
 	}
 }
 
+// WithFollowIncludePayload controls whether FollowTurns requests turn payloads.
+// It defaults to true. Followers that only need turn metadata (IDs, depth,
+// declared type) can disable it to skip the payload transfer; emitted
+// FollowTurn.Turn.Payload is then empty, although PayloadHash is still set.
+func WithFollowIncludePayload(include bool) FollowOption {
+	return func(o *followOptions) {
+		o.includePayload = include
+	}
+}
+
+// WithPayloadReuse makes FollowTurns avoid transferring payloads it already
+// has. A sync normally fetches every turn after the last one emitted, with
+// payloads; while WithReorderBuffer is holding turns behind a gap, each
+// resync of the context therefore transfers the held payloads again, which
+// adds up when hints are redelivered after a reconnect. With reuse enabled,
+// such a sync first lists the turns without payloads and then fetches
+// payloads only from the oldest turn it does not hold onwards, taking the
+// held turns' payloads from the reorder buffer. A resync that finds the gap
+// still open then transfers only the payloads of turns appended since. The
+// sync that finds the gap filled still fetches the held turns again, since
+// GetLast can only fetch a window ending at the head and the missing turn
+// precedes them. Each resync of a context with held turns costs one extra
+// payload-free GetLast. Syncs of contexts with nothing held are unchanged,
+// since their window already starts after the last emitted turn. It has no
+// effect with WithFollowIncludePayload(false).
+func WithPayloadReuse(enabled bool) FollowOption {
+	return func(o *followOptions) {
+		o.reusePayloads = enabled
+	}
+}
+
+// WithMaxTrackedContexts bounds how many contexts FollowTurns keeps state
+// for. When a new context would exceed the limit, the state of the least
+// recently synced context is evicted. An evicted context that receives another
+// hint is treated as newly seen: it is backfilled again according to
+// WithInitialBackfill and its previously emitted turns may be emitted again.
+// Zero (the default) tracks contexts without limit.
+func WithMaxTrackedContexts(n int) FollowOption {
+	return func(o *followOptions) {
+		o.maxTrackedCtx = n
+	}
+}
+
+// withFollowClock replaces the clock used for reorder deadlines and retry
+// backoff. It is intended for tests.
+func withFollowClock(c clock) FollowOption {
+	return func(o *followOptions) {
+		o.clock = c
+	}
+}
+
+// WithFollowMetricsSink records aggregate follower counters (turns emitted,
+// decode and sync errors, gaps detected) into m.
+func WithFollowMetricsSink(m *Metrics) FollowOption {
+	return func(o *followOptions) {
+		o.metrics = m
+	}
+}
+
+// WithReorderBuffer holds back turns that arrive ahead of their predecessors.
+//
+// A hint can name a turn whose ancestors the server has not made queryable
+// yet, so GetLast returns a window with a depth gap. With a reorder buffer,
+// turns past the gap are held per context until the chain is contiguous from
+// the last emitted turn: either a later hint resyncs and finds the missing
+// predecessors, or timeout elapses. On timeout the context is resynced once
+// more and, if the gap persists, the held turns are emitted anyway and a
+// *GapError describing the skipped depths is sent on the error channel.
+// Holding more than size turns for one context triggers the same forced
+// flush immediately.
+//
+// A size of zero (the default) disables buffering and turns are emitted as
+// soon as they are fetched.
+func WithReorderBuffer(size int, timeout time.Duration) FollowOption {
+	return func(o *followOptions) {
+		o.reorderSize = size
+		o.reorderTimeout = timeout
+	}
+}
+
+// FollowRetryPolicy controls how FollowTurns retries a context sync that
+// failed, for example because GetHead returned a transient error.
+type FollowRetryPolicy struct {
+	// MaxAttempts is the number of retries after the initial failure. Zero
+	// disables retries.
+	MaxAttempts int
+
+	// InitialDelay is the delay before the first retry. It doubles after each
+	// further failure. Defaults to 500ms.
+	InitialDelay time.Duration
+
+	// MaxDelay caps the delay between retries. Defaults to 10s.
+	MaxDelay time.Duration
+}
+
+// WithFollowRetry retries failed context syncs with backoff instead of
+// dropping the hint that triggered them. Retries are scheduled, not waited
+// on, so a failing context does not hold up hints for other contexts. A
+// successful sync of the context, whether from a retry or a newer hint, clears
+// the retry state. Only the error from the final attempt is reported; errors
+// from attempts that are later retried are not sent on the error channel.
+// Gap errors and cancellation are never retried. When the events channel
+// closes, contexts awaiting a retry get one last immediate attempt.
+func WithFollowRetry(policy FollowRetryPolicy) FollowOption {
+	return func(o *followOptions) {
+		o.retry = policy
+	}
+}
+
+// SyncOrder selects how FollowTurns chooses which context to sync next.
+type SyncOrder uint8
+
+const (
+	// SyncOrderFIFO syncs contexts in the order their hints are read. With
+	// the default single sync at a time, each hint is acted on before the
+	// next one is read, so a burst of hints for one busy context delays
+	// every hint queued behind it.
+	SyncOrderFIFO SyncOrder = iota
+
+	// SyncOrderRoundRobin first reads every hint that has already arrived,
+	// up to a batch of 128, and queues each context with work once, then
+	// syncs the queued contexts in turn. A context that receives more hints
+	// while queued or syncing goes to the back of the queue, so a chatty
+	// context gets one sync per round rather than one per hint. As with
+	// WithMaxConcurrentSyncs, hints that are coalesced lead to a single
+	// sync: a hint read together with a context_closed event for the same
+	// context is served by the final sync rather than restarting the
+	// context, and a failed sync is not repeated for the hints merged into
+	// it unless WithFollowRetry is set.
+	SyncOrderRoundRobin
+)
+
+// maxIntakeBatch bounds how many events SyncOrderRoundRobin reads before
+// starting a sync, so a flood of events cannot hold syncs off indefinitely.
+const maxIntakeBatch = 128
+
+// WithContextSyncOrder sets how contexts with pending hints share sync
+// capacity. The default is SyncOrderFIFO. SyncOrderRoundRobin keeps one
+// context that is appended to constantly from starving the others, which
+// matters most for a follower of every context with a small
+// WithMaxConcurrentSyncs limit. Contexts named by WithStartupContexts are
+// queued in the same way, so with round-robin order events may be read
+// before the startup syncs finish.
+func WithContextSyncOrder(order SyncOrder) FollowOption {
+	return func(o *followOptions) {
+		o.syncOrder = order
+	}
+}
+
+// WithMaxConcurrentSyncs lets FollowTurns sync up to n different contexts at
+// the same time, bounding the GetHead/GetLast round trips it has in flight
+// against the server no matter how many contexts have pending hints.
+//
+// A context never has more than one sync in flight, so its own turns are still
+// emitted in order. Hints for a context that is already syncing, or waiting
+// for a free slot, are coalesced into a single further sync that starts after
+// the current one. Contexts that are waiting for a slot are served in the
+// order they became ready. Turns from different contexts may interleave on the
+// output channel in any order.
+//
+// n <= 1 (the default) syncs one context at a time on the goroutine reading
+// events, so a slow sync also delays reading further hints.
+func WithMaxConcurrentSyncs(n int) FollowOption {
+	return func(o *followOptions) {
+		o.maxSyncs = n
+	}
+}
+
+// WithFollowClientFactory makes FollowTurns sync contexts through clients
+// obtained from fn instead of the client passed to it, which may then be nil.
+// Combined with WithMaxConcurrentSyncs, each sync in flight has a client, and
+// so a connection, of its own, so syncs of different contexts make their RPCs
+// in parallel instead of queueing on one connection.
+//
+// Clients are pooled: a sync takes an idle client if there is one and
+// otherwise calls fn, so there are never more clients than concurrent syncs.
+// fn is called on the goroutine running the sync and may be called from
+// several at once. If it fails, the sync fails with its error, like any other
+// sync error.
+//
+// FollowTurns owns the clients fn returns. A client whose sync fails is
+// dropped rather than reused, in case its connection is broken, and the rest
+// are dropped when FollowTurns stops, after its last sync has finished. A
+// dropped client that implements io.Closer, as *Client does, is closed.
+func WithFollowClientFactory(fn func() (TurnClient, error)) FollowOption {
+	return func(o *followOptions) {
+		o.clientFactory = fn
+	}
+}
+
+// WithFollowErrorThreshold gives up on a context after n consecutive syncs
+// of it have failed, so one context that can never be synced, for example
+// because every GetLast for it fails to decode, does not keep costing RPCs
+// and flooding the error channel. A failure is a sync whose error is
+// reported on the error channel, including a *GapError; with
+// WithFollowRetry, a sync counts once, after its final retry. Any
+// successful sync resets the count.
+//
+// When a context reaches the threshold its state is released, an error
+// wrapping ErrContextAbandoned is reported, and its ID is sent on abandoned
+// if that is not nil. FollowTurns does not block sending on abandoned, so it
+// should be buffered. For the rest of the FollowTurns call every hint and
+// event for an abandoned context is ignored. n <= 0 (the default) never
+// abandons a context.
+func WithFollowErrorThreshold(n int, abandoned chan<- uint64) FollowOption {
+	return func(o *followOptions) {
+		o.errorThreshold = n
+		o.abandoned = abandoned
+	}
+}
+
+// WithFollowNewContextsOnly makes FollowTurns ignore contexts that existed
+// before it started. Only contexts FollowTurns sees an EventContextCreated
+// event for, plus any named by WithStartupContexts, WithStartupContextList,
+// or WithAppendedTurns, are followed; turn_appended hints for every other
+// context are dropped without an RPC. The event stream must therefore carry
+// context_created events as well as turn_appended ones.
+//
+// A hint can arrive before its context's context_created event, for example
+// when the stream reconnected between them or the events were relayed out
+// of order. Such a hint is dropped, and its turn is emitted by the context's
+// next sync instead, which with the default BackfillFull includes it; with a
+// narrower WithInitialBackfill it may be skipped. A context is forgotten once
+// its context_closed event has been handled, so the set of created contexts
+// does not grow without bound.
+func WithFollowNewContextsOnly(only bool) FollowOption {
+	return func(o *followOptions) {
+		o.newContextsOnly = only
+	}
+}
+
+// WithFollowContexts follows only the given contexts: turn_appended hints for
+// every other context are dropped without an RPC. It may be given more than
+// once, and the IDs add up; with no IDs it has no effect. Contexts named by
+// WithStartupContexts, WithStartupContextList, or WithAppendedTurns are
+// seeded or synced at startup as usual, but later hints for them are
+// dropped unless they are listed here too.
+//
+// It is the client-side counterpart of a context_ids filter on the events
+// URL, which current servers ignore; SubscribeAndFollow applies it from the
+// URL itself.
+func WithFollowContexts(contextIDs ...uint64) FollowOption {
+	return func(o *followOptions) {
+		if len(contextIDs) == 0 {
+			return
+		}
+		if o.onlyContexts == nil {
+			o.onlyContexts = make(map[uint64]bool, len(contextIDs))
+		}
+		for _, id := range contextIDs {
+			o.onlyContexts[id] = true
+		}
+	}
+}
+
+// follows reports whether contextID passes WithFollowContexts.
+func (o *followOptions) follows(contextID uint64) bool {
+	return o.onlyContexts == nil || o.onlyContexts[contextID]
+}
+
+// WithContextTitle follows only contexts whose title contains substr. See
+// WithContextTitleRegexp.
+func WithContextTitle(substr string) FollowOption {
+	return func(o *followOptions) {
+		o.titleMatch = func(title string) bool { return strings.Contains(title, substr) }
+	}
+}
+
+// WithContextTitleRegexp follows only contexts whose title matches re.
+//
+// Titles are learned from EventContextMetadataUpdated events, so the event
+// stream must carry them as well as turn_appended ones; SubscribeAndFollow
+// passes them through. turn_appended hints for a context are dropped without
+// an RPC until a metadata event gives it a matching title. Contexts named by
+// WithStartupContexts, WithStartupContextList, or WithAppendedTurns are
+// seeded or synced at startup as usual, but later hints for them need a
+// matching title too.
+//
+// A context's title can change mid-stream, and each metadata event is
+// matched again. A context whose title comes to match is synced at once, so
+// a new context's first turns and a renamed context's history, as far as
+// WithInitialBackfill allows, are emitted. One whose title stops matching is
+// no longer synced, though a sync already under way finishes. Its state is
+// kept, so if its title matches again it resumes after the last turn emitted
+// and the turns appended in the meantime are emitted then. Titles are
+// forgotten when a context_closed event is handled.
+func WithContextTitleRegexp(re *regexp.Regexp) FollowOption {
+	return func(o *followOptions) {
+		o.titleMatch = re.MatchString
+	}
+}
+
+// WithAssertOrdering controls what FollowTurns does when GetLast returns turns
+// that are not in ascending depth order. By default they are sorted by depth,
+// with turn ID breaking ties, before being emitted. When strict is true the
+// sync fails instead with an error wrapping ErrInvalidResponse, so a server
+// ordering bug is surfaced rather than papered over.
+func WithAssertOrdering(strict bool) FollowOption {
+	return func(o *followOptions) {
+		o.assertOrdering = strict
+	}
+}
+
+// LabelFilterOnError selects what WithFollowLabelFilter does with a context
+// whose metadata cannot be fetched.
+type LabelFilterOnError uint8
+
+const (
+	// LabelFilterSkip stops following the context, as if it lacked the label.
+	LabelFilterSkip LabelFilterOnError = iota
+
+	// LabelFilterRetry leaves the context undecided, so the next hint for it
+	// fetches the metadata again.
+	LabelFilterRetry
+)
+
+type labelFilter struct {
+	label   string
+	fetch   ContextMetadataFunc
+	onError LabelFilterOnError
+}
+
+// WithFollowLabelFilter follows only contexts whose metadata carries label.
+// The first time a context is synced its metadata is looked up with fetch,
+// for example one returned by HTTPContextMetadata, and the decision is cached
+// in the context's state; later hints for an excluded context cost no RPCs.
+// A context evicted by WithMaxTrackedContexts is looked up again when it is
+// next seen.
+//
+// A failed lookup is reported on the error channel and then handled according
+// to onError. The server records metadata from a context's first turn, so a
+// context whose first turn carries no metadata never matches.
+func WithFollowLabelFilter(label string, fetch ContextMetadataFunc, onError LabelFilterOnError) FollowOption {
+	return func(o *followOptions) {
+		o.labelFilter = &labelFilter{label: label, fetch: fetch, onError: onError}
+	}
+}
+
+// WithCompletionSignal controls whether FollowTurns emits a marker when a
+// context is closed. When enabled, a FollowTurn with Completed set and a zero
+// Turn is sent after the last turn of a context that FollowTurns sees an
+// EventContextClosed event for. The context's state is released on a
+// context_closed event either way; this option only adds the marker.
+func WithCompletionSignal(emit bool) FollowOption {
+	return func(o *followOptions) {
+		o.signalCompletion = emit
+	}
+}
+
+// WithAppendedTurns seeds FollowTurns with turns the caller appended itself:
+// each turn and everything before it in its context count as already emitted.
+// A writer that appends and then follows its own context therefore sees only
+// the turns that come after, without a backfill of what it just wrote. It
+// takes precedence over WithInitialBackfill for those contexts. Nil results,
+// as returned by a failed AppendTurn, are ignored.
+func WithAppendedTurns(results ...*AppendResult) FollowOption {
+	return func(o *followOptions) {
+		for _, result := range results {
+			if result != nil {
+				o.appended = append(o.appended, result)
+			}
+		}
+	}
+}
+
+// WithStartupContexts makes FollowTurns sync the given contexts as soon as it
+// starts, before acting on any event, so their existing history is emitted
+// (according to WithInitialBackfill) without waiting for a new turn to be
+// hinted. The startup syncs go through the same limit as every other sync:
+// by default the contexts are backfilled one at a time, and with
+// WithMaxConcurrentSyncs(n) up to n of them are backfilled in parallel. See
+// FollowTurns for how backfilled and live turns are ordered.
+func WithStartupContexts(contextIDs ...uint64) FollowOption {
+	return func(o *followOptions) {
+		o.startupContexts = append(o.startupContexts, contextIDs...)
+	}
+}
+
+// WithStartupContextList is like WithStartupContexts, but the contexts are
+// listed by fn when FollowTurns starts, for example with HTTPRecentContexts.
+// The listed contexts are added to any given with WithStartupContexts. If fn
+// fails the error is reported on the error channel and following starts with
+// the remaining contexts.
+func WithStartupContextList(fn ContextListFunc) FollowOption {
+	return func(o *followOptions) {
+		o.startupList = fn
+	}
+}
+
+// WithInitialBackfill controls how much existing history is emitted the first
+// time a context is seen. The default is BackfillFull.
+func WithInitialBackfill(mode InitialBackfill) FollowOption {
+	return func(o *followOptions) {
+		o.initialBackfill = mode
+	}
+}
+
+type backfillMode uint8
+
+const (
+	backfillFull backfillMode = iota
+	backfillNone
+	backfillLastN
+)
+
+// InitialBackfill selects how FollowTurns treats history that already exists
+// when it first sees a context.
+type InitialBackfill struct {
+	mode backfillMode
+	n    uint32
+}
+
+var (
+	// BackfillFull emits the entire history of a context on first sight.
+	BackfillFull = InitialBackfill{mode: backfillFull}
+
+	// BackfillNone starts following from the current head. Turns that exist
+	// when the context is first seen, including the one that triggered the
+	// hint, are not emitted; only turns appended afterwards are.
+	BackfillNone = InitialBackfill{mode: backfillNone}
+)
+
+// BackfillLastN emits at most the n most recent turns on first sight.
+// BackfillLastN(0) behaves like BackfillNone.
+func BackfillLastN(n uint32) InitialBackfill {
+	return InitialBackfill{mode: backfillLastN, n: n}
+}
+
+// limit returns how many turns to fetch for a context first seen at headDepth.
+func (b InitialBackfill) limit(headDepth uint32) uint32 {
+	total := headDepth + 1
+	switch b.mode {
+	case backfillNone:
+		return 0
+	case backfillLastN:
+		if b.n < total {
+			return b.n
+		}
+		return total
+	default:
+		return total
+	}
+}
+
 const (
 	defaultFollowBuffer      = 128
 	defaultMaxSeenPerContext = 2048
+	defaultReorderTimeout    = 2 * time.Second
 )
 
+// GapError reports that turns were emitted although the turns between
+// FromDepth and ToDepth (inclusive) were never observed.
+type GapError struct {
+	ContextID uint64
+	FromDepth uint32
+	ToDepth   uint32
+}
+
+func (e *GapError) Error() string {
+	return fmt.Sprintf("follow turns: gap in context %d (depth %d-%d missing)", e.ContextID, e.FromDepth, e.ToDepth)
+}
+
 // FollowTurn combines a turn record with its context ID.
 type FollowTurn struct {
 	ContextID uint64
 	Turn      TurnRecord
+
+	// Seq numbers the turns emitted for ContextID, starting at 1 and
+	// increasing by one with each turn. Unlike turn IDs and depths it has no
+	// gaps, so a consumer that sees Seq jump has missed an emission. It
+	// restarts at 1 if the context's state is released, by
+	// WithMaxTrackedContexts or a context_closed event, and the context is
+	// seen again.
+	Seq uint64
+
+	// Completed marks the end of a closed context rather than a turn; Turn is
+	// zero. It is only sent with WithCompletionSignal(true), after every turn
+	// FollowTurns emits for the context.
+	Completed bool
 }
 
 // FollowTurns converts turn_appended SSE hints into ordered turn streams.
+//
+// The binary protocol is strictly request/response and has no message for
+// pushing new turns to a client, so following a context always combines the
+// SSE event stream (for notification) with GetHead/GetLast (for the turns
+// themselves). Each hint costs at most one GetHead and one GetLast round trip.
+//
+// Contexts named by WithStartupContexts or WithStartupContextList are synced
+// before the first event is acted on. A context's backfilled turns are always
+// emitted before its live turns, since a context never has more than one sync
+// in flight, and hints for turns the backfill already emitted are dropped by
+// the usual per-context dedupe. Turns appended while the backfill runs are
+// emitted when their hints are processed. With WithMaxConcurrentSyncs the
+// startup syncs share the same slots as hint-driven ones, so events may be
+// acted on while they run.
+//
+// An EventContextClosed event triggers one final sync of the context, which
+// also flushes any turns held by WithReorderBuffer, and then releases its
+// state. Hints that arrive for the context afterwards are treated as the
+// first sight of a new context.
 func FollowTurns(ctx context.Context, events <-chan Event, client TurnClient, opts ...FollowOption) (<-chan FollowTurn, <-chan error) {
 	options := followOptions{
 		bufferSize:        defaultFollowBuffer,
 		maxSeenPerContext: defaultMaxSeenPerContext,
+		initialBackfill:   BackfillFull,
+		includePayload:    true,
+		clock:             realClock{},
 	}
 	for _, opt := range opts {
 		opt(&options)
@@ -61,43 +585,650 @@ func FollowTurns(ctx context.Context, events <-chan Event, client TurnClient, op
 
 	out := make(chan FollowTurn, options.bufferSize)
 	errs := make(chan error, options.bufferSize)
-	states := make(map[uint64]*followState)
+	states := newFollowStates(&options)
+	syncs := newSyncScheduler(ctx, client, out, errs, states, &options)
 
 	go func() {
 		defer close(out)
 		defer close(errs)
+		defer states.finalHook()
+		defer syncs.closeClients()
+		defer syncs.wg.Wait()
 
-		for {
-			select {
-			case <-ctx.Done():
+		timer := options.clock.NewTimer(time.Hour)
+		timer.Stop()
+		defer timer.Stop()
+
+		for _, contextID := range resumeContexts(states, options.resume) {
+			if ctx.Err() != nil {
 				return
-			case ev, ok := <-events:
-				if !ok {
-					return
+			}
+			syncs.request(contextID, states.get(contextID), jobSync)
+		}
+		for _, result := range options.appended {
+			states.created[result.ContextID] = true
+			states.get(result.ContextID).markHead(result.Head())
+		}
+		for _, contextID := range startupContexts(ctx, &options, errs) {
+			if ctx.Err() != nil {
+				return
+			}
+			states.created[contextID] = true
+			syncs.request(contextID, states.get(contextID), jobSync)
+		}
+
+		alwaysReady := make(chan struct{})
+		close(alwaysReady)
+
+		// handleEvent acts on one event, or on the end of the event stream if
+		// ok is false. It returns false when FollowTurns should stop.
+		handleEvent := func(ev Event, ok bool) bool {
+			if !ok {
+				if !syncs.drain() {
+					return false
+				}
+				for contextID, state := range states.byID {
+					if state.retrying() {
+						state.retryAttempts = state.retry.MaxAttempts
+						syncs.run(contextID, state, jobSync)
+					}
+					if state.hasPending() {
+						syncs.run(contextID, state, jobFlush)
+					}
+				}
+				return false
+			}
+			if ev.Type == EventContextClosed {
+				closed, err := decodeContextClosed(ev.Data)
+				if err != nil {
+					options.metrics.incDecodeErrors()
+					nonBlockingSend(errs, err)
+					return true
 				}
-				if ev.Type != "turn_appended" {
-					continue
+				if state, ok := states.byID[closed.ContextID]; ok {
+					syncs.close(closed.ContextID, state)
 				}
-				turnEvent, err := decodeTurnAppended(ev.Data)
+				delete(states.created, closed.ContextID)
+				delete(states.titled, closed.ContextID)
+				return true
+			}
+			if ev.Type == EventContextMetadataUpdated && options.titleMatch != nil {
+				updated, err := decodeContextMetadataUpdated(ev.Data)
 				if err != nil {
+					options.metrics.incDecodeErrors()
 					nonBlockingSend(errs, err)
-					continue
+					return true
+				}
+				contextID := updated.ContextID
+				matched := options.titleMatch(updated.Title)
+				wasMatched := states.titled[contextID]
+				states.titled[contextID] = matched
+				if !matched || wasMatched || states.abandoned[contextID] || !options.follows(contextID) {
+					return true
 				}
-				state := states[turnEvent.ContextID]
-				if state == nil {
-					state = newFollowState(options.maxSeenPerContext)
-					states[turnEvent.ContextID] = state
+				if options.newContextsOnly && !states.created[contextID] {
+					return true
 				}
-				if err := state.syncContext(ctx, client, turnEvent.ContextID, out); err != nil {
+				syncs.request(contextID, states.get(contextID), jobSync)
+				return true
+			}
+			if ev.Type == EventContextCreated && options.newContextsOnly {
+				created, err := decodeContextCreated(ev.Data)
+				if err != nil {
+					options.metrics.incDecodeErrors()
 					nonBlockingSend(errs, err)
+					return true
 				}
+				states.created[created.ContextID] = true
+				return true
+			}
+			if ev.Type != EventTurnAppended {
+				return true
+			}
+			turnEvent, err := decodeTurnAppended(ev.Data)
+			if err != nil {
+				options.metrics.incDecodeErrors()
+				nonBlockingSend(errs, err)
+				return true
+			}
+			if states.abandoned[turnEvent.ContextID] || !options.follows(turnEvent.ContextID) {
+				return true
 			}
+			if options.newContextsOnly && !states.created[turnEvent.ContextID] {
+				return true
+			}
+			if options.titleMatch != nil && !states.titled[turnEvent.ContextID] {
+				return true
+			}
+			state := states.get(turnEvent.ContextID)
+			if !state.busy && (state.coversHint(turnEvent) || state.excluded()) {
+				return true
+			}
+			syncs.request(turnEvent.ContextID, state, jobSync)
+			return true
+		}
+
+		for {
+			if syncs.ready() {
+				// Round-robin: take in the hints that have already arrived, so
+				// every context with one is queued, then start the next sync.
+			intake:
+				for i := 0; i < maxIntakeBatch; i++ {
+					select {
+					case <-ctx.Done():
+						return
+					case ev, ok := <-events:
+						if !handleEvent(ev, ok) {
+							return
+						}
+					default:
+						break intake
+					}
+				}
+				syncs.dispatch()
+			}
+
+			var reorderC <-chan time.Time
+			if deadline, ok := states.nextDeadline(); ok {
+				timer.Reset(deadline.Sub(options.clock.Now()))
+				reorderC = timer.C()
+			}
+
+			// A waiting round-robin sync must not wait for the next event.
+			var readyC <-chan struct{}
+			if syncs.ready() {
+				readyC = alwaysReady
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case result := <-syncs.results:
+				syncs.finish(result)
+			case <-readyC:
+			case <-reorderC:
+				now := options.clock.Now()
+				for contextID, state := range states.byID {
+					if state.busy {
+						continue
+					}
+					if state.hasPending() && !now.Before(state.pendingDeadline) {
+						syncs.request(contextID, state, jobFlush)
+					}
+					if state.retrying() && !now.Before(state.retryAt) {
+						syncs.request(contextID, state, jobSync)
+					}
+				}
+			case ev, ok := <-events:
+				if !handleEvent(ev, ok) {
+					return
+				}
+			}
+
+			if reorderC != nil && !timer.Stop() {
+				select {
+				case <-timer.C():
+				default:
+				}
+			}
+			states.callHook()
 		}
 	}()
 
 	return out, errs
 }
 
+// startupContexts returns the contexts to sync when FollowTurns starts, in the
+// order given, without duplicates.
+func startupContexts(ctx context.Context, options *followOptions, errs chan<- error) []uint64 {
+	ids := options.startupContexts
+	if options.startupList != nil {
+		listed, err := options.startupList(ctx)
+		if err != nil {
+			nonBlockingSend(errs, fmt.Errorf("follow turns: list startup contexts: %w", err))
+		}
+		ids = append(append([]uint64(nil), ids...), listed...)
+	}
+
+	seen := make(map[uint64]bool, len(ids))
+	unique := ids[:0:0]
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// reportSyncError counts a sync failure (or detected gap) and forwards it.
+func reportSyncError(m *Metrics, errs chan<- error, err error) {
+	var gap *GapError
+	if errors.As(err, &gap) {
+		m.incGapsDetected()
+	} else if !errors.Is(err, context.Canceled) {
+		m.incSyncErrors()
+	}
+	nonBlockingSend(errs, err)
+}
+
+// syncJob is the work a context sync performs. When requests for the same
+// context are coalesced the larger job wins, since a flush also resyncs.
+type syncJob uint8
+
+const (
+	jobNone syncJob = iota
+	jobSync
+	jobFlush
+)
+
+type syncResult struct {
+	contextID uint64
+	state     *followState
+	job       syncJob
+	err       error
+	client    TurnClient // taken from the pool, for WithFollowClientFactory
+}
+
+// syncScheduler runs context syncs for FollowTurns, at most limit at a time
+// and never more than one per context. All methods are called from the
+// FollowTurns goroutine; only the syncs themselves run elsewhere.
+type syncScheduler struct {
+	ctx      context.Context
+	client   TurnClient
+	factory  func() (TurnClient, error) // WithFollowClientFactory
+	idle     []TurnClient               // pooled clients made by factory
+	out      chan<- FollowTurn
+	errs     chan<- error
+	states   *followStates
+	metrics  *Metrics
+	limit    int
+	complete bool // send a Completed marker for closed contexts
+	fair     bool // SyncOrderRoundRobin: queue every request, see dispatch
+
+	threshold int           // WithFollowErrorThreshold
+	abandoned chan<- uint64 // notified of abandoned contexts, may be nil
+
+	inflight int
+	waiting  []syncResult // contexts ready to sync, oldest first
+	results  chan syncResult
+	wg       sync.WaitGroup
+}
+
+func newSyncScheduler(ctx context.Context, client TurnClient, out chan<- FollowTurn, errs chan<- error, states *followStates, options *followOptions) *syncScheduler {
+	limit := options.maxSyncs
+	if limit < 1 {
+		limit = 1
+	}
+	return &syncScheduler{
+		ctx:      ctx,
+		client:   client,
+		factory:  options.clientFactory,
+		out:      out,
+		errs:     errs,
+		states:   states,
+		metrics:  options.metrics,
+		limit:    limit,
+		complete: options.signalCompletion,
+		fair:     options.syncOrder == SyncOrderRoundRobin,
+		results:  make(chan syncResult, limit),
+
+		threshold: options.errorThreshold,
+		abandoned: options.abandoned,
+	}
+}
+
+// request asks for job to run for a context. It starts immediately if a slot
+// is free; otherwise it is merged into whatever the context already has queued.
+func (s *syncScheduler) request(contextID uint64, state *followState, job syncJob) {
+	queued := state.queued != jobNone
+	if job > state.queued {
+		state.queued = job
+	}
+	if state.busy || queued {
+		return
+	}
+	if s.fair || s.inflight >= s.limit {
+		s.waiting = append(s.waiting, syncResult{contextID: contextID, state: state})
+		return
+	}
+	s.start(contextID, state)
+}
+
+func (s *syncScheduler) start(contextID uint64, state *followState) {
+	job := state.queued
+	state.queued = jobNone
+	if s.limit == 1 {
+		s.run(contextID, state, job)
+		return
+	}
+
+	state.busy = true
+	s.inflight++
+	s.wg.Add(1)
+	client := s.takeClient()
+	go func() {
+		defer s.wg.Done()
+		client, err := s.runJob(state, client, contextID, job)
+		s.results <- syncResult{contextID: contextID, state: state, job: job, err: err, client: client}
+	}()
+}
+
+// run performs job on the calling goroutine.
+func (s *syncScheduler) run(contextID uint64, state *followState, job syncJob) {
+	client, err := s.runJob(state, s.takeClient(), contextID, job)
+	s.finish(syncResult{contextID: contextID, state: state, job: job, err: err, client: client})
+}
+
+// runJob performs job with client, first making a client with the factory
+// if client is nil. It returns the client it used, if any.
+func (s *syncScheduler) runJob(state *followState, client TurnClient, contextID uint64, job syncJob) (TurnClient, error) {
+	if client == nil {
+		var err error
+		client, err = s.factory()
+		if err != nil {
+			return nil, fmt.Errorf("follow turns: context %d: new client: %w", contextID, err)
+		}
+	}
+	return client, state.runJob(s.ctx, client, contextID, job, s.out)
+}
+
+// takeClient returns the client for a sync to use: the one FollowTurns was
+// given or, with WithFollowClientFactory, an idle one from the pool. It
+// returns nil if the pool is empty and the sync must make its own.
+func (s *syncScheduler) takeClient() TurnClient {
+	if s.factory == nil {
+		return s.client
+	}
+	n := len(s.idle)
+	if n == 0 {
+		return nil
+	}
+	client := s.idle[n-1]
+	s.idle = s.idle[:n-1]
+	return client
+}
+
+// putClient returns the client a sync used to the pool, or drops it if the
+// sync failed.
+func (s *syncScheduler) putClient(client TurnClient, err error) {
+	if s.factory == nil || client == nil {
+		return
+	}
+	if err != nil {
+		closeTurnClient(client)
+		return
+	}
+	s.idle = append(s.idle, client)
+}
+
+// closeClients drops every pooled client once no sync is running, including
+// those held by results that were never finished.
+func (s *syncScheduler) closeClients() {
+drain:
+	for {
+		select {
+		case r := <-s.results:
+			if r.client != nil && s.factory != nil {
+				closeTurnClient(r.client)
+			}
+		default:
+			break drain
+		}
+	}
+	for _, client := range s.idle {
+		closeTurnClient(client)
+	}
+	s.idle = nil
+}
+
+// closeTurnClient closes a client made by WithFollowClientFactory if it can be
+// closed.
+func closeTurnClient(client TurnClient) {
+	if closer, ok := client.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
+
+// finish reports the outcome of a sync and hands its slot to the next waiting
+// context.
+func (s *syncScheduler) finish(r syncResult) {
+	s.putClient(r.client, r.err)
+	if r.state.busy {
+		r.state.busy = false
+		s.inflight--
+	}
+	s.states.recordCheckpoint(r.contextID, r.state)
+
+	err := r.err
+	if r.job == jobSync {
+		err = r.state.scheduleRetry(r.contextID, err)
+	}
+	if err != nil {
+		reportSyncError(s.metrics, s.errs, err)
+	}
+
+	if s.failedTooOften(r.state, r.err, err) {
+		s.abandon(r.contextID, r.state)
+	} else if r.state.queued != jobNone {
+		s.waiting = append(s.waiting, syncResult{contextID: r.contextID, state: r.state})
+	} else if r.state.closing && !r.state.retrying() {
+		s.release(r.contextID)
+	}
+	if s.fair {
+		return
+	}
+	for s.inflight < s.limit && len(s.waiting) > 0 {
+		next := s.waiting[0]
+		s.waiting = s.waiting[1:]
+		s.start(next.contextID, next.state)
+	}
+}
+
+// ready reports whether a round-robin sync is waiting for a free slot.
+func (s *syncScheduler) ready() bool {
+	return s.fair && s.inflight < s.limit && len(s.waiting) > 0
+}
+
+// dispatch starts waiting syncs while slots are free. A sync that runs inline
+// is the only one started, so the caller can read more events before the
+// next.
+func (s *syncScheduler) dispatch() {
+	for s.inflight < s.limit && len(s.waiting) > 0 {
+		next := s.waiting[0]
+		s.waiting = s.waiting[1:]
+		s.start(next.contextID, next.state)
+		if s.limit == 1 {
+			return
+		}
+	}
+}
+
+// failedTooOften records the outcome of a sync for WithFollowErrorThreshold,
+// given the error the sync returned and the error reported for it, and
+// reports whether the context has now failed too many times in a row.
+func (s *syncScheduler) failedTooOften(state *followState, syncErr, reported error) bool {
+	if s.threshold <= 0 {
+		return false
+	}
+	if syncErr == nil {
+		state.failures = 0
+		return false
+	}
+	if reported == nil || errors.Is(reported, context.Canceled) {
+		return false
+	}
+	state.failures++
+	return state.failures >= s.threshold
+}
+
+// abandon releases a context that failed too often and ignores it from now
+// on. The context has no sync running or waiting: finish is its only caller,
+// and a busy context is never put in the waiting queue.
+func (s *syncScheduler) abandon(contextID uint64, state *followState) {
+	state.queued = jobNone
+	s.states.evict(contextID)
+	s.states.abandoned[contextID] = true
+	nonBlockingSend(s.errs, fmt.Errorf("follow turns: context %d: %w after %d consecutive sync errors", contextID, ErrContextAbandoned, state.failures))
+	if s.abandoned != nil {
+		select {
+		case s.abandoned <- contextID:
+		default:
+		}
+	}
+}
+
+// close runs a final sync for a context that has been closed. Its state is
+// released once that sync, and any retries of it, have finished.
+func (s *syncScheduler) close(contextID uint64, state *followState) {
+	if state.excluded() {
+		s.states.evict(contextID)
+		return
+	}
+	state.closing = true
+	s.request(contextID, state, jobFlush)
+}
+
+// release forgets a closed context and, with WithCompletionSignal, sends its
+// Completed marker.
+func (s *syncScheduler) release(contextID uint64) {
+	s.states.evict(contextID)
+	if !s.complete {
+		return
+	}
+	select {
+	case <-s.ctx.Done():
+	case s.out <- FollowTurn{ContextID: contextID, Completed: true}:
+	}
+}
+
+// drain waits until no syncs are running or waiting. It returns false if ctx
+// is canceled first.
+func (s *syncScheduler) drain() bool {
+	for s.inflight > 0 || len(s.waiting) > 0 {
+		if s.inflight < s.limit && len(s.waiting) > 0 {
+			s.dispatch()
+			continue
+		}
+		select {
+		case <-s.ctx.Done():
+			return false
+		case r := <-s.results:
+			s.finish(r)
+		}
+	}
+	return true
+}
+
+// followStates tracks per-context follow state, evicting the least recently
+// synced context when maxTracked is exceeded.
+type followStates struct {
+	options    *followOptions
+	byID       map[uint64]*followState
+	recent     *list.List // context IDs, most recently synced first
+	maxTracked int
+	abandoned  map[uint64]bool // contexts given up on by WithFollowErrorThreshold
+	created    map[uint64]bool // contexts seen created, for WithFollowNewContextsOnly
+	titled     map[uint64]bool // whether each context's title matches, for WithContextTitle
+
+	// checkpoints holds the last checkpoint of every tracked context, for
+	// WithFollowStateHook; checkpointsDirty is set when one has changed
+	// since the hook was last called, at lastHook.
+	checkpoints      map[uint64]FollowCheckpoint
+	checkpointsDirty bool
+	lastHook         time.Time
+}
+
+func newFollowStates(options *followOptions) *followStates {
+	return &followStates{
+		options:    options,
+		byID:       make(map[uint64]*followState),
+		recent:     list.New(),
+		maxTracked: options.maxTrackedCtx,
+		abandoned:  make(map[uint64]bool),
+		created:    make(map[uint64]bool),
+		titled:     make(map[uint64]bool),
+
+		checkpoints: make(map[uint64]FollowCheckpoint),
+	}
+}
+
+// get returns the state for contextID, creating it if needed, and marks the
+// context as the most recently synced.
+func (f *followStates) get(contextID uint64) *followState {
+	if state, ok := f.byID[contextID]; ok {
+		f.recent.MoveToFront(state.recent)
+		return state
+	}
+
+	state := newFollowState(f.options)
+	state.recent = f.recent.PushFront(contextID)
+	f.byID[contextID] = state
+
+	for f.maxTracked > 0 && len(f.byID) > f.maxTracked {
+		victim := f.evictable()
+		if victim == nil {
+			break
+		}
+		f.evict(victim.Value.(uint64))
+	}
+	return state
+}
+
+// evictable returns the least recently synced context that has no sync
+// running or waiting, never choosing the most recent one.
+func (f *followStates) evictable() *list.Element {
+	for e := f.recent.Back(); e != nil && e != f.recent.Front(); e = e.Prev() {
+		state := f.byID[e.Value.(uint64)]
+		if !state.busy && state.queued == jobNone {
+			return e
+		}
+	}
+	return nil
+}
+
+// evict forgets a context. Any turns held in its reorder buffer are dropped.
+func (f *followStates) evict(contextID uint64) {
+	state, ok := f.byID[contextID]
+	if !ok {
+		return
+	}
+	f.recent.Remove(state.recent)
+	delete(f.byID, contextID)
+	if _, ok := f.checkpoints[contextID]; ok {
+		delete(f.checkpoints, contextID)
+		f.checkpointsDirty = true
+	}
+}
+
+// nextDeadline returns the earliest time a held turn must be flushed, a
+// failed sync retried, or a throttled WithFollowStateHook call made.
+func (f *followStates) nextDeadline() (time.Time, bool) {
+	var next time.Time
+	found := false
+	consider := func(t time.Time) {
+		if !found || t.Before(next) {
+			next = t
+			found = true
+		}
+	}
+	for _, state := range f.byID {
+		if state.busy {
+			continue
+		}
+		if state.hasPending() {
+			consider(state.pendingDeadline)
+		}
+		if state.retrying() {
+			consider(state.retryAt)
+		}
+	}
+	if deadline, ok := f.hookDeadline(); ok {
+		consider(deadline)
+	}
+	return next, found
+}
+
 type followState struct {
 	hasLast        bool
 	lastSeenTurnID uint64
@@ -105,22 +1236,84 @@ type followState struct {
 	seen           map[uint64]struct{}
 	seenOrder      []uint64
 	maxSeen        int
+	seq            uint64 // Seq of the last emitted turn
+	backfill       InitialBackfill
+	recent         *list.Element // position in followStates.recent
+	includePayload bool
+	reusePayloads  bool
+	assertOrdering bool
+
+	label        *labelFilter
+	labelChecked bool
+	labelMatch   bool
+
+	clock           clock
+	metrics         *Metrics
+	reorderSize     int
+	reorderTimeout  time.Duration
+	pending         []TurnRecord
+	pendingFrom     uint32
+	pendingDeadline time.Time
+
+	retry         FollowRetryPolicy
+	retryAttempts int
+	retryDelay    time.Duration
+	retryAt       time.Time
+	failures      int // consecutive failed syncs, for WithFollowErrorThreshold
+
+	// busy, queued, and closing belong to the syncScheduler. While busy is
+	// set a sync owns every other field.
+	busy    bool
+	queued  syncJob
+	closing bool // released after the current sync
 }
 
-func newFollowState(maxSeen int) *followState {
+func newFollowState(options *followOptions) *followState {
+	maxSeen := options.maxSeenPerContext
 	if maxSeen <= 0 {
 		maxSeen = defaultMaxSeenPerContext
 	}
+	reorderTimeout := options.reorderTimeout
+	if reorderTimeout <= 0 {
+		reorderTimeout = defaultReorderTimeout
+	}
+	clk := options.clock
+	if clk == nil {
+		clk = realClock{}
+	}
 	return &followState{
-		seen:    make(map[uint64]struct{}),
-		maxSeen: maxSeen,
+		seen:           make(map[uint64]struct{}),
+		maxSeen:        maxSeen,
+		backfill:       options.initialBackfill,
+		includePayload: options.includePayload,
+		reusePayloads:  options.reusePayloads,
+		assertOrdering: options.assertOrdering,
+		label:          options.labelFilter,
+		reorderSize:    options.reorderSize,
+		reorderTimeout: reorderTimeout,
+		clock:          clk,
+		metrics:        options.metrics,
+		retry:          options.retry,
 	}
 }
 
-func (s *followState) syncContext(ctx context.Context, client TurnClient, contextID uint64, out chan<- FollowTurn) error {
+func (s *followState) runJob(ctx context.Context, client TurnClient, contextID uint64, job syncJob, out chan<- FollowTurn) error {
+	if job == jobFlush {
+		return s.flushPending(ctx, client, contextID, out)
+	}
+	return s.syncContext(ctx, client, contextID, out, false)
+}
+
+// syncContext fetches turns up to the current head and emits the unseen ones.
+// When force is set, turns past a depth gap are emitted instead of held.
+func (s *followState) syncContext(ctx context.Context, client TurnClient, contextID uint64, out chan<- FollowTurn, force bool) error {
+	if ok, err := s.admitted(ctx, contextID); !ok {
+		return err
+	}
+
 	head, err := client.GetHead(ctx, contextID)
 	if err != nil {
-		return fmt.Errorf("follow turns: get head: %w", err)
+		return fmt.Errorf("follow turns: get head: %w", contextNotFound(contextID, err))
 	}
 
 	if s.hasLast && head.HeadDepth < s.lastSeenDepth {
@@ -133,33 +1326,272 @@ func (s *followState) syncContext(ctx context.Context, client TurnClient, contex
 			missing = head.HeadDepth - s.lastSeenDepth
 		}
 	} else {
-		missing = head.HeadDepth + 1
+		missing = s.backfill.limit(head.HeadDepth)
+		if missing == 0 {
+			s.markHead(head)
+			return nil
+		}
 	}
 
 	if missing == 0 {
 		return nil
 	}
 
-	turns, err := client.GetLast(ctx, contextID, GetLastOptions{Limit: missing, IncludePayload: true})
+	turns, err := s.fetchTurns(ctx, client, contextID, head, missing)
+	if err != nil {
+		return fmt.Errorf("follow turns: get last: %w", contextNotFound(contextID, err))
+	}
+	if !turnsOrdered(turns) {
+		if s.assertOrdering {
+			return fmt.Errorf("follow turns: %w: get last returned turns out of order (context %d)", ErrInvalidResponse, contextID)
+		}
+		sort.Slice(turns, func(i, j int) bool { return turnLess(turns[i], turns[j]) })
+	}
+
+	expected := head.HeadDepth + 1 - missing
+	if s.hasLast {
+		expected = s.lastSeenDepth + 1
+	}
+
+	var gapErr error
+	for i, turn := range turns {
+		if s.seenTurn(turn.TurnID) {
+			continue
+		}
+		if s.reorderSize > 0 && turn.Depth > expected {
+			if !force {
+				s.hold(turns[i:], expected)
+				if len(s.pending) > s.reorderSize {
+					return s.emitPending(ctx, contextID, out)
+				}
+				return nil
+			}
+			if gapErr == nil {
+				gapErr = &GapError{ContextID: contextID, FromDepth: expected, ToDepth: turn.Depth - 1}
+			}
+		}
+		if err := s.emit(ctx, contextID, turn, out); err != nil {
+			return err
+		}
+		expected = turn.Depth + 1
+	}
+
+	s.pending = nil
+	return gapErr
+}
+
+// fetchTurns returns the last n turns of the context up to head. With
+// WithPayloadReuse and turns held in the reorder buffer, the turns are listed
+// without payloads first, and payloads are fetched only for the suffix that
+// starts at the oldest turn neither emitted nor held.
+func (s *followState) fetchTurns(ctx context.Context, client TurnClient, contextID uint64, head *ContextHead, n uint32) ([]TurnRecord, error) {
+	opts := GetLastOptions{Limit: n, IncludePayload: s.includePayload, Order: OrderAscending}
+	if !s.reusePayloads || !s.includePayload || !s.hasPending() {
+		return client.GetLast(ctx, contextID, opts)
+	}
+
+	held := make(map[uint64]TurnRecord, len(s.pending))
+	for _, turn := range s.pending {
+		held[turn.TurnID] = turn
+	}
+
+	listOpts := opts
+	listOpts.IncludePayload = false
+	turns, err := client.GetLast(ctx, contextID, listOpts)
+	if err != nil {
+		return nil, err
+	}
+	if !turnsOrdered(turns) {
+		// Leave the ordering policy to syncContext.
+		return client.GetLast(ctx, contextID, opts)
+	}
+
+	first := len(turns)
+	for i, turn := range turns {
+		if held, ok := held[turn.TurnID]; ok {
+			turns[i] = held
+			continue
+		}
+		if !s.seenTurn(turn.TurnID) {
+			first = i
+			break
+		}
+	}
+	if first == len(turns) || turns[first].Depth > head.HeadDepth {
+		return turns, nil
+	}
+
+	// GetLast counts back from the head by depth, so this limit starts the
+	// window at the first turn that needs its payload.
+	opts.Limit = head.HeadDepth - turns[first].Depth + 1
+	rest, err := client.GetLast(ctx, contextID, opts)
 	if err != nil {
-		return fmt.Errorf("follow turns: get last: %w", err)
+		return nil, err
+	}
+	if len(rest) == 0 || !turnsOrdered(rest) || rest[0].TurnID != turns[first].TurnID {
+		// The context moved between the two calls; fetch the whole window.
+		opts.Limit = n
+		return client.GetLast(ctx, contextID, opts)
+	}
+	return append(turns[:first], rest...), nil
+}
+
+// turnsOrdered reports whether turns are sorted by depth, then turn ID.
+func turnsOrdered(turns []TurnRecord) bool {
+	for i := 1; i < len(turns); i++ {
+		if turnLess(turns[i], turns[i-1]) {
+			return false
+		}
+	}
+	return true
+}
+
+func turnLess(a, b TurnRecord) bool {
+	if a.Depth != b.Depth {
+		return a.Depth < b.Depth
+	}
+	return a.TurnID < b.TurnID
+}
+
+func (s *followState) emit(ctx context.Context, contextID uint64, turn TurnRecord, out chan<- FollowTurn) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case out <- FollowTurn{ContextID: contextID, Turn: turn, Seq: s.seq + 1}:
+	}
+	s.seq++
+	s.metrics.incTurnsEmitted()
+	s.recordTurn(turn)
+	return nil
+}
+
+// hold replaces the reorder buffer with turns that follow a gap at depth
+// expected, keeping the original deadline while the context stays gapped.
+func (s *followState) hold(turns []TurnRecord, expected uint32) {
+	if !s.hasPending() {
+		s.pendingDeadline = s.clock.Now().Add(s.reorderTimeout)
+	}
+	s.pending = append(s.pending[:0], turns...)
+	s.pendingFrom = expected
+}
+
+func (s *followState) hasPending() bool {
+	return len(s.pending) > 0
+}
+
+// flushPending resyncs a context whose held turns have timed out, emitting
+// them regardless of any remaining gap.
+func (s *followState) flushPending(ctx context.Context, client TurnClient, contextID uint64, out chan<- FollowTurn) error {
+	err := s.syncContext(ctx, client, contextID, out, true)
+	if s.hasPending() {
+		if emitErr := s.emitPending(ctx, contextID, out); err == nil {
+			err = emitErr
+		}
 	}
+	return err
+}
+
+// emitPending emits all held turns in order and reports the gap they skip.
+func (s *followState) emitPending(ctx context.Context, contextID uint64, out chan<- FollowTurn) error {
+	pending := s.pending
+	expected := s.pendingFrom
+	s.pending = nil
 
-	for _, turn := range turns {
+	var gapErr error
+	for _, turn := range pending {
 		if s.seenTurn(turn.TurnID) {
 			continue
 		}
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case out <- FollowTurn{ContextID: contextID, Turn: turn}:
+		if turn.Depth > expected && gapErr == nil {
+			gapErr = &GapError{ContextID: contextID, FromDepth: expected, ToDepth: turn.Depth - 1}
+		}
+		if err := s.emit(ctx, contextID, turn, out); err != nil {
+			return err
+		}
+		expected = turn.Depth + 1
+	}
+	return gapErr
+}
+
+// coversHint reports whether a hint names a turn that has already been
+// emitted (or skipped by backfill), so syncing for it cannot find anything
+// new. Redelivered hints after a reconnect are answered without any RPC.
+func (s *followState) coversHint(ev TurnAppendedEvent) bool {
+	return s.hasLast && ev.Depth <= s.lastSeenDepth && s.seenTurn(ev.TurnID)
+}
+
+// admitted reports whether the context passes WithFollowLabelFilter, looking
+// up its metadata the first time.
+func (s *followState) admitted(ctx context.Context, contextID uint64) (bool, error) {
+	if s.label == nil {
+		return true, nil
+	}
+	if s.labelChecked {
+		return s.labelMatch, nil
+	}
+
+	meta, err := s.label.fetch(ctx, contextID)
+	if err != nil {
+		if s.label.onError == LabelFilterSkip && !errors.Is(err, context.Canceled) {
+			s.labelChecked = true
 		}
-		s.recordTurn(turn)
+		return false, fmt.Errorf("follow turns: context %d: fetch metadata: %w", contextID, err)
+	}
+	s.labelChecked = true
+	s.labelMatch = meta.HasLabel(s.label.label)
+	return s.labelMatch, nil
+}
+
+// excluded reports whether the context is known to fail the label filter.
+func (s *followState) excluded() bool {
+	return s.label != nil && s.labelChecked && !s.labelMatch
+}
+
+func (s *followState) retrying() bool {
+	return !s.retryAt.IsZero()
+}
+
+// scheduleRetry handles the outcome of a sync. On failure it schedules another
+// attempt if the retry policy allows and returns nil; otherwise it clears any
+// retry state and returns the error to report.
+func (s *followState) scheduleRetry(contextID uint64, err error) error {
+	var gap *GapError
+	if err == nil || errors.As(err, &gap) || errors.Is(err, context.Canceled) || s.retry.MaxAttempts <= 0 {
+		s.retryAttempts, s.retryDelay, s.retryAt = 0, 0, time.Time{}
+		return err
+	}
+
+	if s.retryAttempts >= s.retry.MaxAttempts {
+		attempts := s.retryAttempts
+		s.retryAttempts, s.retryDelay, s.retryAt = 0, 0, time.Time{}
+		return fmt.Errorf("follow turns: context %d: giving up after %d retries: %w", contextID, attempts, err)
 	}
 
+	if s.retryAttempts == 0 {
+		s.retryDelay = s.retry.InitialDelay
+		if s.retryDelay <= 0 {
+			s.retryDelay = defaultRetryDelay
+		}
+	} else {
+		maxDelay := s.retry.MaxDelay
+		if maxDelay <= 0 {
+			maxDelay = defaultMaxRetryDelay
+		}
+		s.retryDelay = nextRetryDelay(s.retryDelay, maxDelay)
+	}
+	s.retryAttempts++
+	s.retryAt = s.clock.Now().Add(s.retryDelay)
 	return nil
 }
 
+// markHead treats everything up to head as already seen without emitting it.
+func (s *followState) markHead(head *ContextHead) {
+	if head.HeadTurnID == 0 {
+		return
+	}
+	s.recordTurn(TurnRecord{TurnID: head.HeadTurnID, Depth: head.HeadDepth})
+}
+
 func (s *followState) seenTurn(turnID uint64) bool {
 	_, ok := s.seen[turnID]
 	return ok
@@ -180,6 +1612,48 @@ func (s *followState) recordTurn(turn TurnRecord) {
 	}
 }
 
+func decodeContextCreated(data json.RawMessage) (ContextCreatedEvent, error) {
+	if len(data) == 0 {
+		return ContextCreatedEvent{}, errors.New("context_created: empty payload")
+	}
+	event, err := DecodeContextCreated(data)
+	if err != nil {
+		return ContextCreatedEvent{}, fmt.Errorf("context_created: decode: %w", err)
+	}
+	if event.ContextID == 0 {
+		return ContextCreatedEvent{}, errors.New("context_created: missing context_id")
+	}
+	return event, nil
+}
+
+func decodeContextMetadataUpdated(data json.RawMessage) (ContextMetadataUpdatedEvent, error) {
+	if len(data) == 0 {
+		return ContextMetadataUpdatedEvent{}, errors.New("context_metadata_updated: empty payload")
+	}
+	event, err := DecodeContextMetadataUpdated(data)
+	if err != nil {
+		return ContextMetadataUpdatedEvent{}, fmt.Errorf("context_metadata_updated: decode: %w", err)
+	}
+	if event.ContextID == 0 {
+		return ContextMetadataUpdatedEvent{}, errors.New("context_metadata_updated: missing context_id")
+	}
+	return event, nil
+}
+
+func decodeContextClosed(data json.RawMessage) (ContextClosedEvent, error) {
+	if len(data) == 0 {
+		return ContextClosedEvent{}, errors.New("context_closed: empty payload")
+	}
+	event, err := DecodeContextClosed(data)
+	if err != nil {
+		return ContextClosedEvent{}, fmt.Errorf("context_closed: decode: %w", err)
+	}
+	if event.ContextID == 0 {
+		return ContextClosedEvent{}, errors.New("context_closed: missing context_id")
+	}
+	return event, nil
+}
+
 func decodeTurnAppended(data json.RawMessage) (TurnAppendedEvent, error) {
 	if len(data) == 0 {
 		return TurnAppendedEvent{}, errors.New("turn_appended: empty payload")
@@ -0,0 +1,134 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"maps"
+	"sort"
+	"time"
+)
+
+// stateHookInterval is the least time between two WithFollowStateHook calls.
+const stateHookInterval = time.Second
+
+// FollowCheckpoint records how far FollowTurns has got in one context, so a
+// later FollowTurns can resume there with WithFollowResume.
+type FollowCheckpoint struct {
+	// TurnID and Depth identify the deepest turn emitted, or skipped by
+	// WithInitialBackfill or WithAppendedTurns. Turns held in the reorder
+	// buffer do not count until they are emitted.
+	TurnID uint64
+	Depth  uint32
+
+	// Seq is the Seq of the last turn emitted, so numbering carries on
+	// across a resume.
+	Seq uint64
+}
+
+// WithFollowStateHook calls fn with a checkpoint for every context
+// FollowTurns is tracking, so the caller can persist them and resume after a
+// restart with WithFollowResume. Contexts that have no turn yet are left out.
+//
+// Calls are throttled: fn is called after a sync that moved a checkpoint,
+// but at most once a second; a change within a second of the previous call
+// is reported when the second is up. A final call with the state at exit is
+// made when FollowTurns stops, after its last sync has finished and before
+// its channels are closed. A context that is released, by a context_closed
+// event, WithMaxTrackedContexts, or WithFollowErrorThreshold, drops out of
+// the next call.
+//
+// fn runs on the FollowTurns goroutine, which handles no events while it
+// runs, so it should be quick. The map is a copy that fn may keep.
+func WithFollowStateHook(fn func(map[uint64]FollowCheckpoint)) FollowOption {
+	return func(o *followOptions) {
+		o.stateHook = fn
+	}
+}
+
+// WithFollowResume starts FollowTurns from checkpoints saved by a
+// WithFollowStateHook. Each context is synced at startup and emits only the
+// turns after its checkpoint, numbered from the checkpoint's Seq, instead of
+// the history WithInitialBackfill would select. The contexts count as
+// created for WithFollowNewContextsOnly.
+func WithFollowResume(checkpoints map[uint64]FollowCheckpoint) FollowOption {
+	return func(o *followOptions) {
+		o.resume = maps.Clone(checkpoints)
+	}
+}
+
+// resumeContexts seeds the states of the contexts given to WithFollowResume
+// and returns their IDs in ascending order.
+func resumeContexts(states *followStates, checkpoints map[uint64]FollowCheckpoint) []uint64 {
+	ids := make([]uint64, 0, len(checkpoints))
+	for contextID := range checkpoints {
+		ids = append(ids, contextID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, contextID := range ids {
+		cp := checkpoints[contextID]
+		state := states.get(contextID)
+		if cp.TurnID != 0 {
+			state.recordTurn(TurnRecord{TurnID: cp.TurnID, Depth: cp.Depth})
+		}
+		state.seq = cp.Seq
+		states.created[contextID] = true
+		states.recordCheckpoint(contextID, state)
+	}
+	return ids
+}
+
+func (s *followState) checkpoint() FollowCheckpoint {
+	return FollowCheckpoint{TurnID: s.lastSeenTurnID, Depth: s.lastSeenDepth, Seq: s.seq}
+}
+
+// recordCheckpoint notes the checkpoint of a context that has no sync
+// running, for WithFollowStateHook.
+func (f *followStates) recordCheckpoint(contextID uint64, state *followState) {
+	if f.options.stateHook == nil || !state.hasLast {
+		return
+	}
+	cp := state.checkpoint()
+	if old, ok := f.checkpoints[contextID]; ok && old == cp {
+		return
+	}
+	f.checkpoints[contextID] = cp
+	f.checkpointsDirty = true
+}
+
+// hookDeadline returns when a throttled WithFollowStateHook call is due.
+func (f *followStates) hookDeadline() (time.Time, bool) {
+	if !f.checkpointsDirty {
+		return time.Time{}, false
+	}
+	return f.lastHook.Add(stateHookInterval), true
+}
+
+// callHook calls the WithFollowStateHook function if a checkpoint has
+// changed and the throttle allows it.
+func (f *followStates) callHook() {
+	deadline, ok := f.hookDeadline()
+	if !ok {
+		return
+	}
+	now := f.options.clock.Now()
+	if !f.lastHook.IsZero() && now.Before(deadline) {
+		return
+	}
+	f.lastHook = now
+	f.checkpointsDirty = false
+	f.options.stateHook(maps.Clone(f.checkpoints))
+}
+
+// finalHook makes the last WithFollowStateHook call when FollowTurns stops.
+// No sync may be running, so every state can be read.
+func (f *followStates) finalHook() {
+	if f.options.stateHook == nil {
+		return
+	}
+	for contextID, state := range f.byID {
+		f.recordCheckpoint(contextID, state)
+	}
+	f.options.stateHook(maps.Clone(f.checkpoints))
+}
@@ -0,0 +1,76 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import "sync"
+
+// MergeFollowTurns merges the turns from several FollowTurns channels, for
+// example one per shard, into a single channel. Turns are forwarded as they
+// arrive, so no input can hold up or starve another, and the order of turns
+// from any one input is preserved. The returned channel is closed once every
+// input has been closed; the caller must keep receiving until then.
+func MergeFollowTurns(chans ...<-chan FollowTurn) <-chan FollowTurn {
+	out := make(chan FollowTurn)
+	var wg sync.WaitGroup
+	wg.Add(len(chans))
+	for _, ch := range chans {
+		go func(ch <-chan FollowTurn) {
+			defer wg.Done()
+			for turn := range ch {
+				out <- turn
+			}
+		}(ch)
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// MergeFollowTurnsFunc is like MergeFollowTurns, but performs a k-way merge
+// ordered by less: if every input is sorted by less, so is the output, with
+// ties going to the earlier input. To choose the next turn it needs one
+// pending turn from every input that is still open, so a quiet input holds
+// back the whole stream until it sends a turn or is closed. If less is nil it
+// behaves like MergeFollowTurns.
+func MergeFollowTurnsFunc(less func(a, b FollowTurn) bool, chans ...<-chan FollowTurn) <-chan FollowTurn {
+	if less == nil {
+		return MergeFollowTurns(chans...)
+	}
+
+	out := make(chan FollowTurn)
+	go func() {
+		defer close(out)
+
+		open := append([]<-chan FollowTurn(nil), chans...)
+		heads := make([]FollowTurn, len(open))
+		pending := make([]bool, len(open))
+		for {
+			next := -1
+			for i, ch := range open {
+				if ch == nil {
+					continue
+				}
+				if !pending[i] {
+					turn, ok := <-ch
+					if !ok {
+						open[i] = nil
+						continue
+					}
+					heads[i], pending[i] = turn, true
+				}
+				if next < 0 || less(heads[i], heads[next]) {
+					next = i
+				}
+			}
+			if next < 0 {
+				return
+			}
+			out <- heads[next]
+			pending[next] = false
+		}
+	}()
+	return out
+}
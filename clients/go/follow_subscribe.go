@@ -0,0 +1,72 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import "context"
+
+// SubscribeAndFollow subscribes to the SSE endpoint at eventsURL and follows
+// the turns announced by its turn_appended events, fetching them with client.
+// context_closed, context_created, and context_metadata_updated events are
+// passed through so FollowTurns can release state and honor
+// WithFollowNewContextsOnly and WithContextTitle.
+// It wires together SubscribeEvents and FollowTurns, which remain available
+// for callers that also need the raw events.
+//
+// If eventsURL has a context_ids parameter, as set by Client.EventsURL or
+// BuildEventsURL, only those contexts are followed, as with
+// WithFollowContexts. Current servers ignore the parameter and stream every
+// context's events, so the filter is applied here; IDs that do not parse are
+// skipped.
+//
+// Errors from both the subscription and the follower are merged onto the
+// returned error channel. Canceling ctx stops both stages; the returned
+// channels are closed once every internal goroutine has exited.
+func SubscribeAndFollow(ctx context.Context, eventsURL string, client TurnClient, sopts []SubscribeOption, fopts ...FollowOption) (<-chan FollowTurn, <-chan error) {
+	if ids := eventsURLContextIDs(eventsURL); len(ids) > 0 {
+		fopts = append([]FollowOption{WithFollowContexts(ids...)}, fopts...)
+	}
+	events, subErrs := SubscribeEvents(ctx, eventsURL, sopts...)
+
+	hints := make(chan Event, defaultEventBuffer)
+	go func() {
+		defer close(hints)
+		for ev := range events {
+			switch ev.Type {
+			case EventTurnAppended, EventContextClosed, EventContextCreated, EventContextMetadataUpdated:
+			default:
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case hints <- ev:
+			}
+		}
+	}()
+
+	turns, followErrs := FollowTurns(ctx, hints, client, fopts...)
+
+	errs := make(chan error, defaultErrorBuffer)
+	go func() {
+		defer close(errs)
+		for subErrs != nil || followErrs != nil {
+			select {
+			case err, ok := <-subErrs:
+				if !ok {
+					subErrs = nil
+					continue
+				}
+				nonBlockingSend(errs, err)
+			case err, ok := <-followErrs:
+				if !ok {
+					followErrs = nil
+					continue
+				}
+				nonBlockingSend(errs, err)
+			}
+		}
+	}()
+
+	return turns, errs
+}
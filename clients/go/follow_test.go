@@ -4,26 +4,62 @@
 package cxdb
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 type stubTurnClient struct {
-	mu    sync.Mutex
-	turns map[uint64][]TurnRecord
-	heads map[uint64]*ContextHead
+	mu     sync.Mutex
+	turns  map[uint64][]TurnRecord
+	heads  map[uint64]*ContextHead
+	hidden map[uint64]bool
+
+	getLastCalls []GetLastOptions
+	getHeadCalls int
+	payloadBytes int // payload bytes returned by GetLast
+	headFailures map[uint64]int
 }
 
 func newStubTurnClient() *stubTurnClient {
 	return &stubTurnClient{
-		turns: make(map[uint64][]TurnRecord),
-		heads: make(map[uint64]*ContextHead),
+		turns:  make(map[uint64][]TurnRecord),
+		heads:  make(map[uint64]*ContextHead),
+		hidden: make(map[uint64]bool),
+	}
+}
+
+// setHidden makes GetLast omit the given turns, simulating turns the server
+// has accepted but not yet made queryable.
+func (s *stubTurnClient) setHidden(hidden bool, turnIDs ...uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range turnIDs {
+		s.hidden[id] = hidden
 	}
 }
 
+// failHead makes the next n GetHead calls for contextID fail.
+func (s *stubTurnClient) failHead(contextID uint64, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.headFailures == nil {
+		s.headFailures = make(map[uint64]int)
+	}
+	s.headFailures[contextID] = n
+}
+
 func (s *stubTurnClient) setContext(contextID uint64, turns []TurnRecord) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -41,6 +77,11 @@ func (s *stubTurnClient) GetHead(ctx context.Context, contextID uint64) (*Contex
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.getHeadCalls++
+	if s.headFailures[contextID] > 0 {
+		s.headFailures[contextID]--
+		return nil, errors.New("transient failure")
+	}
 	head, ok := s.heads[contextID]
 	if !ok {
 		return nil, ErrContextNotFound
@@ -53,6 +94,7 @@ func (s *stubTurnClient) GetLast(ctx context.Context, contextID uint64, opts Get
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.getLastCalls = append(s.getLastCalls, opts)
 	turns, ok := s.turns[contextID]
 	if !ok {
 		return nil, ErrContextNotFound
@@ -62,7 +104,22 @@ func (s *stubTurnClient) GetLast(ctx context.Context, contextID uint64, opts Get
 		limit = len(turns)
 	}
 	start := len(turns) - limit
-	result := append([]TurnRecord{}, turns[start:]...)
+	result := make([]TurnRecord, 0, limit)
+	for _, turn := range turns[start:] {
+		if s.hidden[turn.TurnID] {
+			continue
+		}
+		if !opts.IncludePayload {
+			turn.Payload = nil
+		}
+		s.payloadBytes += len(turn.Payload)
+		result = append(result, turn)
+	}
+	if opts.Order == OrderDescending {
+		for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+			result[i], result[j] = result[j], result[i]
+		}
+	}
 	return result, nil
 }
 
@@ -109,6 +166,60 @@ func TestFollowTurnsBackfillAndDedupe(t *testing.T) {
 	}
 }
 
+func TestFollowTurnsSeq(t *testing.T) {
+	t.Parallel()
+
+	client := newStubTurnClient()
+	// Turn IDs skip values, as they do when other branches append in between.
+	client.setContext(1, []TurnRecord{{TurnID: 5, Depth: 0}, {TurnID: 9, Depth: 1}})
+	client.setContext(2, []TurnRecord{{TurnID: 7, Depth: 0}})
+
+	events := make(chan Event)
+	out, errs := FollowTurns(context.Background(), events, client, WithFollowBuffer(10))
+
+	seqs := map[uint64][]uint64{}
+	var turnIDs []uint64
+	receive := func(n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			select {
+			case turn := <-out:
+				seqs[turn.ContextID] = append(seqs[turn.ContextID], turn.Seq)
+				turnIDs = append(turnIDs, turn.Turn.TurnID)
+			case <-time.After(2 * time.Second):
+				t.Fatal("timed out waiting for turn")
+			}
+		}
+	}
+
+	events <- makeTurnEvent(1, 9, 1)
+	receive(2)
+	events <- makeTurnEvent(2, 7, 0)
+	receive(1)
+	client.setContext(1, []TurnRecord{{TurnID: 5, Depth: 0}, {TurnID: 9, Depth: 1}, {TurnID: 14, Depth: 2}, {TurnID: 20, Depth: 3}})
+	events <- makeTurnEvent(1, 20, 3)
+	receive(2)
+	client.setContext(2, []TurnRecord{{TurnID: 7, Depth: 0}, {TurnID: 30, Depth: 1}})
+	events <- makeTurnEvent(2, 30, 1)
+	receive(1)
+	close(events)
+
+	for range out {
+		t.Fatal("unexpected extra turn")
+	}
+	for err := range errs {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []uint64{5, 9, 7, 14, 20, 30}; !reflect.DeepEqual(turnIDs, want) {
+		t.Fatalf("unexpected turns: got %v want %v", turnIDs, want)
+	}
+	want := map[uint64][]uint64{1: {1, 2, 3, 4}, 2: {1, 2}}
+	if !reflect.DeepEqual(seqs, want) {
+		t.Fatalf("unexpected seqs: got %v want %v", seqs, want)
+	}
+}
+
 func TestFollowTurnsOutOfOrder(t *testing.T) {
 	t.Parallel()
 
@@ -145,6 +256,126 @@ func TestFollowTurnsOutOfOrder(t *testing.T) {
 	}
 }
 
+// reversingTurnClient returns GetLast results newest first.
+type reversingTurnClient struct {
+	*stubTurnClient
+}
+
+func (r reversingTurnClient) GetLast(ctx context.Context, contextID uint64, opts GetLastOptions) ([]TurnRecord, error) {
+	turns, err := r.stubTurnClient.GetLast(ctx, contextID, opts)
+	for i, j := 0, len(turns)-1; i < j; i, j = i+1, j-1 {
+		turns[i], turns[j] = turns[j], turns[i]
+	}
+	return turns, err
+}
+
+func TestFollowTurnsSortsGetLastResults(t *testing.T) {
+	t.Parallel()
+
+	stub := newStubTurnClient()
+	stub.setContext(1, []TurnRecord{
+		{TurnID: 10, Depth: 0},
+		{TurnID: 11, Depth: 1},
+		{TurnID: 12, Depth: 2},
+	})
+	client := reversingTurnClient{stub}
+
+	for _, strict := range []bool{false, true} {
+		events := make(chan Event, 1)
+		events <- makeTurnEvent(1, 12, 2)
+		close(events)
+
+		out, errs := FollowTurns(context.Background(), events, client, WithAssertOrdering(strict))
+
+		var got []uint64
+		for turn := range out {
+			got = append(got, turn.Turn.TurnID)
+		}
+		var errList []error
+		for err := range errs {
+			errList = append(errList, err)
+		}
+
+		if !strict {
+			if want := []uint64{10, 11, 12}; !reflect.DeepEqual(got, want) || len(errList) != 0 {
+				t.Fatalf("sorting: got turns %v errors %v, want %v", got, errList, want)
+			}
+			continue
+		}
+		if len(got) != 0 {
+			t.Fatalf("strict: expected no turns, got %v", got)
+		}
+		if len(errList) != 1 || !errors.Is(errList[0], ErrInvalidResponse) {
+			t.Fatalf("strict: expected one ErrInvalidResponse, got %v", errList)
+		}
+	}
+}
+
+func TestFollowTurnsLabelFilter(t *testing.T) {
+	t.Parallel()
+
+	for _, onError := range []LabelFilterOnError{LabelFilterSkip, LabelFilterRetry} {
+		client := newStubTurnClient()
+		for id := uint64(1); id <= 3; id++ {
+			client.setContext(id, []TurnRecord{{TurnID: id * 10, Depth: 0}, {TurnID: id*10 + 1, Depth: 1}})
+		}
+
+		var mu sync.Mutex
+		lookups := make(map[uint64]int)
+		fetch := func(ctx context.Context, contextID uint64) (ContextMetadata, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			lookups[contextID]++
+			switch {
+			case contextID == 1:
+				return ContextMetadata{ContextID: 1, Labels: []string{"production"}}, nil
+			case contextID == 3 && lookups[contextID] == 1:
+				return ContextMetadata{}, errors.New("metadata unavailable")
+			case contextID == 3:
+				return ContextMetadata{ContextID: 3, Labels: []string{"staging", "production"}}, nil
+			default:
+				return ContextMetadata{ContextID: contextID, Labels: []string{"dev"}}, nil
+			}
+		}
+
+		events := make(chan Event, 10)
+		for _, id := range []uint64{1, 2, 3} {
+			events <- makeTurnEvent(id, id*10, 0)
+		}
+		for _, id := range []uint64{1, 2, 3} {
+			events <- makeTurnEvent(id, id*10+1, 1)
+		}
+		close(events)
+
+		out, errs := FollowTurns(context.Background(), events, client, WithFollowLabelFilter("production", fetch, onError))
+
+		var got []uint64
+		for turn := range out {
+			got = append(got, turn.Turn.TurnID)
+		}
+		var errList []error
+		for err := range errs {
+			errList = append(errList, err)
+		}
+
+		want := []uint64{10, 11}
+		wantLookups := map[uint64]int{1: 1, 2: 1, 3: 1}
+		if onError == LabelFilterRetry {
+			want = []uint64{10, 11, 30, 31}
+			wantLookups[3] = 2
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("onError %d: got turns %v want %v", onError, got, want)
+		}
+		if len(errList) != 1 || !strings.Contains(errList[0].Error(), "metadata unavailable") {
+			t.Fatalf("onError %d: expected one metadata error, got %v", onError, errList)
+		}
+		if !reflect.DeepEqual(lookups, wantLookups) {
+			t.Fatalf("onError %d: metadata lookups %v want %v", onError, lookups, wantLookups)
+		}
+	}
+}
+
 func TestFollowTurnsMultipleContexts(t *testing.T) {
 	t.Parallel()
 
@@ -182,13 +413,1431 @@ func TestFollowTurnsMultipleContexts(t *testing.T) {
 	}
 }
 
-func makeTurnEvent(contextID, turnID uint64, depth uint32) Event {
-	payload := map[string]any{
-		"context_id":     contextID,
-		"turn_id":        turnID,
-		"parent_turn_id": 0,
-		"depth":          depth,
+func TestFollowTurnsInitialBackfill(t *testing.T) {
+	t.Parallel()
+
+	history := []TurnRecord{
+		{TurnID: 1, Depth: 0},
+		{TurnID: 2, Depth: 1},
+		{TurnID: 3, Depth: 2},
+		{TurnID: 4, Depth: 3},
+	}
+
+	tests := []struct {
+		name string
+		mode InitialBackfill
+		want []uint64
+	}{
+		{"full", BackfillFull, []uint64{1, 2, 3, 4, 5}},
+		{"none", BackfillNone, []uint64{5}},
+		{"last n", BackfillLastN(2), []uint64{3, 4, 5}},
+		{"last n exceeds history", BackfillLastN(10), []uint64{1, 2, 3, 4, 5}},
+		{"last zero", BackfillLastN(0), []uint64{5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := newStubTurnClient()
+			contextID := uint64(1)
+			client.setContext(contextID, history)
+
+			events := make(chan Event)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			out, errs := FollowTurns(ctx, events, client, WithFollowBuffer(10), WithInitialBackfill(tt.mode))
+
+			events <- makeTurnEvent(contextID, 4, 3)
+			// The unbuffered send above returns once the follower has taken the
+			// hint; the next send blocks until the first sync has finished.
+			events <- Event{Type: "context_created"}
+
+			client.setContext(contextID, append(append([]TurnRecord{}, history...), TurnRecord{TurnID: 5, Depth: 4}))
+			events <- makeTurnEvent(contextID, 5, 4)
+			close(events)
+
+			var got []uint64
+			for turn := range out {
+				got = append(got, turn.Turn.TurnID)
+			}
+			for err := range errs {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected turns: got %v want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFollowTurnsAppendedTurns(t *testing.T) {
+	t.Parallel()
+
+	client := newStubTurnClient()
+	client.setContext(1, []TurnRecord{
+		{TurnID: 1, Depth: 0},
+		{TurnID: 2, Depth: 1},
+		{TurnID: 3, Depth: 2},
+	})
+
+	events := make(chan Event, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The writer appended turns 2 and 3 itself; another writer appends 4.
+	// A failed append leaves a nil result, which is ignored.
+	appended := []*AppendResult{
+		{ContextID: 1, TurnID: 2, Depth: 1},
+		nil,
+		{ContextID: 1, TurnID: 3, Depth: 2},
+	}
+	out, errs := FollowTurns(ctx, events, client, WithFollowBuffer(10), WithAppendedTurns(appended...))
+
+	events <- makeTurnEvent(1, 3, 2)
+	client.setContext(1, []TurnRecord{
+		{TurnID: 1, Depth: 0},
+		{TurnID: 2, Depth: 1},
+		{TurnID: 3, Depth: 2},
+		{TurnID: 4, Depth: 3},
+	})
+	events <- makeTurnEvent(1, 4, 3)
+	close(events)
+
+	var got []uint64
+	for turn := range out {
+		got = append(got, turn.Turn.TurnID)
+	}
+	for err := range errs {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []uint64{4}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected turns: got %v want %v", got, want)
+	}
+}
+
+func TestFollowTurnsReorderBufferWaitsForPredecessors(t *testing.T) {
+	t.Parallel()
+
+	client := newStubTurnClient()
+	contextID := uint64(3)
+	client.setContext(contextID, []TurnRecord{
+		{TurnID: 1, Depth: 0},
+		{TurnID: 2, Depth: 1},
+		{TurnID: 3, Depth: 2},
+	})
+	client.setHidden(true, 1, 2)
+
+	events := make(chan Event)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out, errs := FollowTurns(ctx, events, client, WithFollowBuffer(10), WithReorderBuffer(8, time.Minute))
+
+	events <- makeTurnEvent(contextID, 3, 2)
+	events <- Event{Type: "context_created"}
+	select {
+	case turn := <-out:
+		t.Fatalf("turn %d emitted before its predecessors", turn.Turn.TurnID)
+	default:
+	}
+
+	client.setHidden(false, 1, 2)
+	events <- makeTurnEvent(contextID, 3, 2)
+	close(events)
+
+	var got []uint64
+	for turn := range out {
+		got = append(got, turn.Turn.TurnID)
+	}
+	for err := range errs {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []uint64{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected turns: got %v want %v", got, want)
+	}
+}
+
+func TestFollowTurnsPayloadReuse(t *testing.T) {
+	t.Parallel()
+
+	payload := bytes.Repeat([]byte("x"), 1000)
+	history := []TurnRecord{
+		{TurnID: 1, Depth: 0, Payload: payload},
+		{TurnID: 2, Depth: 1, Payload: payload},
+		{TurnID: 3, Depth: 2, Payload: payload},
+		{TurnID: 4, Depth: 3, Payload: payload},
+		{TurnID: 5, Depth: 4, Payload: payload},
+	}
+
+	run := func(reuse bool) (int, []uint64) {
+		client := newStubTurnClient()
+		client.setContext(1, history)
+		client.setHidden(true, 2)
+
+		events := make(chan Event)
+		out, errs := FollowTurns(context.Background(), events, client,
+			WithFollowBuffer(10), WithReorderBuffer(8, time.Minute), WithPayloadReuse(reuse))
+
+		// Turn 2 is not visible yet, so 3-5 are held; the redelivered hints
+		// resync the gapped context while they stay held.
+		for i := 0; i < 3; i++ {
+			events <- makeTurnEvent(1, 5, 4)
+		}
+		events <- Event{Type: "context_created"}
+		client.setHidden(false, 2)
+		events <- makeTurnEvent(1, 5, 4)
+		close(events)
+
+		var got []uint64
+		for turn := range out {
+			if !bytes.Equal(turn.Turn.Payload, payload) {
+				t.Fatalf("reuse=%v: turn %d has a %d-byte payload", reuse, turn.Turn.TurnID, len(turn.Turn.Payload))
+			}
+			got = append(got, turn.Turn.TurnID)
+		}
+		for err := range errs {
+			t.Fatalf("reuse=%v: unexpected error: %v", reuse, err)
+		}
+		return client.payloadBytes, got
+	}
+
+	want := []uint64{1, 2, 3, 4, 5}
+	plainBytes, plainTurns := run(false)
+	reuseBytes, reuseTurns := run(true)
+	if !reflect.DeepEqual(plainTurns, want) || !reflect.DeepEqual(reuseTurns, want) {
+		t.Fatalf("turns = %v (plain), %v (reuse); want %v", plainTurns, reuseTurns, want)
+	}
+	// With reuse, the resyncs while turn 2 is missing transfer no payloads:
+	// 1 and 3-5 cross the wire in the first sync, and 2-5 once 2 appears.
+	if want := 8 * len(payload); reuseBytes != want {
+		t.Fatalf("reuse transferred %d payload bytes, want %d", reuseBytes, want)
+	}
+	if plainBytes <= reuseBytes {
+		t.Fatalf("plain sync transferred %d payload bytes, expected more than %d", plainBytes, reuseBytes)
+	}
+}
+
+func TestFollowTurnsReorderBufferTimeout(t *testing.T) {
+	t.Parallel()
+
+	client := newStubTurnClient()
+	contextID := uint64(4)
+	client.setContext(contextID, []TurnRecord{
+		{TurnID: 1, Depth: 0},
+		{TurnID: 2, Depth: 1},
+		{TurnID: 3, Depth: 2},
+	})
+	client.setHidden(true, 1, 2)
+
+	events := make(chan Event)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out, errs := FollowTurns(ctx, events, client, WithFollowBuffer(10), WithReorderBuffer(8, 20*time.Millisecond))
+
+	events <- makeTurnEvent(contextID, 3, 2)
+
+	select {
+	case turn := <-out:
+		if turn.Turn.TurnID != 3 {
+			t.Fatalf("expected turn 3 after timeout, got %d", turn.Turn.TurnID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for held turn to flush")
+	}
+
+	select {
+	case err := <-errs:
+		var gap *GapError
+		if !errors.As(err, &gap) {
+			t.Fatalf("expected *GapError, got %v", err)
+		}
+		if gap.ContextID != contextID || gap.FromDepth != 0 || gap.ToDepth != 1 {
+			t.Fatalf("unexpected gap: %+v", gap)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for gap error")
+	}
+}
+
+func TestFollowTurnsStateHook(t *testing.T) {
+	t.Parallel()
+
+	client := newStubTurnClient()
+	client.setContext(1, []TurnRecord{{TurnID: 1, Depth: 0}, {TurnID: 2, Depth: 1}})
+
+	clk := newFakeClock()
+	calls := make(chan map[uint64]FollowCheckpoint, 10)
+	events := make(chan Event)
+	out, errs := FollowTurns(context.Background(), events, client,
+		WithFollowStateHook(func(cps map[uint64]FollowCheckpoint) { calls <- cps }),
+		withFollowClock(clk),
+	)
+
+	expectCall := func(want FollowCheckpoint) {
+		t.Helper()
+		select {
+		case cps := <-calls:
+			if len(cps) != 1 || cps[1] != want {
+				t.Fatalf("hook got %+v, want context 1 at %+v", cps, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for hook call with %+v", want)
+		}
+	}
+
+	// The first sync is reported at once.
+	events <- makeTurnEvent(1, 2, 1)
+	<-out
+	<-out
+	expectCall(FollowCheckpoint{TurnID: 2, Depth: 1, Seq: 2})
+
+	// The next, within the second, waits for the throttle.
+	client.setContext(1, []TurnRecord{{TurnID: 1, Depth: 0}, {TurnID: 2, Depth: 1}, {TurnID: 3, Depth: 2}})
+	events <- makeTurnEvent(1, 3, 2)
+	<-out
+	clk.waitForTimers(t, 1)
+	select {
+	case cps := <-calls:
+		t.Fatalf("hook called before the throttle interval: %+v", cps)
+	default:
+	}
+	clk.Advance(stateHookInterval)
+	expectCall(FollowCheckpoint{TurnID: 3, Depth: 2, Seq: 3})
+
+	// Stopping makes a final call.
+	close(events)
+	for err := range errs {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var last map[uint64]FollowCheckpoint
+	select {
+	case last = <-calls:
+	default:
+		t.Fatal("no final hook call")
+	}
+	if last[1] != (FollowCheckpoint{TurnID: 3, Depth: 2, Seq: 3}) {
+		t.Fatalf("final checkpoint = %+v", last[1])
+	}
+
+	// Resuming emits only the turns after the checkpoint and carries Seq on.
+	client.setContext(1, []TurnRecord{{TurnID: 1, Depth: 0}, {TurnID: 2, Depth: 1}, {TurnID: 3, Depth: 2}, {TurnID: 4, Depth: 3}})
+	idle := make(chan Event)
+	close(idle)
+	resumed, _ := FollowTurns(context.Background(), idle, client, WithFollowResume(last))
+	var got []FollowTurn
+	for turn := range resumed {
+		got = append(got, turn)
+	}
+	if len(got) != 1 || got[0].Turn.TurnID != 4 || got[0].Seq != 4 {
+		t.Fatalf("resumed turns = %+v, want only turn 4 with Seq 4", got)
+	}
+}
+
+func TestFollowTurnsReorderBufferTimeoutClock(t *testing.T) {
+	t.Parallel()
+
+	client := newStubTurnClient()
+	contextID := uint64(4)
+	client.setContext(contextID, []TurnRecord{
+		{TurnID: 1, Depth: 0},
+		{TurnID: 2, Depth: 1},
+		{TurnID: 3, Depth: 2},
+	})
+	client.setHidden(true, 1, 2)
+
+	clk := newFakeClock()
+	events := make(chan Event)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out, _ := FollowTurns(ctx, events, client,
+		WithFollowBuffer(10),
+		WithReorderBuffer(8, time.Minute),
+		withFollowClock(clk),
+	)
+
+	events <- makeTurnEvent(contextID, 3, 2)
+	clk.waitForTimers(t, 1)
+
+	clk.Advance(59 * time.Second)
+	select {
+	case turn := <-out:
+		t.Fatalf("turn %d emitted before the reorder timeout", turn.Turn.TurnID)
+	default:
+	}
+
+	clk.Advance(time.Second)
+	select {
+	case turn := <-out:
+		if turn.Turn.TurnID != 3 {
+			t.Fatalf("expected turn 3 after timeout, got %d", turn.Turn.TurnID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for held turn to flush")
+	}
+}
+
+// gatedTurnClient blocks GetHead until released and records how many calls
+// were in flight at once.
+type gatedTurnClient struct {
+	*stubTurnClient
+	release chan struct{}
+
+	mu        sync.Mutex
+	active    int
+	maxActive int
+}
+
+func (g *gatedTurnClient) GetHead(ctx context.Context, contextID uint64) (*ContextHead, error) {
+	g.mu.Lock()
+	g.active++
+	if g.active > g.maxActive {
+		g.maxActive = g.active
+	}
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		g.active--
+		g.mu.Unlock()
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-g.release:
+	}
+	return g.stubTurnClient.GetHead(ctx, contextID)
+}
+
+func (g *gatedTurnClient) inFlight() (active, maxActive int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.active, g.maxActive
+}
+
+func TestFollowTurnsMaxConcurrentSyncs(t *testing.T) {
+	t.Parallel()
+
+	const contexts, limit = 10, 3
+
+	client := &gatedTurnClient{stubTurnClient: newStubTurnClient(), release: make(chan struct{})}
+	for id := uint64(1); id <= contexts; id++ {
+		client.setContext(id, []TurnRecord{
+			{TurnID: id * 10, Depth: 0},
+			{TurnID: id*10 + 1, Depth: 1},
+		})
+	}
+
+	events := make(chan Event, 2*contexts)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out, errs := FollowTurns(ctx, events, client, WithFollowBuffer(2*contexts), WithMaxConcurrentSyncs(limit))
+
+	for id := uint64(1); id <= contexts; id++ {
+		events <- makeTurnEvent(id, id*10, 0)
+		events <- makeTurnEvent(id, id*10+1, 1) // coalesced while the first sync is blocked
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if active, _ := client.inFlight(); active == limit {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d syncs in flight", limit)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(client.release)
+	close(events)
+
+	got := make(map[uint64][]uint32)
+	for turn := range out {
+		got[turn.ContextID] = append(got[turn.ContextID], turn.Turn.Depth)
+	}
+	for err := range errs {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, maxActive := client.inFlight(); maxActive != limit {
+		t.Fatalf("max in-flight syncs = %d, want %d", maxActive, limit)
+	}
+	for id := uint64(1); id <= contexts; id++ {
+		if want := []uint32{0, 1}; !reflect.DeepEqual(got[id], want) {
+			t.Fatalf("context %d: got depths %v want %v", id, got[id], want)
+		}
+	}
+}
+
+// pooledTurnClient is one of the clients handed out by a
+// WithFollowClientFactory factory. It records the contexts it served and
+// whether it was closed.
+type pooledTurnClient struct {
+	*gatedTurnClient
+
+	mu       sync.Mutex
+	contexts []uint64
+	closed   bool
+}
+
+func (p *pooledTurnClient) GetHead(ctx context.Context, contextID uint64) (*ContextHead, error) {
+	p.mu.Lock()
+	p.contexts = append(p.contexts, contextID)
+	p.mu.Unlock()
+	return p.gatedTurnClient.GetHead(ctx, contextID)
+}
+
+func (p *pooledTurnClient) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+func TestFollowTurnsClientFactory(t *testing.T) {
+	t.Parallel()
+
+	backend := &gatedTurnClient{stubTurnClient: newStubTurnClient(), release: make(chan struct{})}
+	for id := uint64(1); id <= 2; id++ {
+		backend.setContext(id, []TurnRecord{{TurnID: id * 10, Depth: 0}})
+	}
+
+	var mu sync.Mutex
+	var clients []*pooledTurnClient
+	factory := func() (TurnClient, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		client := &pooledTurnClient{gatedTurnClient: backend}
+		clients = append(clients, client)
+		return client, nil
+	}
+
+	events := make(chan Event, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out, errs := FollowTurns(ctx, events, nil, WithMaxConcurrentSyncs(2), WithFollowClientFactory(factory))
+	events <- makeTurnEvent(1, 10, 0)
+	events <- makeTurnEvent(2, 20, 0)
+
+	// Both syncs block in GetHead at once, each on a client of its own.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if active, _ := backend.inFlight(); active == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected 2 syncs in flight")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(backend.release)
+	close(events)
+
+	got := make(map[uint64]int)
+	for turn := range out {
+		got[turn.ContextID]++
+	}
+	for err := range errs {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got[1] != 1 || got[2] != 1 {
+		t.Fatalf("got turns per context %v, want one each", got)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(clients) != 2 {
+		t.Fatalf("factory made %d clients, want 2", len(clients))
+	}
+	served := make(map[uint64]bool)
+	for i, client := range clients {
+		if len(client.contexts) != 1 || served[client.contexts[0]] {
+			t.Fatalf("client %d served contexts %v, want one context of its own", i, client.contexts)
+		}
+		served[client.contexts[0]] = true
+		if !client.closed {
+			t.Fatalf("client %d was not closed when FollowTurns stopped", i)
+		}
+	}
+}
+
+// chattyTurnClient records the order of GetHead calls and appends a turn to
+// the chatty context before every GetHead for it, like a writer that never
+// stops.
+type chattyTurnClient struct {
+	*stubTurnClient
+	chatty uint64
+
+	mu    sync.Mutex
+	order []uint64
+	turns []TurnRecord
+}
+
+func (c *chattyTurnClient) GetHead(ctx context.Context, contextID uint64) (*ContextHead, error) {
+	c.mu.Lock()
+	c.order = append(c.order, contextID)
+	if contextID == c.chatty {
+		n := uint64(len(c.turns))
+		c.turns = append(c.turns, TurnRecord{TurnID: 100 + n, Depth: uint32(n)})
+		c.stubTurnClient.setContext(contextID, c.turns)
+	}
+	c.mu.Unlock()
+	return c.stubTurnClient.GetHead(ctx, contextID)
+}
+
+func TestFollowTurnsContextSyncOrder(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		order SyncOrder
+		want  []uint64
+	}{
+		// Every hint for the chatty context is acted on before the quiet one.
+		{"fifo", SyncOrderFIFO, []uint64{1, 1, 1, 1, 1, 1, 1, 1, 2}},
+		// Hints already waiting are coalesced, so the quiet context is next.
+		{"round robin", SyncOrderRoundRobin, []uint64{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := &chattyTurnClient{stubTurnClient: newStubTurnClient(), chatty: 1}
+			client.setContext(2, []TurnRecord{{TurnID: 1, Depth: 0}})
+
+			// A skewed burst: eight hints for context 1, all ahead of anything
+			// the follower has seen, then one for context 2.
+			events := make(chan Event, 16)
+			for i := 0; i < 8; i++ {
+				events <- makeTurnEvent(1, uint64(1000+i), uint32(1000+i))
+			}
+			events <- makeTurnEvent(2, 1, 0)
+			close(events)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			out, errs := FollowTurns(ctx, events, client, WithFollowBuffer(32), WithContextSyncOrder(tt.order))
+			for range out {
+			}
+			for err := range errs {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			client.mu.Lock()
+			defer client.mu.Unlock()
+			if !reflect.DeepEqual(client.order, tt.want) {
+				t.Fatalf("GetHead order = %v, want %v", client.order, tt.want)
+			}
+		})
+	}
+}
+
+func TestFollowTurnsMaxTrackedContexts(t *testing.T) {
+	t.Parallel()
+
+	client := newStubTurnClient()
+	client.setContext(1, []TurnRecord{{TurnID: 1, Depth: 0}})
+	client.setContext(2, []TurnRecord{{TurnID: 2, Depth: 0}})
+	client.setContext(3, []TurnRecord{{TurnID: 3, Depth: 0}})
+
+	events := make(chan Event, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out, errs := FollowTurns(ctx, events, client, WithFollowBuffer(10), WithMaxTrackedContexts(2))
+
+	events <- makeTurnEvent(1, 1, 0)
+	events <- makeTurnEvent(2, 2, 0)
+	events <- makeTurnEvent(2, 2, 0) // still tracked: deduped
+	events <- makeTurnEvent(3, 3, 0) // evicts context 1
+	events <- makeTurnEvent(1, 1, 0) // re-backfilled, evicts context 2
+	events <- makeTurnEvent(3, 3, 0) // still tracked: deduped
+	close(events)
+
+	var got []uint64
+	for turn := range out {
+		got = append(got, turn.Turn.TurnID)
+	}
+	for err := range errs {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []uint64{1, 2, 3, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected turns: got %v want %v", got, want)
+	}
+}
+
+func TestFollowTurnsContextClosed(t *testing.T) {
+	t.Parallel()
+
+	for _, signal := range []bool{true, false} {
+		client := newStubTurnClient()
+		client.setContext(1, []TurnRecord{{TurnID: 1, Depth: 0}, {TurnID: 2, Depth: 1}})
+		client.setContext(2, []TurnRecord{{TurnID: 10, Depth: 0}})
+
+		events := make(chan Event, 10)
+		events <- makeTurnEvent(1, 1, 0)
+		events <- makeClosedEvent(1)
+		events <- makeClosedEvent(99) // never followed: ignored
+		events <- makeTurnEvent(2, 10, 0)
+		events <- makeTurnEvent(1, 2, 1) // state was released: backfilled again
+		close(events)
+
+		out, errs := FollowTurns(context.Background(), events, client, WithCompletionSignal(signal))
+
+		var got []string
+		for turn := range out {
+			if turn.Completed {
+				got = append(got, fmt.Sprintf("%d:done", turn.ContextID))
+				continue
+			}
+			got = append(got, fmt.Sprintf("%d:%d", turn.ContextID, turn.Turn.TurnID))
+		}
+		for err := range errs {
+			t.Fatalf("signal=%v: unexpected error: %v", signal, err)
+		}
+
+		want := []string{"1:1", "1:2", "1:done", "2:10", "1:1", "1:2"}
+		if !signal {
+			want = []string{"1:1", "1:2", "2:10", "1:1", "1:2"}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("signal=%v: got %v want %v", signal, got, want)
+		}
+	}
+}
+
+func TestFollowStatesEviction(t *testing.T) {
+	t.Parallel()
+
+	options := followOptions{maxTrackedCtx: 3}
+	states := newFollowStates(&options)
+	for id := uint64(1); id <= 10; id++ {
+		states.get(id)
+		if id%2 == 0 {
+			states.get(1) // keep context 1 hot
+		}
+	}
+
+	if len(states.byID) != 3 || states.recent.Len() != 3 {
+		t.Fatalf("expected 3 tracked contexts, got %d (list %d)", len(states.byID), states.recent.Len())
+	}
+	for _, id := range []uint64{1, 9, 10} {
+		if _, ok := states.byID[id]; !ok {
+			t.Fatalf("expected context %d to be tracked", id)
+		}
+	}
+}
+
+func TestFollowTurnsIncludePayload(t *testing.T) {
+	t.Parallel()
+
+	for _, include := range []bool{true, false} {
+		client := newStubTurnClient()
+		client.setContext(1, []TurnRecord{
+			{TurnID: 1, Depth: 0, Payload: []byte{0x80}},
+			{TurnID: 2, Depth: 1, Payload: []byte{0x80}},
+		})
+
+		events := make(chan Event, 1)
+		events <- makeTurnEvent(1, 2, 1)
+		close(events)
+
+		var opts []FollowOption
+		if !include {
+			opts = append(opts, WithFollowIncludePayload(false))
+		}
+		out, errs := FollowTurns(context.Background(), events, client, opts...)
+		for turn := range out {
+			if hasPayload := len(turn.Turn.Payload) > 0; hasPayload != include {
+				t.Fatalf("include=%v: turn %d has payload=%v", include, turn.Turn.TurnID, hasPayload)
+			}
+		}
+		for err := range errs {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(client.getLastCalls) == 0 {
+			t.Fatal("expected GetLast to be called")
+		}
+		for _, call := range client.getLastCalls {
+			if call.IncludePayload != include {
+				t.Fatalf("include=%v: GetLast requested IncludePayload=%v", include, call.IncludePayload)
+			}
+			if call.Order != OrderAscending {
+				t.Fatalf("GetLast requested order %d, want OrderAscending", call.Order)
+			}
+		}
+	}
+}
+
+func TestFollowTurnsRetry(t *testing.T) {
+	t.Parallel()
+
+	client := newStubTurnClient()
+	client.setContext(1, []TurnRecord{{TurnID: 1, Depth: 0}})
+	client.setContext(2, []TurnRecord{{TurnID: 10, Depth: 0}})
+	client.failHead(1, 2)
+
+	events := make(chan Event, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out, errs := FollowTurns(ctx, events, client, WithFollowRetry(FollowRetryPolicy{
+		MaxAttempts:  3,
+		InitialDelay: 5 * time.Millisecond,
+	}))
+
+	events <- makeTurnEvent(1, 1, 0)
+	events <- makeTurnEvent(2, 10, 0)
+
+	var got []uint64
+	deadline := time.After(2 * time.Second)
+	for len(got) < 2 {
+		select {
+		case turn := <-out:
+			got = append(got, turn.Turn.TurnID)
+		case err := <-errs:
+			t.Fatalf("unexpected error: %v", err)
+		case <-deadline:
+			t.Fatalf("timed out waiting for turns, got %v", got)
+		}
+	}
+
+	// The failing context must not hold up the healthy one.
+	want := []uint64{10, 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected turns: got %v want %v", got, want)
+	}
+}
+
+func TestFollowTurnsRetryGivesUp(t *testing.T) {
+	t.Parallel()
+
+	client := newStubTurnClient()
+	client.setContext(1, []TurnRecord{{TurnID: 1, Depth: 0}})
+	client.failHead(1, 100)
+
+	events := make(chan Event, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out, errs := FollowTurns(ctx, events, client, WithFollowRetry(FollowRetryPolicy{
+		MaxAttempts:  2,
+		InitialDelay: time.Millisecond,
+	}))
+
+	events <- makeTurnEvent(1, 1, 0)
+
+	select {
+	case err := <-errs:
+		if err == nil || !strings.Contains(err.Error(), "giving up after 2 retries") {
+			t.Fatalf("expected give-up error, got %v", err)
+		}
+	case turn := <-out:
+		t.Fatalf("unexpected turn: %+v", turn)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for error")
+	}
+
+	client.mu.Lock()
+	remaining := client.headFailures[1]
+	client.mu.Unlock()
+	if calls := 100 - remaining; calls != 3 {
+		t.Fatalf("expected 3 GetHead attempts, got %d", calls)
+	}
+}
+
+func TestFollowTurnsErrorThreshold(t *testing.T) {
+	t.Parallel()
+
+	client := newStubTurnClient()
+	client.setContext(1, []TurnRecord{{TurnID: 1, Depth: 0}})
+	client.setContext(2, []TurnRecord{{TurnID: 10, Depth: 0}})
+	client.failHead(1, 100)
+
+	events := make(chan Event, 10)
+	abandoned := make(chan uint64, 1)
+	out, errs := FollowTurns(context.Background(), events, client, WithFollowErrorThreshold(3, abandoned))
+
+	for turnID := uint64(1); turnID <= 5; turnID++ {
+		events <- makeTurnEvent(1, turnID, uint32(turnID-1))
+	}
+	events <- makeTurnEvent(2, 10, 0)
+	close(events)
+
+	var got []uint64
+	for turn := range out {
+		got = append(got, turn.Turn.TurnID)
+	}
+	if want := []uint64{10}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected turns: got %v want %v", got, want)
+	}
+
+	var syncErrs, abandonErrs int
+	for err := range errs {
+		if errors.Is(err, ErrContextAbandoned) {
+			abandonErrs++
+		} else {
+			syncErrs++
+		}
+	}
+	if syncErrs != 3 || abandonErrs != 1 {
+		t.Fatalf("got %d sync errors and %d abandon errors, want 3 and 1", syncErrs, abandonErrs)
+	}
+
+	select {
+	case id := <-abandoned:
+		if id != 1 {
+			t.Fatalf("abandoned context %d, want 1", id)
+		}
+	default:
+		t.Fatal("no abandoned context reported")
+	}
+
+	// Hints after abandonment cost no RPCs.
+	client.mu.Lock()
+	remaining := client.headFailures[1]
+	client.mu.Unlock()
+	if calls := 100 - remaining; calls != 3 {
+		t.Fatalf("expected 3 GetHead attempts for context 1, got %d", calls)
+	}
+}
+
+func TestFollowTurnsContextNotFound(t *testing.T) {
+	t.Parallel()
+
+	client := newStubTurnClient()
+	client.setContext(1, []TurnRecord{{TurnID: 1, Depth: 0}})
+
+	events := make(chan Event, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out, errs := FollowTurns(ctx, events, client, WithFollowBuffer(10))
+
+	events <- makeTurnEvent(1, 1, 0)
+	events <- makeTurnEvent(5, 1, 0)
+	close(events)
+
+	for range out {
+	}
+	var got []error
+	for err := range errs {
+		got = append(got, err)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("expected 1 error, got %v", got)
+	}
+	var notFound *ContextNotFoundError
+	if !errors.Is(got[0], ErrContextNotFound) || !errors.As(got[0], &notFound) || notFound.ContextID != 5 {
+		t.Fatalf("expected a *ContextNotFoundError for context 5, got %v", got[0])
+	}
+}
+
+func TestFollowTurnsSkipsRedeliveredHints(t *testing.T) {
+	t.Parallel()
+
+	client := newStubTurnClient()
+	client.setContext(1, []TurnRecord{
+		{TurnID: 1, Depth: 0},
+		{TurnID: 2, Depth: 1},
+	})
+
+	events := make(chan Event, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out, errs := FollowTurns(ctx, events, client, WithFollowBuffer(10))
+
+	events <- makeTurnEvent(1, 2, 1)
+	// Redelivered after a reconnect: both turns were already emitted.
+	events <- makeTurnEvent(1, 1, 0)
+	events <- makeTurnEvent(1, 2, 1)
+	close(events)
+
+	var got []uint64
+	for turn := range out {
+		got = append(got, turn.Turn.TurnID)
+	}
+	for err := range errs {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []uint64{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected turns: got %v want %v", got, want)
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.getHeadCalls != 1 {
+		t.Fatalf("expected 1 GetHead call, got %d", client.getHeadCalls)
+	}
+}
+
+func TestFollowTurnsStartupContexts(t *testing.T) {
+	t.Parallel()
+
+	client := newStubTurnClient()
+	client.setContext(1, []TurnRecord{
+		{TurnID: 1, Depth: 0},
+		{TurnID: 2, Depth: 1},
+	})
+	client.setContext(2, []TurnRecord{
+		{TurnID: 10, Depth: 0},
+	})
+
+	listErr := errors.New("list failed")
+	events := make(chan Event, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out, errs := FollowTurns(ctx, events, client,
+		WithFollowBuffer(10),
+		WithStartupContexts(1, 2, 1),
+		WithStartupContextList(func(context.Context) ([]uint64, error) {
+			return nil, listErr
+		}),
+	)
+
+	// A live hint for a turn the startup sync already emitted is dropped.
+	events <- makeTurnEvent(1, 2, 1)
+	close(events)
+
+	var got []uint64
+	for turn := range out {
+		got = append(got, turn.Turn.TurnID)
+	}
+	var gotErr error
+	for err := range errs {
+		gotErr = err
+	}
+
+	if want := []uint64{1, 2, 10}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected turns: got %v want %v", got, want)
+	}
+	if !errors.Is(gotErr, listErr) {
+		t.Fatalf("expected list error, got %v", gotErr)
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.getHeadCalls != 2 {
+		t.Fatalf("expected 2 GetHead calls, got %d", client.getHeadCalls)
+	}
+}
+
+func TestFollowTurnsStartupContextsConcurrent(t *testing.T) {
+	t.Parallel()
+
+	const contexts, limit = 6, 3
+
+	client := &gatedTurnClient{stubTurnClient: newStubTurnClient(), release: make(chan struct{})}
+	var ids []uint64
+	for id := uint64(1); id <= contexts; id++ {
+		ids = append(ids, id)
+		client.setContext(id, []TurnRecord{
+			{TurnID: id * 10, Depth: 0},
+			{TurnID: id*10 + 1, Depth: 1},
+			{TurnID: id*10 + 2, Depth: 2},
+		})
+	}
+
+	events := make(chan Event, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out, errs := FollowTurns(ctx, events, client,
+		WithFollowBuffer(4*contexts),
+		WithMaxConcurrentSyncs(limit),
+		WithStartupContexts(ids...),
+	)
+
+	// The backfills run in parallel before any event arrives.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if active, _ := client.inFlight(); active == limit {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d startup syncs in flight", limit)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// A live turn hinted while its context is still being backfilled is
+	// emitted after the backfilled ones.
+	client.setContext(1, []TurnRecord{
+		{TurnID: 10, Depth: 0},
+		{TurnID: 11, Depth: 1},
+		{TurnID: 12, Depth: 2},
+		{TurnID: 13, Depth: 3},
+	})
+	events <- makeTurnEvent(1, 13, 3)
+	close(client.release)
+	close(events)
+
+	got := make(map[uint64][]uint32)
+	for turn := range out {
+		got[turn.ContextID] = append(got[turn.ContextID], turn.Turn.Depth)
+	}
+	for err := range errs {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, maxActive := client.inFlight(); maxActive != limit {
+		t.Fatalf("max in-flight syncs = %d, want %d", maxActive, limit)
+	}
+	if want := []uint32{0, 1, 2, 3}; !reflect.DeepEqual(got[1], want) {
+		t.Fatalf("context 1: got depths %v want %v", got[1], want)
+	}
+	for id := uint64(2); id <= contexts; id++ {
+		if want := []uint32{0, 1, 2}; !reflect.DeepEqual(got[id], want) {
+			t.Fatalf("context %d: got depths %v want %v", id, got[id], want)
+		}
+	}
+}
+
+func TestSubscribeAndFollow(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("event: context_created\ndata: {\"context_id\":\"1\"}\n\n"))
+		_, _ = w.Write([]byte("event: turn_appended\ndata: {\"context_id\":\"1\",\"turn_id\":\"2\",\"depth\":1}\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	client := newStubTurnClient()
+	client.setContext(1, []TurnRecord{
+		{TurnID: 1, Depth: 0},
+		{TurnID: 2, Depth: 1},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out, errs := SubscribeAndFollow(ctx, srv.URL, client, nil, WithFollowBuffer(10))
+
+	var got []uint64
+	deadline := time.After(2 * time.Second)
+	for len(got) < 2 {
+		select {
+		case turn := <-out:
+			got = append(got, turn.Turn.TurnID)
+		case err := <-errs:
+			t.Fatalf("unexpected error: %v", err)
+		case <-deadline:
+			t.Fatalf("timed out waiting for turns, got %v", got)
+		}
+	}
+	if want := []uint64{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected turns: got %v want %v", got, want)
+	}
+
+	cancel()
+	for out != nil || errs != nil {
+		select {
+		case _, ok := <-out:
+			if !ok {
+				out = nil
+			}
+		case _, ok := <-errs:
+			if !ok {
+				errs = nil
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("channels not closed after cancel")
+		}
+	}
+}
+
+func TestSubscribeAndFollowContextIDs(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Like current servers, ignore context_ids and send every context.
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("event: turn_appended\ndata: {\"context_id\":\"2\",\"turn_id\":\"20\",\"depth\":0}\n\n"))
+		_, _ = w.Write([]byte("event: turn_appended\ndata: {\"context_id\":\"1\",\"turn_id\":\"10\",\"depth\":0}\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	client := newStubTurnClient()
+	client.setContext(1, []TurnRecord{{TurnID: 10, Depth: 0}})
+	client.setContext(2, []TurnRecord{{TurnID: 20, Depth: 0}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	url, err := BuildEventsURL(srv.URL+"/v1/events", EventQuery{ContextIDs: []uint64{1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, errs := SubscribeAndFollow(ctx, url, client, nil)
+
+	var got []string
+	select {
+	case turn := <-out:
+		got = append(got, fmt.Sprintf("%d:%d", turn.ContextID, turn.Turn.TurnID))
+	case err := <-errs:
+		t.Fatalf("unexpected error: %v", err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for a turn")
+	}
+
+	cancel()
+	for out != nil || errs != nil {
+		select {
+		case turn, ok := <-out:
+			if !ok {
+				out = nil
+				continue
+			}
+			got = append(got, fmt.Sprintf("%d:%d", turn.ContextID, turn.Turn.TurnID))
+		case _, ok := <-errs:
+			if !ok {
+				errs = nil
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("channels not closed after cancel")
+		}
+	}
+	if want := []string{"1:10"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.getHeadCalls != 1 {
+		t.Fatalf("expected GetHead only for context 1, got %d calls", client.getHeadCalls)
+	}
+}
+
+func makeClosedEvent(contextID uint64) Event {
+	data, _ := json.Marshal(map[string]any{"context_id": contextID, "closed_at": 1700000000000})
+	return Event{Type: EventContextClosed, Data: data}
+}
+
+func makeTurnEvent(contextID, turnID uint64, depth uint32) Event {
+	payload := map[string]any{
+		"context_id":     contextID,
+		"turn_id":        turnID,
+		"parent_turn_id": 0,
+		"depth":          depth,
+	}
+	data, _ := json.Marshal(payload)
+	return Event{Type: "turn_appended", Data: data}
+}
+
+func TestMergeFollowTurnsFunc(t *testing.T) {
+	t.Parallel()
+
+	send := func(contextID uint64, turnIDs ...uint64) <-chan FollowTurn {
+		ch := make(chan FollowTurn)
+		go func() {
+			defer close(ch)
+			for _, id := range turnIDs {
+				ch <- FollowTurn{ContextID: contextID, Turn: TurnRecord{TurnID: id}}
+			}
+		}()
+		return ch
+	}
+
+	byTurnID := func(a, b FollowTurn) bool { return a.Turn.TurnID < b.Turn.TurnID }
+	merged := MergeFollowTurnsFunc(byTurnID, send(1, 1, 4, 5, 9), send(2, 2, 3, 6), send(3))
+
+	var got []uint64
+	for turn := range merged {
+		got = append(got, turn.Turn.TurnID)
+	}
+	if want := []uint64{1, 2, 3, 4, 5, 6, 9}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected order: got %v want %v", got, want)
+	}
+}
+
+func TestMergeFollowTurns(t *testing.T) {
+	t.Parallel()
+
+	a := make(chan FollowTurn, 2)
+	b := make(chan FollowTurn, 2)
+	a <- FollowTurn{ContextID: 1, Turn: TurnRecord{TurnID: 1}}
+	a <- FollowTurn{ContextID: 1, Turn: TurnRecord{TurnID: 2}}
+	b <- FollowTurn{ContextID: 2, Turn: TurnRecord{TurnID: 10}}
+	close(a)
+
+	// b stays open: turns from a must not wait for it.
+	merged := MergeFollowTurns(a, b)
+	got := map[uint64][]uint64{}
+	for i := 0; i < 3; i++ {
+		select {
+		case turn := <-merged:
+			got[turn.ContextID] = append(got[turn.ContextID], turn.Turn.TurnID)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out, got %v", got)
+		}
+	}
+	close(b)
+	if _, ok := <-merged; ok {
+		t.Fatal("expected merged channel to close")
+	}
+	if want := (map[uint64][]uint64{1: {1, 2}, 2: {10}}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+}
+
+func TestFollowTurnsNewContextsOnly(t *testing.T) {
+	t.Parallel()
+
+	client := newStubTurnClient()
+	client.setContext(1, []TurnRecord{{TurnID: 1, Depth: 0}})
+	client.setContext(2, []TurnRecord{{TurnID: 20, Depth: 0}, {TurnID: 21, Depth: 1}})
+	client.setContext(3, []TurnRecord{{TurnID: 30, Depth: 0}})
+
+	created := func(contextID uint64) Event {
+		data, _ := json.Marshal(map[string]any{"context_id": contextID, "session_id": "s", "created_at": 1700000000000})
+		return Event{Type: EventContextCreated, Data: data}
+	}
+
+	events := make(chan Event, 10)
+	events <- makeTurnEvent(1, 1, 0)  // existed before FollowTurns started
+	events <- makeTurnEvent(2, 20, 0) // hint raced ahead of the create event
+	events <- created(2)
+	events <- makeTurnEvent(2, 21, 1)
+	events <- Event{Type: EventContextCreated, Data: json.RawMessage(`{}`)}
+	events <- created(3)
+	events <- makeTurnEvent(3, 30, 0)
+	close(events)
+
+	out, errs := FollowTurns(context.Background(), events, client, WithFollowNewContextsOnly(true))
+
+	var got []string
+	for turn := range out {
+		got = append(got, fmt.Sprintf("%d:%d", turn.ContextID, turn.Turn.TurnID))
+	}
+	var gotErrs []error
+	for err := range errs {
+		gotErrs = append(gotErrs, err)
+	}
+
+	want := []string{"2:20", "2:21", "3:30"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if len(gotErrs) != 1 || !strings.Contains(gotErrs[0].Error(), "context_created") {
+		t.Fatalf("expected one context_created decode error, got %v", gotErrs)
+	}
+	if client.getHeadCalls != 2 {
+		t.Fatalf("expected GetHead only for contexts 2 and 3, got %d calls", client.getHeadCalls)
+	}
+}
+
+func TestFollowTurnsFollowContexts(t *testing.T) {
+	t.Parallel()
+
+	client := newStubTurnClient()
+	client.setContext(1, []TurnRecord{{TurnID: 10, Depth: 0}})
+	client.setContext(2, []TurnRecord{{TurnID: 20, Depth: 0}})
+	client.setContext(3, []TurnRecord{{TurnID: 30, Depth: 0}})
+
+	events := make(chan Event, 3)
+	events <- makeTurnEvent(1, 10, 0)
+	events <- makeTurnEvent(2, 20, 0)
+	events <- makeTurnEvent(3, 30, 0)
+	close(events)
+
+	out, errs := FollowTurns(context.Background(), events, client,
+		WithFollowContexts(1), WithFollowContexts(3), WithFollowContexts())
+
+	var got []string
+	for turn := range out {
+		got = append(got, fmt.Sprintf("%d:%d", turn.ContextID, turn.Turn.TurnID))
+	}
+	for err := range errs {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []string{"1:10", "3:30"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if client.getHeadCalls != 2 {
+		t.Fatalf("expected GetHead only for contexts 1 and 3, got %d calls", client.getHeadCalls)
+	}
+}
+
+func TestFollowTurnsContextTitle(t *testing.T) {
+	t.Parallel()
+
+	client := newStubTurnClient()
+	client.setContext(1, []TurnRecord{{TurnID: 10, Depth: 0}, {TurnID: 11, Depth: 1}})
+	client.setContext(2, []TurnRecord{{TurnID: 20, Depth: 0}})
+
+	retitled := func(contextID uint64, title string) Event {
+		data, _ := json.Marshal(map[string]any{"context_id": contextID, "title": title})
+		return Event{Type: EventContextMetadataUpdated, Data: data}
+	}
+
+	events := make(chan Event)
+	out, errs := FollowTurns(context.Background(), events, client, WithContextTitle("deploy"))
+
+	expect := func(want ...string) {
+		t.Helper()
+		for _, w := range want {
+			select {
+			case turn := <-out:
+				if got := fmt.Sprintf("%d:%d", turn.ContextID, turn.Turn.TurnID); got != w {
+					t.Fatalf("got turn %s, want %s", got, w)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("timed out waiting for turn %s", w)
+			}
+		}
+	}
+
+	// A matching title brings context 1 into the follow set at once.
+	events <- retitled(1, "deploy: api")
+	expect("1:10", "1:11")
+
+	// Context 2 has no title yet, then one that does not match.
+	events <- makeTurnEvent(2, 20, 0)
+	events <- retitled(2, "scratch")
+
+	// Renaming context 1 takes it out; its new turn is not fetched.
+	events <- retitled(1, "archived")
+	client.setContext(1, []TurnRecord{{TurnID: 10, Depth: 0}, {TurnID: 11, Depth: 1}, {TurnID: 12, Depth: 2}})
+	events <- makeTurnEvent(1, 12, 2)
+
+	// Renaming context 2 brings it in.
+	events <- retitled(2, "deploy: db")
+	expect("2:20")
+
+	// Context 1 matches again and resumes after the last turn it emitted.
+	events <- retitled(1, "deploy: api v2")
+	expect("1:12")
+	close(events)
+
+	for turn := range out {
+		t.Fatalf("unexpected turn %d:%d", turn.ContextID, turn.Turn.TurnID)
+	}
+	for err := range errs {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.getHeadCalls != 3 {
+		t.Fatalf("expected 3 GetHead calls, got %d", client.getHeadCalls)
 	}
-	data, _ := json.Marshal(payload)
-	return Event{Type: "turn_appended", Data: data}
 }
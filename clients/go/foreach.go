@@ -0,0 +1,81 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"fmt"
+)
+
+// ForEachOptions sets the stop conditions of ForEachTurn. The zero value
+// runs until the channels are closed, counting errors without stopping.
+type ForEachOptions struct {
+	// MaxTurns stops iteration once fn has handled this many turns. Completed
+	// markers are passed to fn but not counted. 0 or less means no limit.
+	MaxTurns int
+
+	// MaxErrors stops iteration once this many errors have been received
+	// from errs. 0 or less means no limit.
+	MaxErrors int
+
+	// StopOnError stops iteration at the first error received from errs.
+	StopOnError bool
+}
+
+// ForEachTurn calls fn for every turn received from turns, typically the
+// channels returned by FollowTurns, until one of the following happens:
+//
+//   - fn returns an error, which ForEachTurn returns;
+//   - opts.MaxTurns turns have been handled, and it returns nil;
+//   - an error is received from errs with opts.StopOnError set, or the
+//     opts.MaxErrors-th error is received, and it returns an error wrapping
+//     the last one;
+//   - ctx is done, and it returns ctx.Err();
+//   - both channels are closed, and it returns nil.
+//
+// Errors that do not stop iteration are dropped; use MaxErrors and
+// StopOnError to act on them. errs may be nil. fn is called from the
+// calling goroutine, one turn at a time.
+func ForEachTurn(ctx context.Context, turns <-chan FollowTurn, errs <-chan error, fn func(FollowTurn) error, opts ForEachOptions) error {
+	turnCount := 0
+	errorCount := 0
+
+	for turns != nil || errs != nil {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case turn, ok := <-turns:
+			if !ok {
+				turns = nil
+				continue
+			}
+			if err := fn(turn); err != nil {
+				return err
+			}
+			if turn.Completed {
+				continue
+			}
+			turnCount++
+			if opts.MaxTurns > 0 && turnCount >= opts.MaxTurns {
+				return nil
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			if err == nil {
+				continue
+			}
+			errorCount++
+			if opts.StopOnError {
+				return fmt.Errorf("cxdb: stopped on error: %w", err)
+			}
+			if opts.MaxErrors > 0 && errorCount >= opts.MaxErrors {
+				return fmt.Errorf("cxdb: stopped after %d errors: %w", errorCount, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestForEachTurn(t *testing.T) {
+	t.Parallel()
+
+	feed := func(n int, errList ...error) (chan FollowTurn, chan error) {
+		turns := make(chan FollowTurn, n+1)
+		errs := make(chan error, len(errList))
+		for i := 1; i <= n; i++ {
+			turns <- FollowTurn{ContextID: 1, Seq: uint64(i)}
+			if i == 2 {
+				turns <- FollowTurn{ContextID: 2, Completed: true}
+			}
+		}
+		for _, err := range errList {
+			errs <- err
+		}
+		close(turns)
+		close(errs)
+		return turns, errs
+	}
+
+	t.Run("max turns", func(t *testing.T) {
+		turns, errs := feed(5)
+		var seen []FollowTurn
+		err := ForEachTurn(context.Background(), turns, errs, func(turn FollowTurn) error {
+			seen = append(seen, turn)
+			return nil
+		}, ForEachOptions{MaxTurns: 3})
+		if err != nil {
+			t.Fatalf("ForEachTurn = %v, want nil", err)
+		}
+		// The Completed marker is passed on but does not count.
+		if len(seen) != 4 || seen[3].Seq != 3 || !seen[2].Completed {
+			t.Fatalf("unexpected turns: %+v", seen)
+		}
+	})
+
+	t.Run("drains closed channels", func(t *testing.T) {
+		turns, errs := feed(2, errors.New("transient"))
+		count := 0
+		err := ForEachTurn(context.Background(), turns, errs, func(FollowTurn) error {
+			count++
+			return nil
+		}, ForEachOptions{})
+		if err != nil || count != 3 {
+			t.Fatalf("ForEachTurn = %v after %d calls, want nil after 3", err, count)
+		}
+	})
+
+	t.Run("stop on error", func(t *testing.T) {
+		boom := errors.New("boom")
+		errs := make(chan error, 1)
+		errs <- boom
+		err := ForEachTurn(context.Background(), make(chan FollowTurn), errs, func(FollowTurn) error {
+			t.Fatal("fn called without a turn")
+			return nil
+		}, ForEachOptions{StopOnError: true})
+		if !errors.Is(err, boom) {
+			t.Fatalf("ForEachTurn = %v, want boom", err)
+		}
+	})
+
+	t.Run("max errors", func(t *testing.T) {
+		first, second := errors.New("first"), errors.New("second")
+		errs := make(chan error, 2)
+		errs <- first
+		errs <- second
+		err := ForEachTurn(context.Background(), make(chan FollowTurn), errs, func(FollowTurn) error {
+			return nil
+		}, ForEachOptions{MaxErrors: 2})
+		if !errors.Is(err, second) || errors.Is(err, first) {
+			t.Fatalf("ForEachTurn = %v, want the second error", err)
+		}
+	})
+
+	t.Run("callback error", func(t *testing.T) {
+		turns, errs := feed(5)
+		stop := errors.New("stop")
+		count := 0
+		err := ForEachTurn(context.Background(), turns, errs, func(FollowTurn) error {
+			count++
+			return stop
+		}, ForEachOptions{})
+		if err != stop || count != 1 {
+			t.Fatalf("ForEachTurn = %v after %d calls, want stop after 1", err, count)
+		}
+	})
+
+	t.Run("context canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := ForEachTurn(ctx, make(chan FollowTurn), nil, func(FollowTurn) error {
+			return nil
+		}, ForEachOptions{})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("ForEachTurn = %v, want context.Canceled", err)
+		}
+	})
+}
@@ -156,18 +156,7 @@ func (c *Client) AppendTurnWithFs(ctx context.Context, req *AppendRequest, fsRoo
 		return nil, fmt.Errorf("append turn: %w", err)
 	}
 
-	if len(resp.payload) < 52 {
-		return nil, fmt.Errorf("%w: append response too short (%d bytes)", ErrInvalidResponse, len(resp.payload))
-	}
-
-	result := &AppendResult{
-		ContextID: binary.LittleEndian.Uint64(resp.payload[0:8]),
-		TurnID:    binary.LittleEndian.Uint64(resp.payload[8:16]),
-		Depth:     binary.LittleEndian.Uint32(resp.payload[16:20]),
-	}
-	copy(result.PayloadHash[:], resp.payload[20:52])
-
-	return result, nil
+	return parseAppendResult(resp.payload)
 }
 
 // sendRequestWithFlags is like sendRequest but allows setting custom flags.
@@ -178,6 +167,10 @@ func (c *Client) sendRequestWithFlags(ctx context.Context, msgType uint16, flags
 	if c.closed {
 		return nil, ErrClientClosed
 	}
+	if c.broken != nil {
+		return nil, c.broken
+	}
+	defer func() { c.lastUsed = time.Now() }()
 
 	// Set deadline for this request
 	deadline := time.Now().Add(c.timeout)
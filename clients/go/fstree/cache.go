@@ -0,0 +1,124 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package fstree
+
+import (
+	"io/fs"
+	"os"
+	"time"
+)
+
+// racyWindow is how close to the start of a capture a modification time may
+// be before the entry is considered too fresh to cache. Filesystems with coarse
+// timestamps can otherwise hide a change made in the same tick as the capture.
+const racyWindow = 2 * time.Second
+
+// captureCache remembers directory listings and file hashes from a previous
+// capture so a Tracker can skip work for entries that have not changed.
+//
+// A directory's listing is reused when the directory's own mtime is unchanged,
+// which is updated by the OS whenever an entry is created, removed, or renamed.
+// A file's hash is reused when its size and mtime are unchanged. Every entry is
+// still stat'ed on each capture, so content edits that keep the listing intact
+// are always detected and RootHash matches a cold Capture.
+type captureCache struct {
+	dirs  map[string]dirCacheEntry
+	files map[string]fileCacheEntry
+}
+
+type dirCacheEntry struct {
+	modTime  time.Time
+	children []dirChild
+}
+
+type dirChild struct {
+	name  string
+	isDir bool
+}
+
+type fileCacheEntry struct {
+	modTime time.Time
+	size    int64
+	hash    [32]byte
+}
+
+func newCaptureCache() *captureCache {
+	return &captureCache{
+		dirs:  make(map[string]dirCacheEntry),
+		files: make(map[string]fileCacheEntry),
+	}
+}
+
+// readDir lists absPath, reusing the previous listing when the directory is
+// unchanged, and records the listing for the next capture.
+func (b *builder) readDir(absPath, relPath string) ([]dirChild, error) {
+	if b.next == nil {
+		return listDir(absPath)
+	}
+
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return nil, err
+	}
+	modTime := info.ModTime()
+
+	if b.prev != nil {
+		if cached, ok := b.prev.dirs[relPath]; ok && cached.modTime.Equal(modTime) {
+			b.next.dirs[relPath] = cached
+			return cached.children, nil
+		}
+	}
+
+	children, err := listDir(absPath)
+	if err != nil {
+		return nil, err
+	}
+	if b.cacheable(modTime) {
+		b.next.dirs[relPath] = dirCacheEntry{modTime: modTime, children: children}
+	}
+	return children, nil
+}
+
+// fileHash returns the content hash of a regular file, reusing the previous
+// hash when size and mtime are unchanged.
+func (b *builder) fileHash(absPath, relPath string, info fs.FileInfo) ([32]byte, error) {
+	if b.next == nil {
+		return hashFile(absPath)
+	}
+
+	if b.prev != nil {
+		if cached, ok := b.prev.files[relPath]; ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+			b.next.files[relPath] = cached
+			return cached.hash, nil
+		}
+	}
+
+	hash, err := hashFile(absPath)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	if b.cacheable(info.ModTime()) {
+		b.next.files[relPath] = fileCacheEntry{modTime: info.ModTime(), size: info.Size(), hash: hash}
+		b.checkpoint.fileHashed(b.next)
+	}
+	return hash, nil
+}
+
+// cacheable reports whether an entry last modified at modTime is old enough
+// that a later change is guaranteed to produce a different mtime.
+func (b *builder) cacheable(modTime time.Time) bool {
+	return modTime.Before(b.start.Add(-racyWindow))
+}
+
+func listDir(absPath string) ([]dirChild, error) {
+	dirEntries, err := os.ReadDir(absPath)
+	if err != nil {
+		return nil, err
+	}
+	children := make([]dirChild, len(dirEntries))
+	for i, de := range dirEntries {
+		children[i] = dirChild{name: de.Name(), isDir: de.IsDir()}
+	}
+	return children, nil
+}
@@ -9,9 +9,12 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"maps"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/vmihailenco/msgpack/v5"
@@ -23,8 +26,13 @@ var (
 	ErrTooManyFiles = errors.New("fstree: too many files")
 	ErrFileTooLarge = errors.New("fstree: file too large")
 	ErrCyclicLink   = errors.New("fstree: cyclic symbolic link detected")
+	ErrBlobNotFound = errors.New("fstree: blob not found")
 )
 
+// errNothingIncluded is returned by buildTree for a directory that holds
+// nothing selected by WithInclude, so that its parent omits it.
+var errNothingIncluded = errors.New("fstree: nothing included")
+
 // Capture takes a snapshot of the filesystem at the given root path.
 // Returns a Snapshot containing the Merkle tree of all files and directories.
 //
@@ -33,73 +41,91 @@ var (
 //   - Unchanged directories have the same tree hash
 //   - This enables efficient deduplication in the CXDB blob store
 func Capture(root string, opts ...Option) (*Snapshot, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	snap, _, err := capture(root, o, nil, nil)
+	return snap, err
+}
+
+// capture builds a snapshot of root. When caching is enabled in o, prev holds
+// the cache from the previous capture (nil on the first run) and the cache for
+// the next run is returned, even if the capture fails part way. A non-nil ckpt
+// is told about each file hashed.
+func capture(root string, o *options, prev *captureCache, ckpt *checkpointer) (*Snapshot, *captureCache, error) {
 	start := time.Now()
 
 	// Resolve to absolute path
 	absRoot, err := filepath.Abs(root)
 	if err != nil {
-		return nil, fmt.Errorf("resolve root: %w", err)
+		return nil, nil, fmt.Errorf("resolve root: %w", err)
 	}
 
 	// Check root exists and is a directory
 	info, err := os.Stat(absRoot)
 	if err != nil {
-		return nil, fmt.Errorf("stat root: %w", err)
+		return nil, nil, fmt.Errorf("stat root: %w", err)
 	}
 	if !info.IsDir() {
-		return nil, fmt.Errorf("root is not a directory: %s", absRoot)
-	}
-
-	// Apply options
-	o := defaultOptions()
-	for _, opt := range opts {
-		opt(o)
+		return nil, nil, fmt.Errorf("root is not a directory: %s", absRoot)
 	}
 
 	// Build the tree
 	b := &builder{
 		root:     absRoot,
 		opts:     o,
+		start:    start,
 		trees:    make(map[[32]byte][]byte),
 		files:    make(map[[32]byte]*FileRef),
 		symlinks: make(map[[32]byte]string),
 		visited:  make(map[string]bool), // for cycle detection with symlinks
 	}
+	if o.snapshotCache {
+		b.prev = prev
+		b.next = newCaptureCache()
+	}
+	b.checkpoint = ckpt
 
 	rootHash, err := b.buildTree(absRoot, "")
 	if err != nil {
-		return nil, err
+		return nil, b.next, err
 	}
+	b.stats.Duration = time.Since(start)
 
 	return &Snapshot{
-		RootHash:   rootHash,
-		Trees:      b.trees,
-		Files:      b.files,
-		Symlinks:   b.symlinks,
-		CapturedAt: start,
-		Stats: SnapshotStats{
-			FileCount:    b.fileCount,
-			DirCount:     b.dirCount,
-			SymlinkCount: b.symlinkCount,
-			TotalBytes:   b.totalBytes,
-			Duration:     time.Since(start),
-		},
-	}, nil
+		RootHash:      rootHash,
+		Trees:         b.trees,
+		Files:         b.files,
+		Symlinks:      b.symlinks,
+		PathPrefix:    o.pathPrefix,
+		Store:         o.contentStore,
+		Metadata:      maps.Clone(o.metadata),
+		CapturedAt:    start,
+		CaptureErrors: b.captureErrors,
+		Stats:         b.stats,
+	}, b.next, nil
 }
 
 // builder accumulates state during tree construction.
 type builder struct {
 	root     string
 	opts     *options
+	start    time.Time
 	trees    map[[32]byte][]byte
 	files    map[[32]byte]*FileRef
 	symlinks map[[32]byte]string // target path for symlinks
 	visited  map[string]bool     // resolved paths for cycle detection
 
-	fileCount    int
-	dirCount     int
-	symlinkCount int
-	totalBytes   uint64
+	prev *captureCache // cache from the previous capture, if any
+	next *captureCache // cache being built, nil when caching is disabled
+
+	checkpoint *checkpointer // set by CaptureResumable
+
+	stats SnapshotStats
+
+	captureErrors []CaptureError
 }
 
 // buildTree recursively builds the tree for a directory.
@@ -116,7 +142,7 @@ func (b *builder) buildTree(absPath, relPath string) ([32]byte, error) {
 	}
 
 	// Read directory entries
-	dirEntries, err := os.ReadDir(absPath)
+	children, err := b.readDir(absPath, relPath)
 	if err != nil {
 		return [32]byte{}, fmt.Errorf("read dir %s: %w", relPath, err)
 	}
@@ -124,13 +150,13 @@ func (b *builder) buildTree(absPath, relPath string) ([32]byte, error) {
 	// Build entries for this directory
 	var entries []TreeEntry
 
-	for _, de := range dirEntries {
-		name := de.Name()
+	for _, child := range children {
+		name := child.name
 		childRelPath := filepath.Join(relPath, name)
 		childAbsPath := filepath.Join(absPath, name)
 
 		// Check exclusions
-		if b.opts.shouldExclude(childRelPath, de.IsDir()) {
+		if b.opts.shouldExclude(childRelPath, child.isDir) {
 			continue
 		}
 
@@ -143,6 +169,15 @@ func (b *builder) buildTree(absPath, relPath string) ([32]byte, error) {
 		}
 		if err != nil {
 			// Skip files we can't stat (permission errors, etc.)
+			if err := b.skipEntry(childRelPath, err); err != nil {
+				return [32]byte{}, err
+			}
+			continue
+		}
+		if !info.IsDir() && !b.opts.shouldInclude(childRelPath) {
+			continue
+		}
+		if b.opts.skipHidden && strings.HasPrefix(name, ".") {
 			continue
 		}
 
@@ -152,12 +187,22 @@ func (b *builder) buildTree(absPath, relPath string) ([32]byte, error) {
 				return [32]byte{}, err
 			}
 			// Skip individual files on error
+			if !errors.Is(err, errNothingIncluded) && !errors.Is(err, ErrFileTooLarge) {
+				if err := b.skipEntry(childRelPath, err); err != nil {
+					return [32]byte{}, err
+				}
+			}
 			continue
 		}
 
 		entries = append(entries, entry)
 	}
 
+	// With an allow-list, directories exist only to reach included entries
+	if len(entries) == 0 && relPath != "" && len(b.opts.includePatterns) > 0 {
+		return [32]byte{}, errNothingIncluded
+	}
+
 	// Sort entries by name for deterministic hashing
 	sort.Slice(entries, func(i, j int) bool {
 		return entries[i].Name < entries[j].Name
@@ -171,7 +216,7 @@ func (b *builder) buildTree(absPath, relPath string) ([32]byte, error) {
 
 	hash := blake3.Sum256(treeBytes)
 	b.trees[hash] = treeBytes
-	b.dirCount++
+	b.stats.DirCount++
 
 	return hash, nil
 }
@@ -189,7 +234,7 @@ func (b *builder) buildEntry(absPath, relPath, name string, info fs.FileInfo) (T
 		}
 
 		hash := blake3.Sum256([]byte(target))
-		b.symlinkCount++
+		b.stats.SymlinkCount++
 
 		// Store symlink target string (not as FileRef since content is the target path)
 		b.symlinks[hash] = target
@@ -202,6 +247,22 @@ func (b *builder) buildEntry(absPath, relPath, name string, info fs.FileInfo) (T
 			Hash: hash,
 		}, nil
 
+	case info.IsDir() && b.atMaxDepth(relPath):
+		// Directory at the depth limit - record it without its contents
+		hash, err := b.emptyTree(relPath)
+		if err != nil {
+			return TreeEntry{}, err
+		}
+
+		return TreeEntry{
+			Name:      name,
+			Kind:      EntryKindDirectory,
+			Mode:      mode,
+			Hash:      hash,
+			Xattrs:    b.xattrs(absPath),
+			Truncated: true,
+		}, nil
+
 	case info.IsDir():
 		// Directory - recurse
 		dirHash, err := b.buildTree(absPath, relPath)
@@ -209,17 +270,29 @@ func (b *builder) buildEntry(absPath, relPath, name string, info fs.FileInfo) (T
 			return TreeEntry{}, err
 		}
 
+		return TreeEntry{
+			Name:   name,
+			Kind:   EntryKindDirectory,
+			Mode:   mode,
+			Size:   0,
+			Hash:   dirHash,
+			Xattrs: b.xattrs(absPath),
+		}, nil
+
+	case !info.Mode().IsRegular():
+		// Named pipe, socket, or device - record it without opening it,
+		// since reading a FIFO or device can block indefinitely.
+		b.stats.SpecialCount++
+
 		return TreeEntry{
 			Name: name,
-			Kind: EntryKindDirectory,
-			Mode: mode,
-			Size: 0,
-			Hash: dirHash,
+			Kind: EntryKindSpecial,
+			Mode: specialFileType(info.Mode()) | mode,
 		}, nil
 
 	default:
 		// Regular file
-		if b.fileCount >= b.opts.maxFiles {
+		if b.stats.FileCount >= b.opts.maxFiles {
 			return TreeEntry{}, ErrTooManyFiles
 		}
 
@@ -228,29 +301,115 @@ func (b *builder) buildEntry(absPath, relPath, name string, info fs.FileInfo) (T
 			return TreeEntry{}, fmt.Errorf("%w: %s (%d bytes)", ErrFileTooLarge, relPath, size)
 		}
 
-		hash, err := hashFile(absPath)
+		hash, err := b.fileHash(absPath, relPath, info)
 		if err != nil {
 			return TreeEntry{}, fmt.Errorf("hash file %s: %w", relPath, err)
 		}
+		if b.opts.contentStore != nil {
+			if err := b.storeFile(absPath, hash); err != nil {
+				return TreeEntry{}, fmt.Errorf("store file %s: %w", relPath, err)
+			}
+		}
 
+		_, seen := b.files[hash]
 		b.files[hash] = &FileRef{
 			Path: absPath,
 			Size: uint64(size),
 			Hash: hash,
 		}
-		b.fileCount++
-		b.totalBytes += uint64(size)
+		b.stats.addFile(b.walkPath(relPath), uint64(size), !seen)
 
 		return TreeEntry{
-			Name: name,
-			Kind: EntryKindFile,
-			Mode: mode,
-			Size: uint64(size),
-			Hash: hash,
+			Name:   name,
+			Kind:   EntryKindFile,
+			Mode:   mode,
+			Size:   uint64(size),
+			Hash:   hash,
+			Xattrs: b.xattrs(absPath),
 		}, nil
 	}
 }
 
+// skipEntry handles an entry that could not be read according to the
+// WithErrorPolicy setting: it records the error and returns nil, or returns
+// the error that stops the capture.
+func (b *builder) skipEntry(relPath string, err error) error {
+	var captureErr *CaptureError
+	if errors.As(err, &captureErr) {
+		// Already reported for an entry further down.
+		return err
+	}
+
+	p := b.walkPath(relPath)
+	if b.opts.errorPolicy == ErrorPolicyFail {
+		return &CaptureError{Path: p, Err: err}
+	}
+	b.captureErrors = append(b.captureErrors, CaptureError{Path: p, Err: err})
+	return nil
+}
+
+// walkPath returns relPath in the form Walk reports it.
+func (b *builder) walkPath(relPath string) string {
+	p := filepath.ToSlash(relPath)
+	if b.opts.pathPrefix != "" {
+		p = path.Join(b.opts.pathPrefix, p)
+	}
+	return p
+}
+
+// atMaxDepth reports whether the directory at relPath is at the WithMaxDepth
+// limit, so its contents are not captured.
+func (b *builder) atMaxDepth(relPath string) bool {
+	if b.opts.maxDepth <= 0 {
+		return false
+	}
+	depth := strings.Count(filepath.ToSlash(relPath), "/") + 1
+	return depth >= b.opts.maxDepth
+}
+
+// emptyTree records the tree object of a truncated directory.
+func (b *builder) emptyTree(relPath string) ([32]byte, error) {
+	treeBytes, err := serializeTree(nil)
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("serialize tree %s: %w", relPath, err)
+	}
+
+	hash := blake3.Sum256(treeBytes)
+	b.trees[hash] = treeBytes
+	b.stats.DirCount++
+
+	return hash, nil
+}
+
+// xattrs returns the extended attributes to record for absPath, or nil when
+// WithXattrs is off or the attributes cannot be read.
+func (b *builder) xattrs(absPath string) map[string][]byte {
+	if !b.opts.xattrs {
+		return nil
+	}
+	attrs, err := readXattrs(absPath)
+	if err != nil || len(attrs) == 0 {
+		return nil
+	}
+	return attrs
+}
+
+// specialFileType returns the POSIX S_IFMT bits for a non-regular file.
+func specialFileType(m fs.FileMode) uint32 {
+	switch {
+	case m&fs.ModeNamedPipe != 0:
+		return 0010000 // S_IFIFO
+	case m&fs.ModeSocket != 0:
+		return 0140000 // S_IFSOCK
+	case m&fs.ModeCharDevice != 0:
+		return 0020000 // S_IFCHR
+	case m&fs.ModeDevice != 0:
+		return 0060000 // S_IFBLK
+	default:
+		return 0
+	}
+}
+
 // hashFile computes the BLAKE3-256 hash of a file's contents.
 func hashFile(path string) ([32]byte, error) {
 	f, err := os.Open(path)
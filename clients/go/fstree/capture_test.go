@@ -4,9 +4,16 @@
 package fstree
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCapture_BasicTree(t *testing.T) {
@@ -32,6 +39,12 @@ func TestCapture_BasicTree(t *testing.T) {
 	if snap.Stats.DirCount != 2 { // root + src
 		t.Errorf("expected 2 directories, got %d", snap.Stats.DirCount)
 	}
+	if snap.Stats.TotalBytes != 45 || snap.Stats.UniqueBytes != 45 {
+		t.Errorf("expected 45 total and unique bytes, got %d and %d", snap.Stats.TotalBytes, snap.Stats.UniqueBytes)
+	}
+	if snap.Stats.LargestFile != "src/lib.go" || snap.Stats.LargestFileSize != 27 {
+		t.Errorf("expected largest file src/lib.go (27 bytes), got %s (%d bytes)", snap.Stats.LargestFile, snap.Stats.LargestFileSize)
+	}
 
 	// Verify we can list files
 	files, err := snap.ListFiles()
@@ -43,6 +56,66 @@ func TestCapture_BasicTree(t *testing.T) {
 	}
 }
 
+func TestCapture_StatsTotals(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	_ = os.MkdirAll(filepath.Join(tmpDir, "a"), 0755)
+	_ = os.MkdirAll(filepath.Join(tmpDir, "b"), 0755)
+	_ = os.WriteFile(filepath.Join(tmpDir, "a", "big.bin"), []byte(strings.Repeat("x", 100)), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "b", "big.bin"), []byte(strings.Repeat("x", 100)), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "b", "other.bin"), []byte(strings.Repeat("y", 100)), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "small.txt"), []byte("tiny"), 0644)
+
+	snap, err := Capture(tmpDir, WithPathPrefix("ws"))
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+
+	// a/big.bin and b/big.bin share content, so it counts once in UniqueBytes.
+	// Of the three 100-byte files, the path that sorts first is named.
+	want := SnapshotStats{
+		FileCount:       4,
+		DirCount:        3,
+		TotalBytes:      304,
+		UniqueBytes:     204,
+		LargestFile:     "ws/a/big.bin",
+		LargestFileSize: 100,
+	}
+	got := snap.Stats
+	got.Duration = 0
+	if got != want {
+		t.Errorf("stats = %+v, want %+v", got, want)
+	}
+
+	// Filter and the payload encoding keep the totals consistent.
+	filtered, err := snap.Filter(func(p string, _ TreeEntry) bool { return p != "ws/a" })
+	if err != nil {
+		t.Fatalf("Filter failed: %v", err)
+	}
+	if s := filtered.Stats; s.TotalBytes != 204 || s.UniqueBytes != 204 || s.LargestFile != "ws/b/big.bin" {
+		t.Errorf("filtered stats = %+v", s)
+	}
+	payload, err := EncodeSnapshot(snap)
+	if err != nil {
+		t.Fatalf("EncodeSnapshot failed: %v", err)
+	}
+	decoded, err := DecodeSnapshot(payload)
+	if err != nil {
+		t.Fatalf("DecodeSnapshot failed: %v", err)
+	}
+	if s := decoded.Stats; s.UniqueBytes != 204 || s.LargestFile != "ws/a/big.bin" || s.LargestFileSize != 100 {
+		t.Errorf("decoded stats = %+v", s)
+	}
+
+	empty, err := Capture(t.TempDir())
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	if empty.Stats.LargestFile != "" || empty.Stats.UniqueBytes != 0 {
+		t.Errorf("empty capture stats = %+v", empty.Stats)
+	}
+}
+
 func TestCapture_DeterministicHash(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -64,6 +137,68 @@ func TestCapture_DeterministicHash(t *testing.T) {
 	if snap1.RootHash != snap2.RootHash {
 		t.Errorf("root hashes differ:\n  snap1: %x\n  snap2: %x", snap1.RootHash, snap2.RootHash)
 	}
+	if !snap1.Equal(snap2) {
+		t.Error("expected snapshots to be Equal")
+	}
+}
+
+func TestSnapshot_Equal(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	_ = os.WriteFile(filepath.Join(tmpDir, "a.txt"), []byte("hello"), 0644)
+	_ = os.MkdirAll(filepath.Join(tmpDir, "sub"), 0755)
+	_ = os.WriteFile(filepath.Join(tmpDir, "sub", "b.txt"), []byte("world"), 0644)
+
+	snap1, err := Capture(tmpDir)
+	if err != nil {
+		t.Fatalf("Capture 1 failed: %v", err)
+	}
+	snap2, err := Capture(tmpDir)
+	if err != nil {
+		t.Fatalf("Capture 2 failed: %v", err)
+	}
+
+	if !snap1.Equal(snap2) || snap1.HasChangedSince(snap2) {
+		t.Error("expected identical captures to be equal")
+	}
+	if eq, err := snap1.EqualDeep(snap2); err != nil || !eq {
+		t.Errorf("EqualDeep = %v, %v; want true, nil", eq, err)
+	}
+	if !snap1.HasChangedSince(nil) {
+		t.Error("expected HasChangedSince(nil) to be true")
+	}
+	if snap1.Equal(nil) {
+		t.Error("expected snapshot not to equal nil")
+	}
+
+	_ = os.WriteFile(filepath.Join(tmpDir, "sub", "b.txt"), []byte("changed"), 0644)
+	snap3, err := Capture(tmpDir)
+	if err != nil {
+		t.Fatalf("Capture 3 failed: %v", err)
+	}
+	if snap3.Equal(snap1) || !snap3.HasChangedSince(snap1) {
+		t.Error("expected modified capture to differ")
+	}
+	if eq, err := snap3.EqualDeep(snap1); err != nil || eq {
+		t.Errorf("EqualDeep = %v, %v; want false, nil", eq, err)
+	}
+
+	// A subtree object that does not match its hash is caught only by the
+	// deep check.
+	var subHash [32]byte
+	entries, _ := snap2.GetRootEntries()
+	for _, e := range entries {
+		if e.Name == "sub" {
+			subHash = e.Hash
+		}
+	}
+	snap2.Trees[subHash] = append([]byte(nil), snap3.Trees[snap3.RootHash]...)
+	if !snap1.Equal(snap2) {
+		t.Error("expected Equal to compare only root hashes")
+	}
+	if _, err := snap1.EqualDeep(snap2); err == nil {
+		t.Error("expected EqualDeep to report the corrupt tree")
+	}
 }
 
 func TestCapture_ContentAddressing(t *testing.T) {
@@ -107,6 +242,163 @@ func TestCapture_ExcludePatterns(t *testing.T) {
 	}
 }
 
+func TestCapture_IncludePatterns(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"main.go":                "package main",
+		"README.md":              "# readme",
+		"src/lib/lib.go":         "package lib",
+		"src/lib/lib_test.go":    "package lib",
+		"src/lib/data.json":      "{}",
+		"src/cmd/tool/tool.go":   "package main",
+		"docs/guide.md":          "guide",
+		"vendor/dep/dep.go":      "package dep",
+		"vendor/dep/LICENSE.txt": "license",
+	}
+	for name, content := range files {
+		p := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(tmpDir, "empty"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	list := func(t *testing.T, opts ...Option) (string, *Snapshot) {
+		t.Helper()
+		snap, err := Capture(tmpDir, opts...)
+		if err != nil {
+			t.Fatalf("Capture failed: %v", err)
+		}
+		var paths []string
+		_ = snap.Walk(func(p string, entry TreeEntry) error {
+			paths = append(paths, p)
+			return nil
+		})
+		sort.Strings(paths)
+		return strings.Join(paths, ","), snap
+	}
+
+	// A base-name pattern reaches into nested directories, and directories
+	// with nothing included are omitted.
+	got, snap := list(t, WithInclude("**/*.go"))
+	want := "main.go,src,src/cmd,src/cmd/tool,src/cmd/tool/tool.go,src/lib,src/lib/lib.go,src/lib/lib_test.go,vendor,vendor/dep,vendor/dep/dep.go"
+	if got != want {
+		t.Fatalf("paths = %s, want %s", got, want)
+	}
+	if snap.Stats.FileCount != 5 || snap.Stats.DirCount != 7 {
+		t.Fatalf("unexpected stats %+v", snap.Stats)
+	}
+
+	// Exclusions win over inclusions.
+	got, _ = list(t, WithInclude("src/**/*.go", "*.md"), WithExclude("*_test.go", "docs"))
+	want = "README.md,src,src/cmd,src/cmd/tool,src/cmd/tool/tool.go,src/lib,src/lib/lib.go"
+	if got != want {
+		t.Fatalf("paths = %s, want %s", got, want)
+	}
+
+	// An include that matches everything is the same as no include, apart
+	// from originally empty directories.
+	all, err := Capture(tmpDir, WithExclude("empty"))
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	if _, snap := list(t, WithInclude("*")); snap.RootHash != all.RootHash {
+		t.Fatal("include-all RootHash should match a capture without the empty directory")
+	}
+}
+
+func TestCapture_SkipHidden(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"visible.txt", ".hidden.txt", "src/main.go", "src/.env", ".config/settings.json", ".config/nested/app.go"} {
+		p := filepath.Join(tmpDir, filepath.FromSlash(name))
+		_ = os.MkdirAll(filepath.Dir(p), 0o755)
+		_ = os.WriteFile(p, []byte(name), 0o644)
+	}
+
+	list := func(t *testing.T, opts ...Option) string {
+		t.Helper()
+		snap, err := Capture(tmpDir, opts...)
+		if err != nil {
+			t.Fatalf("Capture failed: %v", err)
+		}
+		var paths []string
+		_ = snap.Walk(func(p string, entry TreeEntry) error {
+			paths = append(paths, p)
+			return nil
+		})
+		sort.Strings(paths)
+		return strings.Join(paths, ",")
+	}
+
+	// Hidden directories are pruned along with their visible contents.
+	if got, want := list(t, WithSkipHidden(true)), "src,src/main.go,visible.txt"; got != want {
+		t.Fatalf("paths = %s, want %s", got, want)
+	}
+
+	// An include pattern does not bring hidden entries back.
+	if got, want := list(t, WithSkipHidden(true), WithInclude("*.go", ".env")), "src,src/main.go"; got != want {
+		t.Fatalf("paths = %s, want %s", got, want)
+	}
+
+	if got := list(t, WithSkipHidden(false)); !strings.Contains(got, ".config/nested/app.go") || !strings.Contains(got, "src/.env") {
+		t.Fatalf("hidden entries missing without WithSkipHidden: %s", got)
+	}
+}
+
+func TestCapture_ExcludePresets(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	for _, dir := range []string{".git", "sub/.hg", "node_modules/pkg", "sub/__pycache__", "src"} {
+		_ = os.MkdirAll(filepath.Join(tmpDir, filepath.FromSlash(dir)), 0755)
+		_ = os.WriteFile(filepath.Join(tmpDir, filepath.FromSlash(dir), "file"), []byte(dir), 0644)
+	}
+	_ = os.WriteFile(filepath.Join(tmpDir, "src", "debug.log"), []byte("log"), 0644)
+
+	tests := []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{
+			name: "vcs",
+			opts: []Option{WithExcludeVCS()},
+			want: []string{"node_modules/pkg/file", "src/debug.log", "src/file", "sub/__pycache__/file"},
+		},
+		{
+			name: "common",
+			opts: []Option{WithExcludeCommon()},
+			want: []string{"src/debug.log", "src/file"},
+		},
+		{
+			name: "common with user patterns",
+			opts: []Option{WithExclude("*.log"), WithExcludeCommon()},
+			want: []string{"src/file"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snap, err := Capture(tmpDir, tt.opts...)
+			if err != nil {
+				t.Fatalf("Capture failed: %v", err)
+			}
+			files, err := snap.ListFiles()
+			if err != nil {
+				t.Fatalf("ListFiles failed: %v", err)
+			}
+			sort.Strings(files)
+			if strings.Join(files, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expected %v, got %v", tt.want, files)
+			}
+		})
+	}
+}
+
 func TestCapture_Symlinks(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -127,6 +419,53 @@ func TestCapture_Symlinks(t *testing.T) {
 	}
 }
 
+func TestCapture_EntryKinds(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	_ = os.MkdirAll(filepath.Join(tmpDir, "dir"), 0755)
+	_ = os.WriteFile(filepath.Join(tmpDir, "dir", "nested.txt"), []byte("nested"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "file.txt"), []byte("content"), 0644)
+	_ = os.Symlink("file.txt", filepath.Join(tmpDir, "link"))
+	_ = os.Symlink("dir", filepath.Join(tmpDir, "dirlink"))
+
+	snap, err := Capture(tmpDir)
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+
+	want := map[string]EntryKind{
+		"dir":            EntryKindDirectory,
+		"dir/nested.txt": EntryKindFile,
+		"file.txt":       EntryKindFile,
+		"link":           EntryKindSymlink,
+		"dirlink":        EntryKindSymlink,
+	}
+
+	entries, err := snap.GetRootEntries()
+	if err != nil {
+		t.Fatalf("GetRootEntries failed: %v", err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 root entries, got %d", len(entries))
+	}
+	for _, e := range entries {
+		if e.Kind != want[e.Name] {
+			t.Errorf("root entry %s: kind %v, want %v", e.Name, e.Kind, want[e.Name])
+		}
+	}
+
+	got := make(map[string]EntryKind)
+	if err := snap.Walk(func(path string, entry TreeEntry) error {
+		got[path] = entry.Kind
+		return nil
+	}); err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk kinds = %v, want %v", got, want)
+	}
+}
+
 func TestCapture_ModeBits(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -196,6 +535,410 @@ func TestSnapshot_Diff(t *testing.T) {
 	}
 }
 
+func TestSnapshot_DiffCompareMode(t *testing.T) {
+	tmpDir := t.TempDir()
+	shared := filepath.Join(tmpDir, "shared.txt")
+	_ = os.WriteFile(shared, []byte("same content"), 0644)
+	_ = os.Chmod(shared, 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "edit.txt"), []byte("before"), 0644)
+
+	snap1, err := Capture(tmpDir)
+	if err != nil {
+		t.Fatalf("Capture 1 failed: %v", err)
+	}
+
+	// Same content, group-writable, as under a different umask.
+	_ = os.Chmod(shared, 0664)
+	_ = os.WriteFile(filepath.Join(tmpDir, "edit.txt"), []byte("after"), 0644)
+
+	snap2, err := Capture(tmpDir)
+	if err != nil {
+		t.Fatalf("Capture 2 failed: %v", err)
+	}
+	if snap1.Equal(snap2) {
+		t.Fatal("RootHash ignores the mode change")
+	}
+
+	tests := []struct {
+		name string
+		opts DiffOptions
+		want []string
+	}{
+		{"default", DiffOptions{}, []string{"edit.txt"}},
+		{"compare mode", DiffOptions{CompareMode: true}, []string{"edit.txt", "shared.txt"}},
+	}
+	for _, tt := range tests {
+		diff, err := snap2.DiffWithOptions(snap1, tt.opts)
+		if err != nil {
+			t.Fatalf("%s: DiffWithOptions failed: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(diff.Modified, tt.want) {
+			t.Errorf("%s: modified = %v, want %v", tt.name, diff.Modified, tt.want)
+		}
+		if len(diff.Added) != 0 || len(diff.Removed) != 0 {
+			t.Errorf("%s: added %v, removed %v, want none", tt.name, diff.Added, diff.Removed)
+		}
+	}
+
+	// Diff stays content-only.
+	diff, err := snap2.Diff(snap1)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if want := []string{"edit.txt"}; !reflect.DeepEqual(diff.Modified, want) {
+		t.Errorf("Diff modified = %v, want %v", diff.Modified, want)
+	}
+}
+
+func TestSnapshot_DiffStream(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	_ = os.MkdirAll(filepath.Join(tmpDir, "same"), 0755)
+	_ = os.WriteFile(filepath.Join(tmpDir, "same", "file.txt"), []byte("same"), 0644)
+	_ = os.MkdirAll(filepath.Join(tmpDir, "src"), 0755)
+	_ = os.WriteFile(filepath.Join(tmpDir, "src", "a.go"), []byte("a"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "src", "b.go"), []byte("b"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "swap"), []byte("file"), 0644)
+
+	snap1, err := Capture(tmpDir)
+	if err != nil {
+		t.Fatalf("Capture 1 failed: %v", err)
+	}
+
+	_ = os.WriteFile(filepath.Join(tmpDir, "src", "a.go"), []byte("changed"), 0644)
+	_ = os.Remove(filepath.Join(tmpDir, "src", "b.go"))
+	_ = os.WriteFile(filepath.Join(tmpDir, "src", "c.go"), []byte("c"), 0644)
+	_ = os.Remove(filepath.Join(tmpDir, "swap"))
+	_ = os.MkdirAll(filepath.Join(tmpDir, "swap"), 0755)
+	_ = os.WriteFile(filepath.Join(tmpDir, "swap", "inner.txt"), []byte("inner"), 0644)
+
+	snap2, err := Capture(tmpDir)
+	if err != nil {
+		t.Fatalf("Capture 2 failed: %v", err)
+	}
+
+	var got []string
+	err = snap2.DiffStream(snap1, func(change DiffChange) error {
+		var kind string
+		switch change.Kind {
+		case ChangeAdded:
+			kind = "A"
+		case ChangeRemoved:
+			kind = "R"
+		case ChangeModified:
+			kind = "M"
+		}
+		got = append(got, kind+" "+change.Path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("DiffStream failed: %v", err)
+	}
+
+	want := []string{"M src/a.go", "R src/b.go", "A src/c.go", "R swap", "A swap/inner.txt"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+
+	// Returning an error stops the stream.
+	stop := errors.New("stop")
+	calls := 0
+	err = snap2.DiffStream(snap1, func(change DiffChange) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("expected early stop after 1 call, got err=%v calls=%d", err, calls)
+	}
+
+	// A nil base reports every file as added.
+	var added int
+	if err := snap2.DiffStream(nil, func(change DiffChange) error {
+		if change.Kind == ChangeAdded {
+			added++
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("DiffStream(nil) failed: %v", err)
+	}
+	if added != snap2.Stats.FileCount {
+		t.Errorf("expected %d added, got %d", snap2.Stats.FileCount, added)
+	}
+}
+
+func TestSnapshot_UniqueDirCount(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	for _, dir := range []string{"a", "b"} {
+		_ = os.MkdirAll(filepath.Join(tmpDir, dir, "vendor", "lib"), 0755)
+		_ = os.WriteFile(filepath.Join(tmpDir, dir, "vendor", "lib", "lib.go"), []byte("package lib"), 0644)
+	}
+
+	snap, err := Capture(tmpDir)
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+
+	// root, a, b, and two copies each of vendor and lib
+	if snap.Stats.DirCount != 7 {
+		t.Errorf("expected 7 directories, got %d", snap.Stats.DirCount)
+	}
+	// root plus one shared tree for each of a/b, vendor, and lib
+	if got := snap.UniqueDirCount(); got != 4 {
+		t.Errorf("expected 4 unique directories, got %d", got)
+	}
+
+	entries, err := snap.GetRootEntries()
+	if err != nil {
+		t.Fatalf("GetRootEntries failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Hash != entries[1].Hash {
+		t.Errorf("expected identical subtrees to share a hash, got %+v", entries)
+	}
+}
+
+func TestSnapshot_Filter(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"src/main.go":        "package main\n\nfunc main() {}\n",
+		"src/util/util.go":   "package util\n",
+		"docs/README.md":     "readme with some text",
+		"docs/small.txt":     "tiny",
+		"notes.txt":          "top-level notes file",
+		"src/util/empty.txt": "",
+	}
+	for name, content := range files {
+		p := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(tmpDir, "src", "gen"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("main.go", filepath.Join(tmpDir, "src", "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	snap, err := Capture(tmpDir)
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+
+	// Keeping a subtree matches a capture that excluded everything else.
+	underSrc, err := snap.Filter(func(p string, entry TreeEntry) bool {
+		return p == "src" || strings.HasPrefix(p, "src/")
+	})
+	if err != nil {
+		t.Fatalf("Filter failed: %v", err)
+	}
+	want, err := Capture(tmpDir, WithExcludeFunc(func(p string, isDir bool) bool {
+		p = filepath.ToSlash(p)
+		return p != "src" && !strings.HasPrefix(p, "src/")
+	}))
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	if underSrc.RootHash != want.RootHash {
+		t.Fatal("filtered RootHash should match a capture excluding the same entries")
+	}
+	if len(underSrc.Trees) != len(want.Trees) || len(underSrc.Files) != len(want.Files) || len(underSrc.Symlinks) != len(want.Symlinks) {
+		t.Fatalf("content store not pruned: trees %d/%d files %d/%d symlinks %d/%d",
+			len(underSrc.Trees), len(want.Trees), len(underSrc.Files), len(want.Files), len(underSrc.Symlinks), len(want.Symlinks))
+	}
+	wantStats := want.Stats
+	wantStats.Duration = snap.Stats.Duration
+	if underSrc.Stats != wantStats {
+		t.Fatalf("stats = %+v, want %+v", underSrc.Stats, wantStats)
+	}
+
+	// Filtering on size drops directories left empty, but keeps src/gen,
+	// which was empty to begin with.
+	large, err := snap.Filter(func(p string, entry TreeEntry) bool {
+		return entry.Kind == EntryKindDirectory || entry.Size > 16
+	})
+	if err != nil {
+		t.Fatalf("Filter failed: %v", err)
+	}
+	paths, err := large.ListFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(paths)
+	if got, want := strings.Join(paths, ","), "docs/README.md,notes.txt,src/main.go"; got != want {
+		t.Fatalf("files = %s, want %s", got, want)
+	}
+	var dirs []string
+	_ = large.Walk(func(p string, entry TreeEntry) error {
+		if entry.Kind == EntryKindDirectory {
+			dirs = append(dirs, p)
+		}
+		return nil
+	})
+	if got, want := strings.Join(dirs, ","), "docs,src,src/gen"; got != want {
+		t.Fatalf("dirs = %s, want %s", got, want)
+	}
+	if large.Stats.FileCount != 3 || large.Stats.DirCount != 4 || large.Stats.SymlinkCount != 0 {
+		t.Fatalf("unexpected stats %+v", large.Stats)
+	}
+	if len(large.Files) != 3 || len(large.Symlinks) != 0 {
+		t.Fatalf("content store not pruned: %d files, %d symlinks", len(large.Files), len(large.Symlinks))
+	}
+	if snap.Stats.FileCount != len(files) {
+		t.Fatal("Filter must not modify the original snapshot")
+	}
+}
+
+func TestSnapshot_Prune(t *testing.T) {
+	tmpDir := t.TempDir()
+	big := strings.Repeat("x", 64)
+	files := map[string]string{
+		"small.txt":     "tiny",
+		"data/big.bin":  big,
+		"data/copy.bin": big,
+		"blobs/huge":    strings.Repeat("y", 128),
+		"src/main.go":   "package main\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snap, err := Capture(tmpDir)
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	before := len(snap.Files)
+	oldFiles := snap.Files
+
+	if err := snap.Prune(func(p string, entry TreeEntry) bool {
+		return entry.Kind == EntryKindFile && entry.Size > 32
+	}); err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+
+	// data and blobs held only large files and are removed with them.
+	want, err := Capture(tmpDir, WithExcludeFunc(func(p string, isDir bool) bool {
+		p = filepath.ToSlash(p)
+		return p == "data" || p == "blobs"
+	}))
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	if snap.RootHash != want.RootHash {
+		t.Fatal("pruned RootHash should match a capture excluding the same entries")
+	}
+	if len(snap.Files) != 2 || before != 4 {
+		t.Fatalf("files before/after prune = %d/%d, want 4/2", before, len(snap.Files))
+	}
+	if len(snap.Trees) != len(want.Trees) {
+		t.Fatalf("trees = %d, want %d", len(snap.Trees), len(want.Trees))
+	}
+	wantStats := want.Stats
+	wantStats.Duration = snap.Stats.Duration
+	if snap.Stats != wantStats {
+		t.Fatalf("stats = %+v, want %+v", snap.Stats, wantStats)
+	}
+	if len(oldFiles) != before {
+		t.Fatal("Prune modified the Files map it replaced")
+	}
+	if err := snap.Walk(func(p string, entry TreeEntry) error {
+		if entry.Size > 32 {
+			t.Errorf("%s survived the prune", p)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+}
+
+func TestSnapshot_Subtree(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"src/main.go":      "package main\n\nfunc main() {}\n",
+		"src/util/util.go": "package util\n",
+		"docs/README.md":   "readme",
+		"notes.txt":        "top-level notes file",
+	}
+	for name, content := range files {
+		p := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("main.go", filepath.Join(tmpDir, "src", "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	snap, err := Capture(tmpDir, WithPathPrefix("repo"))
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+
+	sub, err := snap.Subtree("repo/src")
+	if err != nil {
+		t.Fatalf("Subtree failed: %v", err)
+	}
+	want, err := Capture(filepath.Join(tmpDir, "src"))
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	if sub.RootHash != want.RootHash {
+		t.Fatal("subtree RootHash should match a direct capture of the directory")
+	}
+	if len(sub.Trees) != len(want.Trees) || len(sub.Files) != len(want.Files) || len(sub.Symlinks) != len(want.Symlinks) {
+		t.Fatalf("content store not pruned: trees %d/%d files %d/%d symlinks %d/%d",
+			len(sub.Trees), len(want.Trees), len(sub.Files), len(want.Files), len(sub.Symlinks), len(want.Symlinks))
+	}
+	wantStats := want.Stats
+	wantStats.Duration = snap.Stats.Duration
+	if sub.Stats != wantStats {
+		t.Fatalf("stats = %+v, want %+v", sub.Stats, wantStats)
+	}
+	if sub.PathPrefix != "" {
+		t.Fatalf("PathPrefix = %q, want empty", sub.PathPrefix)
+	}
+	paths, err := sub.ListFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(paths)
+	if got, want := strings.Join(paths, ","), "main.go,util/util.go"; got != want {
+		t.Fatalf("files = %s, want %s", got, want)
+	}
+
+	// The prefix itself is the whole tree.
+	whole, err := snap.Subtree("repo")
+	if err != nil {
+		t.Fatalf("Subtree failed: %v", err)
+	}
+	if whole.RootHash != snap.RootHash || whole.Stats.FileCount != len(files) {
+		t.Fatal("subtree at the prefix should match the original tree")
+	}
+
+	for _, bad := range []string{"repo/missing", "src", "repo/notes.txt", "repo/src/main.go/x"} {
+		if _, err := snap.Subtree(bad); err == nil {
+			t.Errorf("Subtree(%q) should fail", bad)
+		}
+	}
+}
+
 func TestSnapshot_GetFileAtPath(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -221,6 +964,134 @@ func TestSnapshot_GetFileAtPath(t *testing.T) {
 	}
 }
 
+func TestSnapshot_BlobReader(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	_ = os.WriteFile(filepath.Join(tmpDir, "a.txt"), []byte("hello"), 0644)
+	if err := os.Symlink("a.txt", filepath.Join(tmpDir, "link")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	snap, err := Capture(tmpDir)
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+
+	read := func(hash [32]byte) string {
+		t.Helper()
+		r, err := snap.BlobReader(fmt.Sprintf("%x", hash))
+		if err != nil {
+			t.Fatalf("BlobReader(%x) failed: %v", hash[:8], err)
+		}
+		defer func() { _ = r.Close() }()
+		data, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	for hash := range snap.Files {
+		if got := read(hash); got != "hello" {
+			t.Errorf("file blob = %q, want %q", got, "hello")
+		}
+	}
+	for hash := range snap.Symlinks {
+		if got := read(hash); got != "a.txt" {
+			t.Errorf("symlink blob = %q, want %q", got, "a.txt")
+		}
+	}
+	if got := read(snap.RootHash); got != string(snap.Trees[snap.RootHash]) {
+		t.Error("tree blob does not match the serialized root tree")
+	}
+
+	if _, err := snap.BlobReader(strings.Repeat("00", 32)); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("expected ErrBlobNotFound, got %v", err)
+	}
+	if _, err := snap.BlobReader("not-a-hash"); err == nil || errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("expected an invalid hash error, got %v", err)
+	}
+}
+
+func TestSnapshot_WindowsPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	_ = os.MkdirAll(filepath.Join(tmpDir, "src", "pkg"), 0755)
+	_ = os.WriteFile(filepath.Join(tmpDir, "src", "pkg", "main.go"), []byte("package main"), 0644)
+
+	snap, err := Capture(tmpDir)
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+
+	for _, p := range []string{filepath.FromSlash("src/pkg/main.go"), `src\pkg\main.go`} {
+		entry, reader, err := snap.GetFileAtPath(p)
+		if err != nil {
+			t.Fatalf("GetFileAtPath(%q) failed: %v", p, err)
+		}
+		_ = reader.Close()
+		if entry.Name != "main.go" {
+			t.Errorf("GetFileAtPath(%q): expected name 'main.go', got '%s'", p, entry.Name)
+		}
+	}
+
+	files, err := snap.ListFiles()
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	if len(files) != 1 || files[0] != "src/pkg/main.go" {
+		t.Errorf("expected [src/pkg/main.go], got %v", files)
+	}
+}
+
+func TestCapture_PathPrefix(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	_ = os.MkdirAll(filepath.Join(tmpDir, "src"), 0755)
+	_ = os.WriteFile(filepath.Join(tmpDir, "src", "main.go"), []byte("package main"), 0644)
+
+	plain, err := Capture(tmpDir)
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	snap, err := Capture(tmpDir, WithPathPrefix(`\repos\app\`))
+	if err != nil {
+		t.Fatalf("Capture with prefix failed: %v", err)
+	}
+
+	if snap.PathPrefix != "repos/app" {
+		t.Errorf("expected prefix 'repos/app', got %q", snap.PathPrefix)
+	}
+	if snap.RootHash != plain.RootHash {
+		t.Error("expected prefix not to change the root hash")
+	}
+
+	files, err := snap.ListFiles()
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	if len(files) != 1 || files[0] != "repos/app/src/main.go" {
+		t.Errorf("expected [repos/app/src/main.go], got %v", files)
+	}
+
+	_, reader, err := snap.GetFileAtPath("repos/app/src/main.go")
+	if err != nil {
+		t.Fatalf("GetFileAtPath failed: %v", err)
+	}
+	_ = reader.Close()
+	if _, _, err := snap.GetFileAtPath("src/main.go"); err == nil {
+		t.Error("expected unprefixed path not to resolve")
+	}
+
+	diff, err := snap.Diff(plain)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if len(diff.Added) != 1 || len(diff.Removed) != 1 {
+		t.Errorf("expected prefixed and unprefixed paths to differ, got %+v", diff)
+	}
+}
+
 func TestTracker_SnapshotIfChanged(t *testing.T) {
 	tmpDir := t.TempDir()
 	_ = os.WriteFile(filepath.Join(tmpDir, "file.txt"), []byte("content"), 0644)
@@ -295,3 +1166,138 @@ func TestCapture_MaxFileSize(t *testing.T) {
 		t.Errorf("expected 1 file (small only), got %d", snap.Stats.FileCount)
 	}
 }
+
+func TestCapture_MaxDepth(t *testing.T) {
+	tmpDir := writeTree(t, map[string]string{
+		"top.txt":       "top",
+		"a/x.txt":       "x",
+		"a/b/c/deep.go": "package c",
+	})
+	if err := os.Mkdir(filepath.Join(tmpDir, "empty"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	full, err := Capture(tmpDir)
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	shallow, err := Capture(tmpDir, WithMaxDepth(1))
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+
+	var paths []string
+	truncated := map[string]bool{}
+	err = shallow.Walk(func(p string, entry TreeEntry) error {
+		paths = append(paths, p)
+		truncated[p] = entry.Truncated
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+	if want := []string{"a", "empty", "top.txt"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("shallow paths = %v, want %v", paths, want)
+	}
+	if !truncated["a"] || !truncated["empty"] || truncated["top.txt"] {
+		t.Errorf("unexpected Truncated flags: %v", truncated)
+	}
+	if shallow.Stats.FileCount != 1 {
+		t.Errorf("expected 1 file in shallow capture, got %d", shallow.Stats.FileCount)
+	}
+
+	// A truncated directory hashes differently from a full one, even when the
+	// directory is empty, so the root hash differs too.
+	if shallow.RootHash == full.RootHash {
+		t.Error("expected shallow capture to have a different RootHash")
+	}
+	entries, _ := shallow.GetRootEntries()
+	fullEntries, _ := full.GetRootEntries()
+	for i := range entries {
+		if entries[i].Name == "empty" && entries[i].Hash != fullEntries[i].Hash {
+			t.Error("expected a truncated empty directory to keep the empty tree hash")
+		}
+	}
+
+	// Depth 3 truncates a/b/c only.
+	mid, err := Capture(tmpDir, WithMaxDepth(3))
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	if mid.Stats.FileCount != 2 {
+		t.Errorf("expected 2 files at depth 3, got %d", mid.Stats.FileCount)
+	}
+	entry, _, err := mid.GetFileAtPath("a/b/c/deep.go")
+	if err == nil {
+		t.Errorf("expected a/b/c/deep.go to be absent, got %+v", entry)
+	}
+
+	// A limit no directory reaches leaves the hash unchanged.
+	deep, err := Capture(tmpDir, WithMaxDepth(10))
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	if deep.RootHash != full.RootHash {
+		t.Error("expected an unreached depth limit to match the full capture")
+	}
+}
+
+func TestTracker_SnapshotCacheMatchesColdCapture(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	_ = os.MkdirAll(filepath.Join(tmpDir, "stable", "deep"), 0755)
+	_ = os.MkdirAll(filepath.Join(tmpDir, "hot"), 0755)
+	_ = os.WriteFile(filepath.Join(tmpDir, "stable", "a.txt"), []byte("a"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "stable", "deep", "b.txt"), []byte("b"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "hot", "c.txt"), []byte("c"), 0644)
+
+	// Age everything so it is outside the racy window and eligible for caching.
+	old := time.Now().Add(-time.Hour)
+	_ = filepath.Walk(tmpDir, func(path string, info os.FileInfo, err error) error {
+		return os.Chtimes(path, old, old)
+	})
+
+	tracker := NewTracker(tmpDir, WithSnapshotCache())
+
+	check := func(step string) {
+		t.Helper()
+		snap, _, err := tracker.Snapshot()
+		if err != nil {
+			t.Fatalf("%s: tracker snapshot failed: %v", step, err)
+		}
+		cold, err := Capture(tmpDir)
+		if err != nil {
+			t.Fatalf("%s: cold capture failed: %v", step, err)
+		}
+		if snap.RootHash != cold.RootHash {
+			t.Fatalf("%s: root hash %x does not match cold capture %x", step, snap.RootHash[:8], cold.RootHash[:8])
+		}
+		if snap.Stats.FileCount != cold.Stats.FileCount || snap.Stats.DirCount != cold.Stats.DirCount {
+			t.Fatalf("%s: stats %+v do not match cold capture %+v", step, snap.Stats, cold.Stats)
+		}
+	}
+
+	check("initial")
+
+	if _, ok := tracker.cache.files[filepath.Join("stable", "deep", "b.txt")]; !ok {
+		t.Fatal("expected aged file to be cached")
+	}
+	if _, ok := tracker.cache.dirs["stable"]; !ok {
+		t.Fatal("expected aged directory to be cached")
+	}
+
+	check("unchanged")
+
+	_ = os.WriteFile(filepath.Join(tmpDir, "hot", "c.txt"), []byte("changed"), 0644)
+	check("modified file")
+
+	_ = os.WriteFile(filepath.Join(tmpDir, "hot", "d.txt"), []byte("new"), 0644)
+	check("added file")
+
+	_ = os.Remove(filepath.Join(tmpDir, "stable", "deep", "b.txt"))
+	check("removed file")
+
+	if _, ok := tracker.cache.files[filepath.Join("hot", "d.txt")]; ok {
+		t.Fatal("expected freshly written file not to be cached")
+	}
+}
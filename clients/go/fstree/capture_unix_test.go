@@ -0,0 +1,119 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unix
+
+package fstree
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestCapture_NamedPipe(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	_ = os.WriteFile(filepath.Join(tmpDir, "file.txt"), []byte("content"), 0644)
+	if err := syscall.Mkfifo(filepath.Join(tmpDir, "pipe"), 0600); err != nil {
+		t.Skipf("mkfifo not supported: %v", err)
+	}
+
+	done := make(chan struct{})
+	var snap *Snapshot
+	var err error
+	go func() {
+		defer close(done)
+		snap, err = Capture(tmpDir)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Capture blocked on a named pipe")
+	}
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+
+	if snap.Stats.FileCount != 1 {
+		t.Errorf("expected 1 file, got %d", snap.Stats.FileCount)
+	}
+	if snap.Stats.SpecialCount != 1 {
+		t.Errorf("expected 1 special file, got %d", snap.Stats.SpecialCount)
+	}
+
+	entries, err := snap.GetRootEntries()
+	if err != nil {
+		t.Fatalf("GetRootEntries failed: %v", err)
+	}
+	for _, e := range entries {
+		if e.Name != "pipe" {
+			continue
+		}
+		if e.Kind != EntryKindSpecial {
+			t.Errorf("expected special kind, got %d", e.Kind)
+		}
+		if e.Mode != 0010600 {
+			t.Errorf("expected mode 0010600, got %o", e.Mode)
+		}
+		return
+	}
+	t.Fatal("pipe entry not found")
+}
+
+func TestCapture_ErrorPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	_ = os.MkdirAll(filepath.Join(tmpDir, "sub"), 0755)
+	_ = os.WriteFile(filepath.Join(tmpDir, "ok.txt"), []byte("ok"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "sub", "secret.txt"), []byte("secret"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "sub", "public.txt"), []byte("public"), 0644)
+	_ = os.Symlink("missing", filepath.Join(tmpDir, "dangling"))
+
+	// Followed, the dangling link cannot be stat'ed; the unreadable file
+	// cannot be opened unless running as root.
+	if err := os.Chmod(filepath.Join(tmpDir, "sub", "secret.txt"), 0); err != nil {
+		t.Fatal(err)
+	}
+	wantPaths := []string{"dangling", "sub/secret.txt"}
+	if os.Geteuid() == 0 {
+		wantPaths = wantPaths[:1]
+	}
+
+	snap, err := Capture(tmpDir, WithFollowSymlinks())
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	var gotPaths []string
+	for _, ce := range snap.CaptureErrors {
+		gotPaths = append(gotPaths, ce.Path)
+		if ce.Err == nil {
+			t.Errorf("CaptureError for %s has no error", ce.Path)
+		}
+	}
+	if !reflect.DeepEqual(gotPaths, wantPaths) {
+		t.Fatalf("CaptureErrors paths = %v, want %v", gotPaths, wantPaths)
+	}
+
+	// Skipped entries are absent from the tree and the stats.
+	if want := 4 - len(wantPaths); snap.Stats.FileCount != want {
+		t.Errorf("FileCount = %d, want %d", snap.Stats.FileCount, want)
+	}
+	if _, _, err := snap.GetFileAtPath("dangling"); err == nil {
+		t.Error("skipped entry is in the snapshot")
+	}
+	if _, _, err := snap.GetFileAtPath("sub/public.txt"); err != nil {
+		t.Errorf("readable sibling missing: %v", err)
+	}
+
+	_, err = Capture(tmpDir, WithFollowSymlinks(), WithErrorPolicy(ErrorPolicyFail))
+	var captureErr *CaptureError
+	if !errors.As(err, &captureErr) || captureErr.Path != "dangling" || !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Capture with ErrorPolicyFail = %v, want a CaptureError for dangling", err)
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package fstree
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// checkpointVersion is bumped whenever the checkpoint file layout changes.
+// Checkpoints with another version are ignored.
+const checkpointVersion = 1
+
+// checkpointEvery is how many newly hashed files CaptureResumable allows
+// between checkpoint writes.
+const checkpointEvery = 1000
+
+// CaptureResumable is Capture for very large trees that may not finish in one
+// attempt. While it runs it periodically writes the directory listings and
+// file hashes gathered so far to checkpointPath, and writes them once more if
+// the capture fails. A later call with the same root and checkpointPath picks
+// up that progress: files whose size and mtime are unchanged are not read
+// again, with the same rules and racy-timestamp guard as WithSnapshotCache.
+// The resulting RootHash matches a clean Capture.
+//
+// The checkpoint is removed once a capture succeeds. A checkpoint that is
+// unreadable, from another version, or recorded for a different root is
+// ignored and the capture starts from scratch.
+func CaptureResumable(root, checkpointPath string, opts ...Option) (*Snapshot, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	o.snapshotCache = true
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("resolve root: %w", err)
+	}
+
+	ckpt := &checkpointer{path: checkpointPath, root: absRoot}
+	ckpt.prev = loadCheckpoint(checkpointPath, absRoot)
+
+	snap, cache, err := capture(absRoot, o, ckpt.prev, ckpt)
+	if err != nil {
+		if cache != nil {
+			if saveErr := ckpt.save(cache); saveErr != nil {
+				return nil, errors.Join(err, saveErr)
+			}
+		}
+		return nil, err
+	}
+
+	if err := os.Remove(checkpointPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("remove checkpoint: %w", err)
+	}
+	return snap, nil
+}
+
+// checkpointer persists capture progress for CaptureResumable.
+type checkpointer struct {
+	path    string
+	root    string
+	prev    *captureCache // progress loaded from an earlier attempt
+	unsaved int           // files hashed since the last write
+}
+
+// fileHashed notes that a file hash was added to next and writes a checkpoint
+// every checkpointEvery files. A failed periodic write does not stop the
+// capture; the write on failure will try again. It is a no-op on a nil
+// checkpointer.
+func (c *checkpointer) fileHashed(next *captureCache) {
+	if c == nil {
+		return
+	}
+	c.unsaved++
+	if c.unsaved < checkpointEvery {
+		return
+	}
+	_ = c.save(next)
+}
+
+// save writes the progress in next, together with anything from the earlier
+// attempt that this one has not reached yet, replacing the checkpoint file
+// atomically.
+func (c *checkpointer) save(next *captureCache) error {
+	file := checkpointFile{
+		Version: checkpointVersion,
+		Root:    c.root,
+		Dirs:    make(map[string]checkpointDir),
+		Files:   make(map[string]checkpointEntry),
+	}
+	for _, cache := range []*captureCache{c.prev, next} {
+		if cache == nil {
+			continue
+		}
+		for rel, d := range cache.dirs {
+			entry := checkpointDir{ModTime: d.modTime.UnixNano()}
+			for _, child := range d.children {
+				entry.Names = append(entry.Names, child.name)
+				entry.IsDir = append(entry.IsDir, child.isDir)
+			}
+			file.Dirs[rel] = entry
+		}
+		for rel, f := range cache.files {
+			file.Files[rel] = checkpointEntry{ModTime: f.modTime.UnixNano(), Size: f.size, Hash: f.hash}
+		}
+	}
+
+	data, err := msgpack.Marshal(&file)
+	if err != nil {
+		return fmt.Errorf("encode checkpoint: %w", err)
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	c.unsaved = 0
+	return nil
+}
+
+// loadCheckpoint reads the progress recorded for root at path. It returns nil
+// if there is no usable checkpoint.
+func loadCheckpoint(path, root string) *captureCache {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var file checkpointFile
+	if err := msgpack.Unmarshal(data, &file); err != nil {
+		return nil
+	}
+	if file.Version != checkpointVersion || file.Root != root {
+		return nil
+	}
+
+	cache := newCaptureCache()
+	for rel, d := range file.Dirs {
+		if len(d.Names) != len(d.IsDir) {
+			return nil
+		}
+		children := make([]dirChild, len(d.Names))
+		for i, name := range d.Names {
+			children[i] = dirChild{name: name, isDir: d.IsDir[i]}
+		}
+		cache.dirs[rel] = dirCacheEntry{modTime: time.Unix(0, d.ModTime), children: children}
+	}
+	for rel, f := range file.Files {
+		cache.files[rel] = fileCacheEntry{modTime: time.Unix(0, f.ModTime), size: f.Size, hash: f.Hash}
+	}
+	return cache
+}
+
+// checkpointFile is the on-disk form of a captureCache.
+type checkpointFile struct {
+	Version int                        `msgpack:"version"`
+	Root    string                     `msgpack:"root"`
+	Dirs    map[string]checkpointDir   `msgpack:"dirs"`
+	Files   map[string]checkpointEntry `msgpack:"files"`
+}
+
+type checkpointDir struct {
+	ModTime int64    `msgpack:"mtime"`
+	Names   []string `msgpack:"names"`
+	IsDir   []bool   `msgpack:"is_dir"`
+}
+
+type checkpointEntry struct {
+	ModTime int64    `msgpack:"mtime"`
+	Size    int64    `msgpack:"size"`
+	Hash    [32]byte `msgpack:"hash"`
+}
@@ -0,0 +1,84 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package fstree
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCaptureResumable(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-time.Hour)
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt", "e.txt", "f.txt"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("content of "+name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chtimes(dir, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	clean, err := Capture(dir)
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+
+	// Interrupt the first attempt after three files have been hashed.
+	ckptPath := filepath.Join(t.TempDir(), "capture.ckpt")
+	if _, err := CaptureResumable(dir, ckptPath, WithMaxFiles(3)); !errors.Is(err, ErrTooManyFiles) {
+		t.Fatalf("expected ErrTooManyFiles, got %v", err)
+	}
+	absDir, _ := filepath.Abs(dir)
+	progress := loadCheckpoint(ckptPath, absDir)
+	if progress == nil {
+		t.Fatal("expected a checkpoint after the interrupted capture")
+	}
+	if len(progress.files) != 3 {
+		t.Fatalf("expected 3 checkpointed files, got %d", len(progress.files))
+	}
+	if loadCheckpoint(ckptPath, filepath.Join(absDir, "other")) != nil {
+		t.Fatal("checkpoint should not apply to a different root")
+	}
+
+	// Rewrite a checkpointed file without changing its size or mtime. The
+	// resumed capture must reuse the recorded hash rather than read it again.
+	path := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(path, []byte("CONTENT OF a.txt"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	resumed, err := CaptureResumable(dir, ckptPath)
+	if err != nil {
+		t.Fatalf("CaptureResumable failed: %v", err)
+	}
+	if resumed.RootHash != clean.RootHash {
+		t.Fatal("resumed capture should match the clean capture")
+	}
+	if resumed.Stats.FileCount != 6 {
+		t.Fatalf("expected 6 files, got %d", resumed.Stats.FileCount)
+	}
+	if _, err := os.Stat(ckptPath); !os.IsNotExist(err) {
+		t.Fatalf("expected checkpoint to be removed, stat err = %v", err)
+	}
+
+	// Without a checkpoint the rewritten file is read again.
+	fresh, err := CaptureResumable(dir, ckptPath)
+	if err != nil {
+		t.Fatalf("CaptureResumable failed: %v", err)
+	}
+	if fresh.RootHash == clean.RootHash {
+		t.Fatal("capture without a checkpoint should see the rewritten file")
+	}
+}
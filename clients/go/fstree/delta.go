@@ -0,0 +1,277 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package fstree
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"path"
+	"time"
+
+	"github.com/zeebo/blake3"
+)
+
+// Patch holds the blobs that a receiver which already has a base snapshot
+// lacks to rebuild a target snapshot: the tree objects, file content, and
+// symlink targets the target references and the base does not. It is built
+// by TransferDelta and applied with Apply.
+type Patch struct {
+	// BaseRoot is the RootHash of the base the patch was computed against,
+	// or zero if there was none.
+	BaseRoot [32]byte
+
+	// TargetRoot is the RootHash of the snapshot the patch rebuilds.
+	TargetRoot [32]byte
+
+	// Trees maps tree hashes to serialized tree objects.
+	Trees map[[32]byte][]byte
+
+	// Files maps file content hashes to file content.
+	Files map[[32]byte][]byte
+
+	// Symlinks maps symlink target hashes to target paths.
+	Symlinks map[[32]byte]string
+
+	// PathPrefix, Metadata, and CapturedAt are copied from the target.
+	PathPrefix string
+	Metadata   map[string]string
+	CapturedAt time.Time
+}
+
+// Size returns the number of blob bytes in the patch.
+func (p *Patch) Size() int64 {
+	var n int64
+	for _, data := range p.Trees {
+		n += int64(len(data))
+	}
+	for _, data := range p.Files {
+		n += int64(len(data))
+	}
+	for _, target := range p.Symlinks {
+		n += int64(len(target))
+	}
+	return n
+}
+
+// TransferDelta computes the patch that turns base into target. A blob is
+// left out if base references it or, when store is not nil, if store
+// already has it, so store can stand for the content a remote receiver
+// holds. Subtrees whose hash base already has are skipped without being
+// visited, as in DiffStream, so the cost follows the size of the change.
+// base may be nil, in which case the patch carries everything not in store.
+// File content is read from target as by GetFile.
+func TransferDelta(base, target *Snapshot, store ContentStore) (*Patch, error) {
+	p := &Patch{
+		TargetRoot: target.RootHash,
+		Trees:      make(map[[32]byte][]byte),
+		Files:      make(map[[32]byte][]byte),
+		Symlinks:   make(map[[32]byte]string),
+		PathPrefix: target.PathPrefix,
+		Metadata:   maps.Clone(target.Metadata),
+		CapturedAt: target.CapturedAt,
+	}
+	if base != nil {
+		p.BaseRoot = base.RootHash
+	} else {
+		base = &Snapshot{}
+	}
+
+	if err := p.addTree(base, target, store, target.RootHash); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Patch) addTree(base, target *Snapshot, store ContentStore, hash [32]byte) error {
+	if _, ok := base.Trees[hash]; ok {
+		return nil
+	}
+	if _, ok := p.Trees[hash]; ok {
+		return nil
+	}
+	data, ok := target.Trees[hash]
+	if !ok {
+		return fmt.Errorf("tree not found: %x", hash[:8])
+	}
+	p.Trees[hash] = data
+
+	entries, err := DeserializeTree(data)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		switch entry.Kind {
+		case EntryKindDirectory:
+			if err := p.addTree(base, target, store, entry.Hash); err != nil {
+				return err
+			}
+		case EntryKindFile:
+			if err := p.addFile(base, target, store, entry.Hash); err != nil {
+				return err
+			}
+		case EntryKindSymlink:
+			if _, ok := base.Symlinks[entry.Hash]; ok {
+				continue
+			}
+			linkTarget, ok := target.Symlinks[entry.Hash]
+			if !ok {
+				return fmt.Errorf("symlink not found: %x", entry.Hash[:8])
+			}
+			p.Symlinks[entry.Hash] = linkTarget
+		}
+	}
+	return nil
+}
+
+func (p *Patch) addFile(base, target *Snapshot, store ContentStore, hash [32]byte) error {
+	if _, ok := base.Files[hash]; ok {
+		return nil
+	}
+	if _, ok := p.Files[hash]; ok {
+		return nil
+	}
+	if store != nil {
+		has, err := store.Has(hash)
+		if err != nil {
+			return fmt.Errorf("check store for %x: %w", hash[:8], err)
+		}
+		if has {
+			return nil
+		}
+	}
+
+	data, err := target.readContent(hash)
+	if err != nil {
+		return fmt.Errorf("read file %x: %w", hash[:8], err)
+	}
+	if blake3.Sum256(data) != hash {
+		return fmt.Errorf("file %x changed since capture", hash[:8])
+	}
+	p.Files[hash] = data
+	return nil
+}
+
+// Apply rebuilds the target snapshot from base and the patch, writing file
+// content into store, which becomes the Store of the returned snapshot. base
+// must be the snapshot the patch was computed against (nil if there was
+// none) and store must hold, or be able to receive, every file the target
+// shares with it: content the store lacks is copied from base with GetFile.
+// Blobs are verified against their hashes, so the result has TargetRoot as
+// its RootHash. Stats are recomputed, apart from Duration, which is zero.
+func (p *Patch) Apply(base *Snapshot, store ContentStore) (*Snapshot, error) {
+	var baseRoot [32]byte
+	if base != nil {
+		baseRoot = base.RootHash
+	} else {
+		base = &Snapshot{}
+	}
+	if baseRoot != p.BaseRoot {
+		return nil, fmt.Errorf("patch is for base %x, not %x", p.BaseRoot[:8], baseRoot[:8])
+	}
+	if store == nil {
+		return nil, fmt.Errorf("apply patch: content store is required")
+	}
+
+	for hash, data := range p.Files {
+		if err := store.Put(hash, bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("store file %x: %w", hash[:8], err)
+		}
+	}
+
+	out := &Snapshot{
+		RootHash:   p.TargetRoot,
+		Trees:      make(map[[32]byte][]byte),
+		Files:      make(map[[32]byte]*FileRef),
+		Symlinks:   make(map[[32]byte]string),
+		PathPrefix: p.PathPrefix,
+		Store:      store,
+		Metadata:   maps.Clone(p.Metadata),
+		CapturedAt: p.CapturedAt,
+	}
+	if err := p.applyTree(base, store, out, p.TargetRoot, p.PathPrefix); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (p *Patch) applyTree(base *Snapshot, store ContentStore, out *Snapshot, hash [32]byte, prefix string) error {
+	data, ok := p.Trees[hash]
+	if !ok {
+		data, ok = base.Trees[hash]
+	}
+	if !ok {
+		return fmt.Errorf("tree not found: %x", hash[:8])
+	}
+	if blake3.Sum256(data) != hash {
+		return fmt.Errorf("tree %x does not match its hash", hash[:8])
+	}
+	out.Trees[hash] = data
+	out.Stats.DirCount++
+
+	entries, err := DeserializeTree(data)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		entryPath := entry.Name
+		if prefix != "" {
+			entryPath = path.Join(prefix, entry.Name)
+		}
+
+		switch entry.Kind {
+		case EntryKindDirectory:
+			if err := p.applyTree(base, store, out, entry.Hash, entryPath); err != nil {
+				return err
+			}
+		case EntryKindFile:
+			_, seen := out.Files[entry.Hash]
+			if !seen {
+				if err := copyToStore(base, store, entry.Hash); err != nil {
+					return err
+				}
+				out.Files[entry.Hash] = &FileRef{Size: entry.Size, Hash: entry.Hash}
+			}
+			out.Stats.addFile(entryPath, entry.Size, !seen)
+		case EntryKindSymlink:
+			linkTarget, ok := p.Symlinks[entry.Hash]
+			if !ok {
+				linkTarget, ok = base.Symlinks[entry.Hash]
+			}
+			if !ok {
+				return fmt.Errorf("symlink not found: %x", entry.Hash[:8])
+			}
+			out.Symlinks[entry.Hash] = linkTarget
+			out.Stats.SymlinkCount++
+		case EntryKindSpecial:
+			out.Stats.SpecialCount++
+		}
+	}
+	return nil
+}
+
+// copyToStore makes sure store holds the file content hash, copying it from
+// base if needed.
+func copyToStore(base *Snapshot, store ContentStore, hash [32]byte) error {
+	has, err := store.Has(hash)
+	if err != nil {
+		return fmt.Errorf("check store for %x: %w", hash[:8], err)
+	}
+	if has {
+		return nil
+	}
+	if _, ok := base.Files[hash]; !ok {
+		return fmt.Errorf("%w: file %x is in neither the patch nor the base", ErrBlobNotFound, hash[:8])
+	}
+
+	r, err := base.GetFile(hash)
+	if err != nil {
+		return fmt.Errorf("read base file %x: %w", hash[:8], err)
+	}
+	defer func() { _ = r.Close() }()
+	if err := store.Put(hash, r); err != nil {
+		return fmt.Errorf("store file %x: %w", hash[:8], err)
+	}
+	return nil
+}
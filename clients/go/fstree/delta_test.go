@@ -0,0 +1,102 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package fstree
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zeebo/blake3"
+)
+
+func TestTransferDelta(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	for i := 0; i < 20; i++ {
+		p := filepath.Join(tmpDir, "lib", fmt.Sprintf("file%02d.txt", i))
+		_ = os.MkdirAll(filepath.Dir(p), 0755)
+		_ = os.WriteFile(p, []byte(fmt.Sprintf("shared content %d", i)), 0644)
+	}
+	_ = os.MkdirAll(filepath.Join(tmpDir, "src"), 0755)
+	_ = os.WriteFile(filepath.Join(tmpDir, "src", "main.go"), []byte("package main"), 0644)
+
+	base, err := Capture(tmpDir)
+	if err != nil {
+		t.Fatalf("Capture base failed: %v", err)
+	}
+
+	_ = os.WriteFile(filepath.Join(tmpDir, "src", "main.go"), []byte("package main // changed"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "src", "util.go"), []byte("package main // new"), 0644)
+
+	target, err := Capture(tmpDir)
+	if err != nil {
+		t.Fatalf("Capture target failed: %v", err)
+	}
+
+	patch, err := TransferDelta(base, target, nil)
+	if err != nil {
+		t.Fatalf("TransferDelta failed: %v", err)
+	}
+
+	// Only the changed and new files, and the trees on their path, travel.
+	if len(patch.Files) != 2 {
+		t.Errorf("patch has %d files, want 2", len(patch.Files))
+	}
+	for _, content := range []string{"package main // changed", "package main // new"} {
+		if got, ok := patch.Files[blake3.Sum256([]byte(content))]; !ok || string(got) != content {
+			t.Errorf("patch is missing %q", content)
+		}
+	}
+	if len(patch.Trees) != 2 {
+		t.Errorf("patch has %d trees, want 2 (root and src)", len(patch.Trees))
+	}
+	full, err := TransferDelta(nil, target, nil)
+	if err != nil {
+		t.Fatalf("TransferDelta without base failed: %v", err)
+	}
+	if len(full.Files) != 22 || patch.Size() >= full.Size() {
+		t.Errorf("full patch has %d files and %d bytes, delta %d bytes", len(full.Files), full.Size(), patch.Size())
+	}
+
+	// A receiver store that already has a blob is not sent it again.
+	remote := NewMemoryStore()
+	_ = remote.Put(blake3.Sum256([]byte("package main // new")), strings.NewReader("package main // new"))
+	if p, err := TransferDelta(base, target, remote); err != nil || len(p.Files) != 1 {
+		t.Errorf("TransferDelta with store: %d files, err %v; want 1 file", len(p.Files), err)
+	}
+
+	store := NewMemoryStore()
+	rebuilt, err := patch.Apply(base, store)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if eq, err := rebuilt.EqualDeep(target); err != nil || !eq {
+		t.Fatalf("rebuilt snapshot differs from target: %v", err)
+	}
+	wantStats := target.Stats
+	wantStats.Duration = 0
+	if rebuilt.Stats != wantStats {
+		t.Errorf("stats = %+v, want %+v", rebuilt.Stats, wantStats)
+	}
+	for _, name := range []string{"src/main.go", "lib/file07.txt"} {
+		_, r, err := rebuilt.GetFileAtPath(name)
+		if err != nil {
+			t.Fatalf("GetFileAtPath(%s) failed: %v", name, err)
+		}
+		got, _ := io.ReadAll(r)
+		_ = r.Close()
+		want, _ := os.ReadFile(filepath.Join(tmpDir, filepath.FromSlash(name)))
+		if string(got) != string(want) {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	if _, err := patch.Apply(nil, NewMemoryStore()); err == nil {
+		t.Error("expected Apply against the wrong base to fail")
+	}
+}
@@ -0,0 +1,427 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package fstree
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/zeebo/blake3"
+)
+
+// DiffTextOption configures DiffText output.
+type DiffTextOption func(*diffTextOptions)
+
+type diffTextOptions struct {
+	contextLines int
+	maxTextSize  int64
+}
+
+func defaultDiffTextOptions() *diffTextOptions {
+	return &diffTextOptions{
+		contextLines: 3,
+		maxTextSize:  1024 * 1024, // 1MB
+	}
+}
+
+// WithContextLines sets the number of unchanged lines shown around each
+// change in a content diff. Default is 3; negative values are treated as 0.
+func WithContextLines(n int) DiffTextOption {
+	return func(o *diffTextOptions) {
+		if n < 0 {
+			n = 0
+		}
+		o.contextLines = n
+	}
+}
+
+// WithMaxTextSize sets the largest blob, in bytes, for which DiffText writes a
+// line diff. Larger blobs are reported as "Binary files ... differ". Default
+// is 1MB.
+func WithMaxTextSize(bytes int64) DiffTextOption {
+	return func(o *diffTextOptions) {
+		o.maxTextSize = bytes
+	}
+}
+
+// DiffText writes a git-style description of the changes from base to s.
+//
+// The output starts with one summary line per changed path, in DiffStream
+// order: "A path" for added, "M path" for modified, and "D path" for removed
+// paths. A patch for each change follows, with a "diff --git" header and a
+// unified line diff of the content. Symlinks are diffed by their target.
+// Content that contains a NUL byte or is larger than the WithMaxTextSize
+// limit is reported as "Binary files a/path and b/path differ".
+//
+// File content is read from the Store of the snapshot that recorded it, or
+// from FileRef.Path if it has none. If a file has changed on disk since it
+// was captured, or is missing from the store, the patch notes that its
+// content is unavailable instead of showing a line diff. base may be nil, in
+// which case every path in s is reported as added.
+func (s *Snapshot) DiffText(base *Snapshot, w io.Writer, opts ...DiffTextOption) error {
+	o := defaultDiffTextOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var changes []DiffChange
+	err := s.DiffStream(base, func(change DiffChange) error {
+		changes = append(changes, change)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, change := range changes {
+		fmt.Fprintf(bw, "%c %s\n", changeLetter(change.Kind), change.Path)
+	}
+	for _, change := range changes {
+		bw.WriteByte('\n')
+		if err := s.writePatch(bw, base, change, o); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func changeLetter(kind ChangeKind) byte {
+	switch kind {
+	case ChangeAdded:
+		return 'A'
+	case ChangeRemoved:
+		return 'D'
+	default:
+		return 'M'
+	}
+}
+
+// writePatch writes the header and content diff for a single change.
+func (s *Snapshot) writePatch(w *bufio.Writer, base *Snapshot, change DiffChange, o *diffTextOptions) error {
+	oldName, newName := "a/"+change.Path, "b/"+change.Path
+	fmt.Fprintf(w, "diff --git %s %s\n", oldName, newName)
+	switch change.Kind {
+	case ChangeAdded:
+		fmt.Fprintf(w, "new file mode %06o\n", gitMode(change.New))
+		oldName = "/dev/null"
+	case ChangeRemoved:
+		fmt.Fprintf(w, "deleted file mode %06o\n", gitMode(change.Old))
+		newName = "/dev/null"
+	default:
+		if oldMode, newMode := gitMode(change.Old), gitMode(change.New); oldMode != newMode {
+			fmt.Fprintf(w, "old mode %06o\nnew mode %06o\n", oldMode, newMode)
+		}
+	}
+
+	var oldData, newData []byte
+	binary := false
+	if change.Kind != ChangeAdded {
+		data, err := base.diffContent(change.Old, o.maxTextSize)
+		switch {
+		case errors.Is(err, errContentTooLarge):
+			binary = true
+		case errors.Is(err, errContentChanged):
+			fmt.Fprintf(w, "Content of %s is unavailable: %v\n", oldName, err)
+			return nil
+		case err != nil:
+			return fmt.Errorf("read %s: %w", oldName, err)
+		}
+		oldData = data
+	}
+	if change.Kind != ChangeRemoved {
+		data, err := s.diffContent(change.New, o.maxTextSize)
+		switch {
+		case errors.Is(err, errContentTooLarge):
+			binary = true
+		case errors.Is(err, errContentChanged):
+			fmt.Fprintf(w, "Content of %s is unavailable: %v\n", newName, err)
+			return nil
+		case err != nil:
+			return fmt.Errorf("read %s: %w", newName, err)
+		}
+		newData = data
+	}
+
+	if binary || isBinary(oldData) || isBinary(newData) {
+		fmt.Fprintf(w, "Binary files %s and %s differ\n", oldName, newName)
+		return nil
+	}
+
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", oldName, newName)
+	writeUnified(w, splitLines(oldData), splitLines(newData), o.contextLines)
+	return nil
+}
+
+// gitMode returns the git file mode for a file or symlink entry.
+func gitMode(entry TreeEntry) uint32 {
+	if entry.Kind == EntryKindSymlink {
+		return 0o120000
+	}
+	if entry.Mode&0o111 != 0 {
+		return 0o100755
+	}
+	return 0o100644
+}
+
+var (
+	errContentTooLarge = errors.New("content too large")
+	errContentChanged  = errors.New("changed since capture")
+)
+
+// diffContent returns the content of a file or symlink entry. It returns
+// errContentTooLarge if the content exceeds maxSize, and errContentChanged if
+// the file on disk has been removed, is missing from the store, or no longer
+// matches the entry's hash.
+func (s *Snapshot) diffContent(entry TreeEntry, maxSize int64) ([]byte, error) {
+	if entry.Kind == EntryKindSymlink {
+		target, ok := s.Symlinks[entry.Hash]
+		if !ok {
+			return nil, fmt.Errorf("symlink not found: %x", entry.Hash[:8])
+		}
+		return []byte(target), nil
+	}
+	if int64(entry.Size) > maxSize {
+		return nil, errContentTooLarge
+	}
+
+	r, err := s.GetFile(entry.Hash)
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, ErrContentNotFound) {
+		return nil, errContentChanged
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if blake3.Sum256(data) != entry.Hash {
+		return nil, errContentChanged
+	}
+	return data, nil
+}
+
+// isBinary reports whether data looks like binary content, using the same
+// NUL-byte heuristic as git.
+func isBinary(data []byte) bool {
+	const sniffLen = 8000
+	if len(data) > sniffLen {
+		data = data[:sniffLen]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// splitLines splits data into lines, each keeping its trailing newline. The
+// final line has none if data does not end in a newline.
+func splitLines(data []byte) []string {
+	var lines []string
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			lines = append(lines, string(data))
+			break
+		}
+		lines = append(lines, string(data[:i+1]))
+		data = data[i+1:]
+	}
+	return lines
+}
+
+// lineOp is one step of a line edit script: an unchanged, deleted, or
+// inserted line. a and b are the positions in the old and new line lists
+// before the step is applied.
+type lineOp struct {
+	kind byte // ' ', '-', or '+'
+	a, b int
+}
+
+// maxDiffTrace bounds the memory used by diffLines. Inputs whose edit script
+// needs more are diffed as a single replacement of the differing region.
+const maxDiffTrace = 1 << 24
+
+// diffLines computes an edit script from a to b. Lines common to the start and
+// end of both are matched directly; the rest uses Myers' algorithm.
+func diffLines(a, b []string) []lineOp {
+	var ops []lineOp
+	p := 0
+	for p < len(a) && p < len(b) && a[p] == b[p] {
+		ops = append(ops, lineOp{kind: ' ', a: p, b: p})
+		p++
+	}
+	q := 0
+	for q < len(a)-p && q < len(b)-p && a[len(a)-1-q] == b[len(b)-1-q] {
+		q++
+	}
+
+	mid, ok := myers(a[p:len(a)-q], b[p:len(b)-q])
+	if !ok {
+		mid = mid[:0]
+		for i := p; i < len(a)-q; i++ {
+			mid = append(mid, lineOp{kind: '-', a: i - p, b: 0})
+		}
+		for j := p; j < len(b)-q; j++ {
+			mid = append(mid, lineOp{kind: '+', a: len(a) - q - p, b: j - p})
+		}
+	}
+	for _, op := range mid {
+		ops = append(ops, lineOp{kind: op.kind, a: op.a + p, b: op.b + p})
+	}
+
+	for i := 0; i < q; i++ {
+		ops = append(ops, lineOp{kind: ' ', a: len(a) - q + i, b: len(b) - q + i})
+	}
+	return ops
+}
+
+// myers returns a shortest edit script from a to b, or false if finding one
+// would exceed maxDiffTrace.
+func myers(a, b []string) ([]lineOp, bool) {
+	n, m := len(a), len(b)
+	maxD := n + m
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+
+	// trace[d] holds v[-d-1..d+1] as it was before step d.
+	var trace [][]int
+	traceSize := 0
+
+search:
+	for d := 0; d <= maxD; d++ {
+		traceSize += 2*d + 3
+		if traceSize > maxDiffTrace {
+			return nil, false
+		}
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Walk the trace backwards from (n, m) to recover the edit script.
+	var ops []lineOp
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		tv := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && tv[k-1+d+1] < tv[k+1+d+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := tv[prevK+d+1]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, lineOp{kind: ' ', a: x, b: y})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			ops = append(ops, lineOp{kind: '+', a: x, b: y - 1})
+		} else {
+			ops = append(ops, lineOp{kind: '-', a: x - 1, b: y})
+		}
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops, true
+}
+
+// writeUnified writes the hunks of a unified diff from a to b with the given
+// number of context lines.
+func writeUnified(w *bufio.Writer, a, b []string, context int) {
+	ops := diffLines(a, b)
+	i := 0
+	for i < len(ops) {
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			return
+		}
+
+		// Extend the hunk while the next change is close enough that the
+		// context around the two would touch.
+		last := i
+		for j := i + 1; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				last = j
+			} else if j-last > 2*context {
+				break
+			}
+		}
+		start := max(i-context, 0)
+		end := min(last+context+1, len(ops))
+		writeHunk(w, a, b, ops[start:end])
+		i = end
+	}
+}
+
+func writeHunk(w *bufio.Writer, a, b []string, ops []lineOp) {
+	var oldCount, newCount int
+	for _, op := range ops {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+	}
+	fmt.Fprintf(w, "@@ -%s +%s @@\n", hunkRange(ops[0].a, oldCount), hunkRange(ops[0].b, newCount))
+
+	for _, op := range ops {
+		var line string
+		if op.kind == '+' {
+			line = b[op.b]
+		} else {
+			line = a[op.a]
+		}
+		w.WriteByte(op.kind)
+		w.WriteString(line)
+		if !strings.HasSuffix(line, "\n") {
+			w.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats a hunk's line range the way diff -u does: a range of one
+// line omits the count, and an empty range starts at the line before it.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
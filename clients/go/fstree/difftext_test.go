@@ -0,0 +1,181 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package fstree
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestSnapshot_DiffText(t *testing.T) {
+	oldDir := writeTree(t, map[string]string{
+		"a.txt":    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+		"bin.dat":  "\x00\x01\x02",
+		"gone.txt": "bye\n",
+	})
+	newDir := writeTree(t, map[string]string{
+		"a.txt":   "1\n2\n3\n4\nfive\n6\n7\n8\n9\n10\n",
+		"bin.dat": "\x00\x01\x03",
+		"new.txt": "hello",
+	})
+
+	base, err := Capture(oldDir)
+	if err != nil {
+		t.Fatalf("Capture old: %v", err)
+	}
+	snap, err := Capture(newDir)
+	if err != nil {
+		t.Fatalf("Capture new: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := snap.DiffText(base, &buf); err != nil {
+		t.Fatalf("DiffText failed: %v", err)
+	}
+
+	want := `M a.txt
+M bin.dat
+D gone.txt
+A new.txt
+
+diff --git a/a.txt b/a.txt
+--- a/a.txt
++++ b/a.txt
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+
+diff --git a/bin.dat b/bin.dat
+Binary files a/bin.dat and b/bin.dat differ
+
+diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+--- a/gone.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+
+diff --git a/new.txt b/new.txt
+new file mode 100644
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1 @@
++hello
+\ No newline at end of file
+`
+	if got := buf.String(); got != want {
+		t.Errorf("DiffText output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Text over the size limit is treated as binary; fewer context lines
+	// shrink the hunk.
+	buf.Reset()
+	if err := snap.DiffText(base, &buf, WithMaxTextSize(8), WithContextLines(1)); err != nil {
+		t.Fatalf("DiffText failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Binary files a/a.txt and b/a.txt differ\n") {
+		t.Errorf("expected a.txt to be reported as binary, got:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "@@ -0,0 +1 @@\n+hello\n") {
+		t.Errorf("expected new.txt under the limit to be diffed, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := snap.DiffText(base, &buf, WithContextLines(1)); err != nil {
+		t.Fatalf("DiffText failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "@@ -4,3 +4,3 @@\n 4\n-5\n+five\n 6\n") {
+		t.Errorf("expected a one-line-context hunk, got:\n%s", buf.String())
+	}
+
+	// Content that changed on disk after capture is not diffed.
+	if err := os.WriteFile(filepath.Join(oldDir, "a.txt"), []byte("rewritten\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := snap.DiffText(base, &buf); err != nil {
+		t.Fatalf("DiffText failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Content of a/a.txt is unavailable: changed since capture\n") {
+		t.Errorf("expected stale content note, got:\n%s", buf.String())
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"", "", ""},
+		{"x\n", "x\n", " "},
+		{"a\nb\nc\n", "a\nc\n", " - "},
+		{"a\nc\n", "a\nb\nc\n", " + "},
+		{"a\nb\n", "c\nd\n", "--++"},
+		{"a\nb\nc\nd\n", "b\nx\nd\ny\n", "- -+ +"},
+	}
+	for _, tt := range tests {
+		a, b := splitLines([]byte(tt.a)), splitLines([]byte(tt.b))
+		ops := diffLines(a, b)
+
+		var kinds []byte
+		var rebuilt []string
+		for _, op := range ops {
+			kinds = append(kinds, op.kind)
+			switch op.kind {
+			case ' ':
+				if a[op.a] != b[op.b] {
+					t.Errorf("diffLines(%q, %q): unchanged op pairs %q with %q", tt.a, tt.b, a[op.a], b[op.b])
+				}
+				rebuilt = append(rebuilt, b[op.b])
+			case '+':
+				rebuilt = append(rebuilt, b[op.b])
+			}
+		}
+		if string(kinds) != tt.want {
+			t.Errorf("diffLines(%q, %q) = %q, want %q", tt.a, tt.b, kinds, tt.want)
+		}
+		if strings.Join(rebuilt, "") != tt.b {
+			t.Errorf("diffLines(%q, %q) rebuilds %q", tt.a, tt.b, strings.Join(rebuilt, ""))
+		}
+	}
+
+	// Inputs too large for the trace fall back to a single replacement.
+	var a, b []string
+	for i := 0; i < 5000; i++ {
+		a = append(a, "a\n")
+		b = append(b, "b\n")
+	}
+	ops := diffLines(a, b)
+	if len(ops) != 10000 || ops[0].kind != '-' || ops[9999].kind != '+' {
+		t.Fatalf("unexpected fallback script: %d ops", len(ops))
+	}
+	if !reflect.DeepEqual(ops[5000], lineOp{kind: '+', a: 5000, b: 0}) {
+		t.Errorf("ops[5000] = %+v", ops[5000])
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Package fstreetest provides helpers for tests that capture fstree snapshots.
+// It imports package testing and is meant to be used only from _test files.
+package fstreetest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/strongdm/ai-cxdb/clients/go/fstree"
+)
+
+// AssertStats reports a test error listing every count in snap.Stats that
+// differs from want. Duration is ignored since it varies between runs.
+func AssertStats(t testing.TB, snap *fstree.Snapshot, want fstree.SnapshotStats) {
+	t.Helper()
+
+	if snap == nil {
+		t.Errorf("snapshot stats: got nil snapshot, want %s", want)
+		return
+	}
+	if diff := DiffStats(snap.Stats, want); diff != "" {
+		t.Errorf("snapshot stats mismatch (-got +want):\n%s", diff)
+	}
+}
+
+// DiffStats returns a readable description of the counts that differ between
+// got and want, one field per line, or "" if they match. Duration is ignored.
+func DiffStats(got, want fstree.SnapshotStats) string {
+	var b strings.Builder
+	field := func(name string, got, want any) {
+		if got != want {
+			fmt.Fprintf(&b, "  %s: -%v +%v\n", name, got, want)
+		}
+	}
+	field("FileCount", got.FileCount, want.FileCount)
+	field("DirCount", got.DirCount, want.DirCount)
+	field("SymlinkCount", got.SymlinkCount, want.SymlinkCount)
+	field("SpecialCount", got.SpecialCount, want.SpecialCount)
+	field("TotalBytes", got.TotalBytes, want.TotalBytes)
+	return b.String()
+}
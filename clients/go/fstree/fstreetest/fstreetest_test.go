@@ -0,0 +1,57 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package fstreetest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/strongdm/ai-cxdb/clients/go/fstree"
+)
+
+// recordingTB captures errors instead of failing the enclosing test.
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertStats(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(tmpDir, "src"), 0755)
+	_ = os.WriteFile(filepath.Join(tmpDir, "README.md"), []byte("# Test"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "src", "main.go"), []byte("package main"), 0644)
+
+	snap, err := fstree.Capture(tmpDir)
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+
+	AssertStats(t, snap, fstree.SnapshotStats{FileCount: 2, DirCount: 2, TotalBytes: 18})
+
+	rec := &recordingTB{TB: t}
+	AssertStats(rec, snap, fstree.SnapshotStats{FileCount: 3, DirCount: 2, TotalBytes: 18})
+	if len(rec.errors) == 0 {
+		t.Fatal("expected a mismatch to be reported")
+	}
+	if report := strings.Join(rec.errors, "\n"); !strings.Contains(report, "FileCount: -2 +3") || strings.Contains(report, "DirCount") {
+		t.Errorf("unexpected report:\n%s", report)
+	}
+}
+
+func TestSnapshotStatsString(t *testing.T) {
+	stats := fstree.SnapshotStats{FileCount: 2, DirCount: 1, TotalBytes: 10}
+	want := "files=2 dirs=1 symlinks=0 special=0 bytes=10 duration=0s"
+	if got := stats.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package fstree
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// snapshotJSON is the JSON document produced by Snapshot.MarshalJSON.
+type snapshotJSON struct {
+	RootHash   string            `json:"root_hash"`
+	PathPrefix string            `json:"path_prefix,omitempty"`
+	CapturedAt time.Time         `json:"captured_at"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Stats      statsJSON         `json:"stats"`
+	Entries    []entryJSON       `json:"entries"`
+}
+
+type statsJSON struct {
+	FileCount    int    `json:"file_count"`
+	DirCount     int    `json:"dir_count"`
+	SymlinkCount int    `json:"symlink_count"`
+	SpecialCount int    `json:"special_count"`
+	TotalBytes   uint64 `json:"total_bytes"`
+	DurationMs   int64  `json:"duration_ms"`
+
+	UniqueBytes     uint64 `json:"unique_bytes"`
+	LargestFile     string `json:"largest_file,omitempty"`
+	LargestFileSize uint64 `json:"largest_file_size"`
+}
+
+type entryJSON struct {
+	Path      string            `json:"path"`
+	Kind      string            `json:"kind"`
+	Mode      uint32            `json:"mode"`
+	Size      uint64            `json:"size"`
+	Hash      string            `json:"hash"`
+	Target    string            `json:"target,omitempty"`
+	Xattrs    map[string][]byte `json:"xattrs,omitempty"`
+	Truncated bool              `json:"truncated,omitempty"`
+}
+
+// String returns the lowercase name of the kind, as used in JSON output.
+func (k EntryKind) String() string {
+	switch k {
+	case EntryKindFile:
+		return "file"
+	case EntryKindDirectory:
+		return "directory"
+	case EntryKindSymlink:
+		return "symlink"
+	case EntryKindSpecial:
+		return "special"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(k))
+	}
+}
+
+// MarshalJSON encodes the snapshot as a flat JSON document for scripts and
+// UIs: the hex root hash, capture time, metadata, stats, and one element per
+// entry in Walk order with its path, kind, mode, size, and hex hash. Symlinks
+// also carry their target. File contents are referenced by hash, never inlined.
+// The output is deterministic for a given snapshot. It is meant for
+// consumption by tools and is not read back by this package.
+func (s Snapshot) MarshalJSON() ([]byte, error) {
+	doc := snapshotJSON{
+		RootHash:   hex.EncodeToString(s.RootHash[:]),
+		PathPrefix: s.PathPrefix,
+		CapturedAt: s.CapturedAt,
+		Metadata:   s.Metadata,
+		Stats: statsJSON{
+			FileCount:    s.Stats.FileCount,
+			DirCount:     s.Stats.DirCount,
+			SymlinkCount: s.Stats.SymlinkCount,
+			SpecialCount: s.Stats.SpecialCount,
+			TotalBytes:   s.Stats.TotalBytes,
+			DurationMs:   s.Stats.Duration.Milliseconds(),
+
+			UniqueBytes:     s.Stats.UniqueBytes,
+			LargestFile:     s.Stats.LargestFile,
+			LargestFileSize: s.Stats.LargestFileSize,
+		},
+		Entries: []entryJSON{},
+	}
+
+	err := s.Walk(func(path string, entry TreeEntry) error {
+		e := entryJSON{
+			Path:      path,
+			Kind:      entry.Kind.String(),
+			Mode:      entry.Mode,
+			Size:      entry.Size,
+			Hash:      hex.EncodeToString(entry.Hash[:]),
+			Xattrs:    entry.Xattrs,
+			Truncated: entry.Truncated,
+		}
+		if entry.Kind == EntryKindSymlink {
+			e.Target = s.Symlinks[entry.Hash]
+		}
+		doc.Entries = append(doc.Entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal snapshot: %w", err)
+	}
+
+	return json.Marshal(doc)
+}
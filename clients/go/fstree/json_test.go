@@ -0,0 +1,84 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package fstree
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshot_MarshalJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	_ = os.MkdirAll(filepath.Join(tmpDir, "src"), 0755)
+	_ = os.WriteFile(filepath.Join(tmpDir, "src", "main.go"), []byte("package main"), 0644)
+	if err := os.Symlink("src/main.go", filepath.Join(tmpDir, "link")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	snap, err := Capture(tmpDir)
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	again, err := json.Marshal(snap)
+	if err != nil {
+		t.Fatalf("second Marshal failed: %v", err)
+	}
+	if !bytes.Equal(data, again) {
+		t.Error("expected deterministic JSON output")
+	}
+
+	var doc struct {
+		RootHash string `json:"root_hash"`
+		Stats    struct {
+			FileCount int `json:"file_count"`
+		} `json:"stats"`
+		Entries []struct {
+			Path   string `json:"path"`
+			Kind   string `json:"kind"`
+			Size   uint64 `json:"size"`
+			Hash   string `json:"hash"`
+			Target string `json:"target"`
+		} `json:"entries"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if doc.RootHash != hex.EncodeToString(snap.RootHash[:]) {
+		t.Errorf("unexpected root hash %s", doc.RootHash)
+	}
+	if doc.Stats.FileCount != 1 {
+		t.Errorf("expected file_count 1, got %d", doc.Stats.FileCount)
+	}
+
+	want := []struct{ path, kind string }{
+		{"link", "symlink"},
+		{"src", "directory"},
+		{"src/main.go", "file"},
+	}
+	if len(doc.Entries) != len(want) {
+		t.Fatalf("expected %d entries, got %+v", len(want), doc.Entries)
+	}
+	for i, w := range want {
+		if doc.Entries[i].Path != w.path || doc.Entries[i].Kind != w.kind {
+			t.Errorf("entry %d: expected %s %s, got %+v", i, w.kind, w.path, doc.Entries[i])
+		}
+	}
+	if doc.Entries[0].Target != "src/main.go" {
+		t.Errorf("expected symlink target, got %q", doc.Entries[0].Target)
+	}
+	if doc.Entries[2].Size != 12 || len(doc.Entries[2].Hash) != 64 {
+		t.Errorf("unexpected file entry %+v", doc.Entries[2])
+	}
+}
@@ -3,17 +3,31 @@
 
 package fstree
 
-import "path/filepath"
+import (
+	"maps"
+	"path"
+	"path/filepath"
+	"strings"
+)
 
 // Option configures snapshot behavior.
 type Option func(*options)
 
 type options struct {
 	excludePatterns []string
+	includePatterns []string
+	skipHidden      bool
 	excludeFn       func(path string, isDir bool) bool
 	followSymlinks  bool
 	maxFileSize     int64
 	maxFiles        int
+	maxDepth        int
+	snapshotCache   bool
+	xattrs          bool
+	pathPrefix      string
+	contentStore    ContentStore
+	metadata        map[string]string
+	errorPolicy     ErrorPolicy
 }
 
 func defaultOptions() *options {
@@ -34,6 +48,73 @@ func WithExclude(patterns ...string) Option {
 	}
 }
 
+// WithInclude restricts the capture to files, symlinks, and special files
+// that match at least one of the glob patterns; with no include patterns,
+// everything not excluded is captured. Directories are always traversed to
+// reach matching entries, but a directory left with nothing included is
+// omitted, as are originally empty directories. Exclusions take precedence:
+// an entry or directory matching both an exclude and an include pattern is
+// excluded.
+//
+// A pattern without a slash is matched against the entry's base name, so
+// "*.go" selects Go files at any depth. Otherwise it is matched against the
+// whole relative path, with "**" standing for any number of directories:
+// "src/**/*.go" selects Go files anywhere under src, and "**/*.go" is the
+// same as "*.go".
+func WithInclude(patterns ...string) Option {
+	return func(o *options) {
+		o.includePatterns = append(o.includePatterns, patterns...)
+	}
+}
+
+// WithSkipHidden skips files, symlinks, special files, and directories whose
+// name starts with a dot, at any depth. A hidden directory is pruned with
+// everything below it, even entries that are not hidden themselves. The check
+// is made after WithInclude matching and cannot be overridden by it: a hidden
+// entry is skipped even if an include pattern matches it. The root directory
+// itself is captured whatever its name.
+func WithSkipHidden(skip bool) Option {
+	return func(o *options) {
+		o.skipHidden = skip
+	}
+}
+
+// vcsExcludes are the version control metadata names skipped by WithExcludeVCS.
+var vcsExcludes = []string{".git", ".hg", ".svn", ".bzr", "_darcs", "CVS", ".jj"}
+
+// commonExcludes are the dependency and cache directory names skipped by
+// WithExcludeCommon in addition to vcsExcludes.
+var commonExcludes = []string{
+	"node_modules",
+	"__pycache__",
+	".venv",
+	".tox",
+	".mypy_cache",
+	".pytest_cache",
+	".gradle",
+	".terraform",
+}
+
+// WithExcludeVCS excludes version control metadata: any file or directory
+// named .git, .hg, .svn, .bzr, _darcs, CVS, or .jj, at any depth. Matching a
+// file as well as a directory also covers the .git file of a worktree or
+// submodule. It adds to, rather than replaces, patterns from WithExclude.
+func WithExcludeVCS() Option {
+	return WithExclude(vcsExcludes...)
+}
+
+// WithExcludeCommon excludes everything WithExcludeVCS does plus common
+// dependency and cache directories: node_modules, __pycache__, .venv, .tox,
+// .mypy_cache, .pytest_cache, .gradle, and .terraform, at any depth. Build
+// output directories such as dist or target are not included since their
+// names are too often used for source. It adds to patterns from WithExclude.
+func WithExcludeCommon() Option {
+	return func(o *options) {
+		WithExcludeVCS()(o)
+		WithExclude(commonExcludes...)(o)
+	}
+}
+
 // WithExcludeFunc sets a custom exclusion function.
 // Return true to exclude the path. Called for every file and directory.
 func WithExcludeFunc(fn func(path string, isDir bool) bool) Option {
@@ -68,6 +149,171 @@ func WithMaxFiles(n int) Option {
 	}
 }
 
+// ErrorPolicy selects what Capture does with an entry it cannot read.
+type ErrorPolicy uint8
+
+const (
+	// ErrorPolicyContinue skips the entry and records it in
+	// Snapshot.CaptureErrors. It is the default.
+	ErrorPolicyContinue ErrorPolicy = iota
+
+	// ErrorPolicyFail stops the capture with a *CaptureError for the entry.
+	ErrorPolicyFail
+)
+
+// WithErrorPolicy sets what Capture does when an entry below the root cannot
+// be read: one that cannot be stat'ed, a file that cannot be opened or
+// hashed, or a directory that cannot be listed. With ErrorPolicyContinue, the
+// default, the entry is left out of the snapshot as if it did not exist, so
+// neither Stats nor RootHash account for it, and its path and error are
+// appended to Snapshot.CaptureErrors. An unreadable directory is left out
+// with everything below it. With ErrorPolicyFail the capture returns the
+// first such error as a *CaptureError.
+//
+// Entries left out by design, such as files over WithMaxFileSize or entries
+// excluded by a pattern, are not errors under either policy. A root that
+// cannot be read and the WithMaxFiles and symlink cycle errors always fail
+// the capture.
+func WithErrorPolicy(policy ErrorPolicy) Option {
+	return func(o *options) {
+		o.errorPolicy = policy
+	}
+}
+
+// WithMaxDepth stops the capture from descending more than n levels below
+// the root. Entries directly under the root are at depth 1. A directory at
+// depth n is still recorded, but with Truncated set and the hash of an empty
+// tree instead of its contents. Zero (the default) captures the whole tree.
+//
+// Because Truncated is part of the entry, a truncated directory hashes
+// differently from the same directory captured in full, even when it is
+// empty, and so do all its ancestors up to RootHash. Snapshots taken with
+// different depth limits are therefore not comparable with Diff. When no
+// directory reaches the limit, RootHash matches an unlimited capture.
+func WithMaxDepth(n int) Option {
+	return func(o *options) {
+		o.maxDepth = n
+	}
+}
+
+// WithSnapshotCache makes a Tracker remember per-directory listings and file
+// hashes between snapshots. Directories whose mtime is unchanged are not
+// re-listed and files whose size and mtime are unchanged are not re-read, so
+// only the parts of the tree that changed cost more than a stat. Entries
+// modified within a couple of seconds of a capture are never cached, which
+// guards against coarse filesystem timestamps. The resulting RootHash always
+// matches a cold Capture. It has no effect on a one-off Capture.
+func WithSnapshotCache() Option {
+	return func(o *options) {
+		o.snapshotCache = true
+	}
+}
+
+// WithXattrs records extended attributes on file and directory entries. It is
+// supported on Linux, where this includes security labels such as SELinux
+// contexts and POSIX ACLs exposed as system.posix_acl_* attributes, and on
+// macOS, where it includes flags such as com.apple.quarantine. Attributes
+// that cannot be read are skipped rather than failing the capture. On other
+// platforms the option has no effect. Attributes are only captured: fstree
+// has no Restore, so reapplying them is up to the caller.
+//
+// Entries without attributes serialize exactly as before, so RootHash is
+// unaffected for trees that carry none. Otherwise the attributes are part of
+// the entry and change the hash of the containing directory and its
+// ancestors: snapshots taken with and without this option are not comparable.
+func WithXattrs(enabled bool) Option {
+	return func(o *options) {
+		o.xattrs = enabled
+	}
+}
+
+// WithPathPrefix places every path in the snapshot under prefix, so captures
+// of different directories can be combined without their paths colliding.
+// The prefix may use either separator and is stored with forward slashes;
+// leading and trailing slashes are dropped. Only reported paths change: tree
+// objects and RootHash are the same as without a prefix.
+func WithPathPrefix(prefix string) Option {
+	return func(o *options) {
+		o.pathPrefix = normalizePath(prefix)
+	}
+}
+
+// WithSnapshotMetadata attaches metadata, such as a name or labels, to the
+// snapshot as Snapshot.Metadata. The map is copied, and each snapshot gets its
+// own copy. Metadata is descriptive: it is carried by EncodeSnapshot and
+// MarshalJSON but does not affect RootHash.
+func WithSnapshotMetadata(metadata map[string]string) Option {
+	return func(o *options) {
+		o.metadata = maps.Clone(metadata)
+	}
+}
+
+// WithContentStore copies the content of every captured file into store and
+// sets Snapshot.Store, so GetFile, GetFileAtPath, DiffText, and Upload read
+// content from the store rather than from the captured paths. Content the
+// store already has is not copied again. Without it, file content stays on
+// disk and is read from FileRef.Path on demand.
+func WithContentStore(store ContentStore) Option {
+	return func(o *options) {
+		o.contentStore = store
+	}
+}
+
+// normalizePath converts p to a clean forward-slash path with no leading or
+// trailing slash. Both '/' and '\' are accepted as separators on every
+// platform so paths recorded on one OS resolve on another.
+func normalizePath(p string) string {
+	p = path.Clean("/" + strings.ReplaceAll(p, "\\", "/"))
+	return strings.TrimPrefix(p, "/")
+}
+
+// shouldInclude reports whether a non-directory entry at relPath passes the
+// WithInclude patterns.
+func (o *options) shouldInclude(relPath string) bool {
+	if len(o.includePatterns) == 0 {
+		return true
+	}
+	relPath = filepath.ToSlash(relPath)
+	for _, pattern := range o.includePatterns {
+		if matchGlob(filepath.ToSlash(pattern), relPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches a forward-slash path against a WithInclude pattern.
+func matchGlob(pattern, name string) bool {
+	if !strings.Contains(pattern, "/") {
+		matched, _ := path.Match(pattern, path.Base(name))
+		return matched
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchSegments matches path segments against pattern segments, where a "**"
+// segment matches zero or more path segments.
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], name[0]); !matched {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
 // shouldExclude checks if a path should be excluded based on options.
 func (o *options) shouldExclude(relPath string, isDir bool) bool {
 	// Check custom function first
@@ -0,0 +1,207 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package fstree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/zeebo/blake3"
+
+	cxdb "github.com/strongdm/ai-cxdb/clients/go"
+)
+
+const (
+	// TypeIDSnapshot is the declared type ID of turns whose payload is a
+	// snapshot encoded by EncodeSnapshot.
+	TypeIDSnapshot = "cxdb.fstree.Snapshot"
+
+	// TypeVersionSnapshot is the current version of the snapshot payload.
+	TypeVersionSnapshot uint32 = 1
+)
+
+// ErrNotSnapshot is returned by DecodeTurnFSTree when a turn's payload is not
+// an encoded snapshot.
+var ErrNotSnapshot = errors.New("fstree: turn payload is not a snapshot")
+
+// snapshotPayload is the msgpack form of a Snapshot. File content is not
+// included; files are listed by hash and size only.
+type snapshotPayload struct {
+	RootHash   [32]byte         `msgpack:"1"`
+	PathPrefix string           `msgpack:"2,omitempty"`
+	CapturedAt int64            `msgpack:"3"` // Unix milliseconds
+	Trees      []payloadBlob    `msgpack:"4"`
+	Files      []payloadFile    `msgpack:"5"`
+	Symlinks   []payloadSymlink `msgpack:"6"`
+	Stats      payloadStats     `msgpack:"7"`
+	Metadata   []payloadLabel   `msgpack:"8,omitempty"`
+}
+
+type payloadBlob struct {
+	Hash [32]byte `msgpack:"1"`
+	Data []byte   `msgpack:"2"`
+}
+
+type payloadFile struct {
+	Hash [32]byte `msgpack:"1"`
+	Size uint64   `msgpack:"2"`
+}
+
+type payloadSymlink struct {
+	Hash   [32]byte `msgpack:"1"`
+	Target string   `msgpack:"2"`
+}
+
+// payloadLabel is one Metadata pair. Metadata is encoded as a list sorted by
+// key, rather than a map, to keep the encoding deterministic.
+type payloadLabel struct {
+	Key   string `msgpack:"1"`
+	Value string `msgpack:"2"`
+}
+
+type payloadStats struct {
+	FileCount    int    `msgpack:"1"`
+	DirCount     int    `msgpack:"2"`
+	SymlinkCount int    `msgpack:"3"`
+	SpecialCount int    `msgpack:"4"`
+	TotalBytes   uint64 `msgpack:"5"`
+	DurationMs   int64  `msgpack:"6"`
+
+	UniqueBytes     uint64 `msgpack:"7"`
+	LargestFile     string `msgpack:"8"`
+	LargestFileSize uint64 `msgpack:"9"`
+}
+
+// EncodeSnapshot encodes s as a msgpack turn payload, to be appended with
+// TypeIDSnapshot and TypeVersionSnapshot. The payload holds the tree objects,
+// symlink targets, stats, metadata, and the hash and size of every file, but
+// not file content, which is uploaded separately with Upload. The encoding is
+// deterministic for a given snapshot.
+func EncodeSnapshot(s *Snapshot) ([]byte, error) {
+	p := snapshotPayload{
+		RootHash:   s.RootHash,
+		PathPrefix: s.PathPrefix,
+		CapturedAt: s.CapturedAt.UnixMilli(),
+		Stats: payloadStats{
+			FileCount:    s.Stats.FileCount,
+			DirCount:     s.Stats.DirCount,
+			SymlinkCount: s.Stats.SymlinkCount,
+			SpecialCount: s.Stats.SpecialCount,
+			TotalBytes:   s.Stats.TotalBytes,
+			DurationMs:   s.Stats.Duration.Milliseconds(),
+
+			UniqueBytes:     s.Stats.UniqueBytes,
+			LargestFile:     s.Stats.LargestFile,
+			LargestFileSize: s.Stats.LargestFileSize,
+		},
+	}
+	for hash, data := range s.Trees {
+		p.Trees = append(p.Trees, payloadBlob{Hash: hash, Data: data})
+	}
+	for hash, ref := range s.Files {
+		p.Files = append(p.Files, payloadFile{Hash: hash, Size: ref.Size})
+	}
+	for hash, target := range s.Symlinks {
+		p.Symlinks = append(p.Symlinks, payloadSymlink{Hash: hash, Target: target})
+	}
+	for key, value := range s.Metadata {
+		p.Metadata = append(p.Metadata, payloadLabel{Key: key, Value: value})
+	}
+
+	sort.Slice(p.Trees, func(i, j int) bool { return bytes.Compare(p.Trees[i].Hash[:], p.Trees[j].Hash[:]) < 0 })
+	sort.Slice(p.Files, func(i, j int) bool { return bytes.Compare(p.Files[i].Hash[:], p.Files[j].Hash[:]) < 0 })
+	sort.Slice(p.Symlinks, func(i, j int) bool { return bytes.Compare(p.Symlinks[i].Hash[:], p.Symlinks[j].Hash[:]) < 0 })
+	sort.Slice(p.Metadata, func(i, j int) bool { return p.Metadata[i].Key < p.Metadata[j].Key })
+
+	data, err := msgpack.Marshal(&p)
+	if err != nil {
+		return nil, fmt.Errorf("encode snapshot: %w", err)
+	}
+	return data, nil
+}
+
+// DecodeSnapshot decodes a payload produced by EncodeSnapshot. Tree objects
+// are verified against their hashes. The returned snapshot can be walked and
+// diffed, but its FileRefs have no Path, so GetFile and DiffText cannot read
+// file content from it unless Store is set to a ContentStore holding it.
+func DecodeSnapshot(data []byte) (*Snapshot, error) {
+	var p snapshotPayload
+	if err := msgpack.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotSnapshot, err)
+	}
+
+	s := &Snapshot{
+		RootHash:   p.RootHash,
+		Trees:      make(map[[32]byte][]byte, len(p.Trees)),
+		Files:      make(map[[32]byte]*FileRef, len(p.Files)),
+		Symlinks:   make(map[[32]byte]string, len(p.Symlinks)),
+		PathPrefix: p.PathPrefix,
+		Stats: SnapshotStats{
+			FileCount:    p.Stats.FileCount,
+			DirCount:     p.Stats.DirCount,
+			SymlinkCount: p.Stats.SymlinkCount,
+			SpecialCount: p.Stats.SpecialCount,
+			TotalBytes:   p.Stats.TotalBytes,
+			Duration:     time.Duration(p.Stats.DurationMs) * time.Millisecond,
+
+			UniqueBytes:     p.Stats.UniqueBytes,
+			LargestFile:     p.Stats.LargestFile,
+			LargestFileSize: p.Stats.LargestFileSize,
+		},
+		CapturedAt: time.UnixMilli(p.CapturedAt),
+	}
+	for _, tree := range p.Trees {
+		if blake3.Sum256(tree.Data) != tree.Hash {
+			return nil, fmt.Errorf("%w: tree %x does not match its hash", ErrNotSnapshot, tree.Hash[:8])
+		}
+		s.Trees[tree.Hash] = tree.Data
+	}
+	for _, f := range p.Files {
+		s.Files[f.Hash] = &FileRef{Size: f.Size, Hash: f.Hash}
+	}
+	for _, l := range p.Symlinks {
+		s.Symlinks[l.Hash] = l.Target
+	}
+	if len(p.Metadata) > 0 {
+		s.Metadata = make(map[string]string, len(p.Metadata))
+		for _, label := range p.Metadata {
+			s.Metadata[label.Key] = label.Value
+		}
+	}
+	if _, ok := s.Trees[s.RootHash]; !ok {
+		return nil, fmt.Errorf("%w: root tree %x missing", ErrNotSnapshot, s.RootHash[:8])
+	}
+	return s, nil
+}
+
+// DecodeTurnFSTree decodes a turn whose payload is a snapshot encoded by
+// EncodeSnapshot, decompressing it with cxdb.DecodeTurnPayload first. The turn
+// must have been fetched with its payload. It returns an error wrapping
+// ErrNotSnapshot if the turn's type ID or encoding does not match or the
+// payload does not decode as a snapshot.
+func DecodeTurnFSTree(turn cxdb.TurnRecord) (*Snapshot, error) {
+	if turn.TypeID != TypeIDSnapshot {
+		return nil, fmt.Errorf("%w: turn %d has type %q", ErrNotSnapshot, turn.TurnID, turn.TypeID)
+	}
+	if turn.TypeVersion > TypeVersionSnapshot {
+		return nil, fmt.Errorf("%w: turn %d has unsupported version %d", ErrNotSnapshot, turn.TurnID, turn.TypeVersion)
+	}
+	if turn.Encoding != cxdb.EncodingMsgpack {
+		return nil, fmt.Errorf("%w: turn %d has encoding %d", ErrNotSnapshot, turn.TurnID, turn.Encoding)
+	}
+
+	data, err := cxdb.DecodeTurnPayload(turn)
+	if err != nil {
+		return nil, fmt.Errorf("decode turn %d: %w", turn.TurnID, err)
+	}
+	s, err := DecodeSnapshot(data)
+	if err != nil {
+		return nil, fmt.Errorf("decode turn %d: %w", turn.TurnID, err)
+	}
+	return s, nil
+}
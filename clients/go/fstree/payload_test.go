@@ -0,0 +1,153 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package fstree
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	cxdb "github.com/strongdm/ai-cxdb/clients/go"
+)
+
+func TestDecodeTurnFSTree(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"a.txt":     "alpha\n",
+		"sub/b.txt": "beta\n",
+	})
+	if err := os.Symlink("a.txt", filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	snap, err := Capture(dir)
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+
+	payload, err := EncodeSnapshot(snap)
+	if err != nil {
+		t.Fatalf("EncodeSnapshot failed: %v", err)
+	}
+	again, err := EncodeSnapshot(snap)
+	if err != nil {
+		t.Fatalf("EncodeSnapshot failed: %v", err)
+	}
+	if !bytes.Equal(payload, again) {
+		t.Error("EncodeSnapshot is not deterministic")
+	}
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write(payload)
+	_ = zw.Close()
+
+	turn := cxdb.TurnRecord{
+		TurnID:      7,
+		TypeID:      TypeIDSnapshot,
+		TypeVersion: TypeVersionSnapshot,
+		Encoding:    cxdb.EncodingMsgpack,
+		Compression: cxdb.CompressionGzip,
+		Payload:     gz.Bytes(),
+	}
+	got, err := DecodeTurnFSTree(turn)
+	if err != nil {
+		t.Fatalf("DecodeTurnFSTree failed: %v", err)
+	}
+
+	if got.RootHash != snap.RootHash {
+		t.Errorf("root hash mismatch")
+	}
+	if got.Stats.FileCount != snap.Stats.FileCount || got.Stats.TotalBytes != snap.Stats.TotalBytes {
+		t.Errorf("stats mismatch: got %+v, want %+v", got.Stats, snap.Stats)
+	}
+	if !got.CapturedAt.Equal(snap.CapturedAt.Truncate(1e6)) {
+		t.Errorf("CapturedAt = %v, want %v", got.CapturedAt, snap.CapturedAt)
+	}
+	files, err := got.ListFiles()
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("expected 2 files, got %v", files)
+	}
+	diff, err := got.Diff(snap)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if !diff.IsEmpty() {
+		t.Errorf("decoded snapshot differs from original: %+v", diff)
+	}
+
+	// Wrong type, and a payload that is not a snapshot.
+	other := turn
+	other.TypeID = "cxdb.ConversationItem"
+	if _, err := DecodeTurnFSTree(other); !errors.Is(err, ErrNotSnapshot) {
+		t.Errorf("expected ErrNotSnapshot for wrong type, got %v", err)
+	}
+	other = turn
+	other.Compression = cxdb.CompressionNone
+	other.Payload = []byte{0x93, 0x01, 0x02, 0x03}
+	if _, err := DecodeTurnFSTree(other); !errors.Is(err, ErrNotSnapshot) {
+		t.Errorf("expected ErrNotSnapshot for bad payload, got %v", err)
+	}
+}
+
+func TestEncodeSnapshot_Metadata(t *testing.T) {
+	dir := writeTree(t, map[string]string{"a.txt": "alpha\n"})
+	metadata := map[string]string{"name": "nightly", "env": "prod", "build": "1234"}
+
+	snap, err := Capture(dir, WithSnapshotMetadata(metadata))
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	plain, err := Capture(dir)
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	if snap.RootHash != plain.RootHash {
+		t.Error("metadata changed RootHash")
+	}
+	metadata["name"] = "changed"
+	if snap.Metadata["name"] != "nightly" {
+		t.Error("snapshot metadata aliases the caller's map")
+	}
+
+	payload, err := EncodeSnapshot(snap)
+	if err != nil {
+		t.Fatalf("EncodeSnapshot failed: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		again, _ := EncodeSnapshot(snap)
+		if !bytes.Equal(payload, again) {
+			t.Fatal("EncodeSnapshot is not deterministic with metadata")
+		}
+	}
+
+	got, err := DecodeSnapshot(payload)
+	if err != nil {
+		t.Fatalf("DecodeSnapshot failed: %v", err)
+	}
+	want := map[string]string{"name": "nightly", "env": "prod", "build": "1234"}
+	if !reflect.DeepEqual(got.Metadata, want) {
+		t.Errorf("Metadata = %v, want %v", got.Metadata, want)
+	}
+	if !got.Equal(plain) {
+		t.Error("decoded snapshot differs from one captured without metadata")
+	}
+
+	plainPayload, err := EncodeSnapshot(plain)
+	if err != nil {
+		t.Fatalf("EncodeSnapshot failed: %v", err)
+	}
+	decoded, err := DecodeSnapshot(plainPayload)
+	if err != nil {
+		t.Fatalf("DecodeSnapshot failed: %v", err)
+	}
+	if decoded.Metadata != nil {
+		t.Errorf("Metadata = %v, want nil", decoded.Metadata)
+	}
+}
@@ -4,23 +4,58 @@
 package fstree
 
 import (
+	"bytes"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"maps"
 	"os"
-	"path/filepath"
+	"path"
+	"strings"
+
+	"github.com/zeebo/blake3"
 )
 
 // GetFile returns a reader for the file content given its hash.
-// Returns nil if the file is not in this snapshot.
+// Returns nil if the file is not in this snapshot. Content is read from
+// Store if one is set and from FileRef.Path otherwise.
 func (s *Snapshot) GetFile(hash [32]byte) (io.ReadCloser, error) {
 	ref, ok := s.Files[hash]
 	if !ok {
 		return nil, fmt.Errorf("file not found: %x", hash[:8])
 	}
 
+	if s.Store != nil {
+		return s.Store.Get(hash)
+	}
 	return os.Open(ref.Path)
 }
 
+// BlobReader returns a reader for the blob whose hash is given as 64 hex
+// digits, as printed with %x: the content of a file, the target of a
+// symlink, or a serialized tree object, which are the blobs Upload sends. It
+// returns an error wrapping ErrBlobNotFound if the snapshot references no
+// such blob. File content is read as by GetFile.
+func (s *Snapshot) BlobReader(hash string) (io.ReadCloser, error) {
+	raw, err := hex.DecodeString(hash)
+	if err != nil || len(raw) != 32 {
+		return nil, fmt.Errorf("invalid blob hash %q", hash)
+	}
+	var h [32]byte
+	copy(h[:], raw)
+
+	if _, ok := s.Files[h]; ok {
+		return s.GetFile(h)
+	}
+	if target, ok := s.Symlinks[h]; ok {
+		return io.NopCloser(strings.NewReader(target)), nil
+	}
+	if data, ok := s.Trees[h]; ok {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrBlobNotFound, hash)
+}
+
 // GetTree returns the deserialized tree object for a given hash.
 func (s *Snapshot) GetTree(hash [32]byte) ([]TreeEntry, error) {
 	data, ok := s.Trees[hash]
@@ -31,16 +66,104 @@ func (s *Snapshot) GetTree(hash [32]byte) ([]TreeEntry, error) {
 	return DeserializeTree(data)
 }
 
+// UniqueDirCount returns the number of distinct directory tree objects in the
+// snapshot. Content-identical directories, such as repeated vendored
+// dependencies, count once, whereas Stats.DirCount counts every occurrence.
+func (s *Snapshot) UniqueDirCount() int {
+	return len(s.Trees)
+}
+
+// Equal reports whether s and other hold the same tree. It compares only
+// RootHash, so it runs in constant time: every tree object is named by the
+// BLAKE3-256 hash of its serialized entries, which in turn name their files,
+// symlinks, and subdirectories by hash, so equal root hashes imply equal trees
+// as long as both snapshots were hashed with the same algorithm. PathPrefix,
+// Stats, and CapturedAt are not compared. Two nil snapshots are equal.
+func (s *Snapshot) Equal(other *Snapshot) bool {
+	if s == nil || other == nil {
+		return s == other
+	}
+	return s.RootHash == other.RootHash
+}
+
+// EqualDeep is like Equal, but when the root hashes match it also walks both
+// trees and checks that every tree object they reference is present, hashes
+// to its name, and is identical in both, and that every symlink resolves to
+// the same target. It is meant for snapshots whose Trees were built or
+// decoded from an untrusted source; for snapshots returned by Capture, Equal
+// gives the same answer. It returns an error if either snapshot is missing a
+// tree object or holds one that does not match its hash.
+func (s *Snapshot) EqualDeep(other *Snapshot) (bool, error) {
+	if !s.Equal(other) {
+		return false, nil
+	}
+	if s == nil {
+		return true, nil
+	}
+	return s.equalTree(other, s.RootHash, make(map[[32]byte]bool))
+}
+
+func (s *Snapshot) equalTree(other *Snapshot, hash [32]byte, seen map[[32]byte]bool) (bool, error) {
+	if seen[hash] {
+		return true, nil
+	}
+	seen[hash] = true
+
+	data, ok := s.Trees[hash]
+	if !ok {
+		return false, fmt.Errorf("tree not found: %x", hash[:8])
+	}
+	otherData, ok := other.Trees[hash]
+	if !ok {
+		return false, fmt.Errorf("tree not found: %x", hash[:8])
+	}
+	if blake3.Sum256(data) != hash || blake3.Sum256(otherData) != hash {
+		return false, fmt.Errorf("tree %x does not match its hash", hash[:8])
+	}
+	if !bytes.Equal(data, otherData) {
+		return false, nil
+	}
+
+	entries, err := DeserializeTree(data)
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range entries {
+		switch entry.Kind {
+		case EntryKindDirectory:
+			if eq, err := s.equalTree(other, entry.Hash, seen); err != nil || !eq {
+				return eq, err
+			}
+		case EntryKindSymlink:
+			target, ok := s.Symlinks[entry.Hash]
+			otherTarget, otherOK := other.Symlinks[entry.Hash]
+			if ok != otherOK || target != otherTarget {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// HasChangedSince reports whether s holds a different tree from other, an
+// earlier snapshot of the same directory. It is the inverse of Equal, except
+// that a nil other always counts as a change. Tracker uses it to decide
+// whether a new snapshot differs from the last one.
+func (s *Snapshot) HasChangedSince(other *Snapshot) bool {
+	return other == nil || !s.Equal(other)
+}
+
 // GetRootEntries returns the entries at the root of the snapshot.
 func (s *Snapshot) GetRootEntries() ([]TreeEntry, error) {
 	return s.GetTree(s.RootHash)
 }
 
 // Walk traverses the snapshot tree, calling fn for each entry.
-// The path argument is the full relative path from the root.
+// The path argument is the full relative path from the root, using forward
+// slashes on every platform and starting with PathPrefix if one is set.
 // If fn returns an error, walking stops and that error is returned.
 func (s *Snapshot) Walk(fn func(path string, entry TreeEntry) error) error {
-	return s.walkTree(s.RootHash, "", fn)
+	return s.walkTree(s.RootHash, s.PathPrefix, fn)
 }
 
 func (s *Snapshot) walkTree(hash [32]byte, prefix string, fn func(string, TreeEntry) error) error {
@@ -50,17 +173,17 @@ func (s *Snapshot) walkTree(hash [32]byte, prefix string, fn func(string, TreeEn
 	}
 
 	for _, entry := range entries {
-		path := entry.Name
+		entryPath := entry.Name
 		if prefix != "" {
-			path = filepath.Join(prefix, entry.Name)
+			entryPath = path.Join(prefix, entry.Name)
 		}
 
-		if err := fn(path, entry); err != nil {
+		if err := fn(entryPath, entry); err != nil {
 			return err
 		}
 
 		if entry.Kind == EntryKindDirectory {
-			if err := s.walkTree(entry.Hash, path, fn); err != nil {
+			if err := s.walkTree(entry.Hash, entryPath, fn); err != nil {
 				return err
 			}
 		}
@@ -69,6 +192,181 @@ func (s *Snapshot) walkTree(hash [32]byte, prefix string, fn func(string, TreeEn
 	return nil
 }
 
+// Filter returns a new snapshot containing only the entries for which pred
+// returns true. pred sees the same paths as Walk. Returning false for a
+// directory drops it and everything below it without visiting them. A
+// directory that loses all of its entries to the filter is dropped as well,
+// while one that was already empty is kept if pred accepts it. The root
+// directory is always kept.
+//
+// Tree objects are rebuilt, so RootHash is the hash of the filtered tree and
+// equals that of a Capture that excluded the same entries. Trees, Files, and
+// Symlinks hold only what the filtered tree references, and Stats counts only
+// the remaining entries. Duration, CapturedAt, PathPrefix, Store, and Metadata
+// are copied from s.
+func (s *Snapshot) Filter(pred func(path string, entry TreeEntry) bool) (*Snapshot, error) {
+	out := &Snapshot{
+		Trees:      make(map[[32]byte][]byte),
+		Files:      make(map[[32]byte]*FileRef),
+		Symlinks:   make(map[[32]byte]string),
+		PathPrefix: s.PathPrefix,
+		Store:      s.Store,
+		Metadata:   maps.Clone(s.Metadata),
+		CapturedAt: s.CapturedAt,
+		Stats:      SnapshotStats{Duration: s.Stats.Duration},
+	}
+
+	rootHash, _, err := s.filterTree(out, s.RootHash, s.PathPrefix, pred, true)
+	if err != nil {
+		return nil, err
+	}
+	out.RootHash = rootHash
+	return out, nil
+}
+
+// Prune removes from s, in place, the entries for which pred returns true,
+// along with the tree objects, files, and symlink targets that nothing left
+// references. It is Filter with pred inverted, applied to s itself: pred sees
+// the same paths, returning true for a directory removes everything below
+// it, and a directory that loses all of its entries is removed as well.
+//
+// RootHash, Trees, Files, Symlinks, and Stats are replaced with those of the
+// pruned tree; the maps are new, so copies of them taken earlier still
+// describe the tree as it was. Other fields are unchanged, and Store is not
+// touched, since other snapshots may share its blobs. Use Filter to keep s
+// as it is. If Prune returns an error, s is unchanged. Prune must not run
+// concurrently with other use of s.
+func (s *Snapshot) Prune(pred func(path string, entry TreeEntry) bool) error {
+	pruned, err := s.Filter(func(path string, entry TreeEntry) bool {
+		return !pred(path, entry)
+	})
+	if err != nil {
+		return err
+	}
+	s.RootHash = pruned.RootHash
+	s.Trees = pruned.Trees
+	s.Files = pruned.Files
+	s.Symlinks = pruned.Symlinks
+	s.Stats = pruned.Stats
+	return nil
+}
+
+// filterTree writes the filtered form of the tree hash into out and returns
+// its hash. It returns false, adding nothing to out, if the filter removed
+// every entry and keepEmptied is not set.
+func (s *Snapshot) filterTree(out *Snapshot, hash [32]byte, prefix string, pred func(string, TreeEntry) bool, keepEmptied bool) ([32]byte, bool, error) {
+	entries, err := s.GetTree(hash)
+	if err != nil {
+		return [32]byte{}, false, err
+	}
+
+	// Left nil when nothing is kept so an empty directory serializes exactly
+	// as Capture writes it.
+	var kept []TreeEntry
+	for _, entry := range entries {
+		entryPath := entry.Name
+		if prefix != "" {
+			entryPath = path.Join(prefix, entry.Name)
+		}
+		if !pred(entryPath, entry) {
+			continue
+		}
+
+		switch entry.Kind {
+		case EntryKindDirectory:
+			subHash, ok, err := s.filterTree(out, entry.Hash, entryPath, pred, false)
+			if err != nil {
+				return [32]byte{}, false, err
+			}
+			if !ok {
+				continue
+			}
+			entry.Hash = subHash
+		case EntryKindFile:
+			ref, ok := s.Files[entry.Hash]
+			if !ok {
+				return [32]byte{}, false, fmt.Errorf("file not found: %x", entry.Hash[:8])
+			}
+			_, seen := out.Files[entry.Hash]
+			out.Files[entry.Hash] = ref
+			out.Stats.addFile(entryPath, entry.Size, !seen)
+		case EntryKindSymlink:
+			out.Symlinks[entry.Hash] = s.Symlinks[entry.Hash]
+			out.Stats.SymlinkCount++
+		case EntryKindSpecial:
+			out.Stats.SpecialCount++
+		}
+		kept = append(kept, entry)
+	}
+	if len(kept) == 0 && len(entries) > 0 && !keepEmptied {
+		return [32]byte{}, false, nil
+	}
+
+	treeBytes, err := serializeTree(kept)
+	if err != nil {
+		return [32]byte{}, false, fmt.Errorf("serialize tree %s: %w", prefix, err)
+	}
+	newHash := blake3.Sum256(treeBytes)
+	out.Trees[newHash] = treeBytes
+	out.Stats.DirCount++
+	return newHash, true, nil
+}
+
+// Subtree returns the directory at dirPath as a standalone snapshot, as if it
+// had been captured directly: its RootHash and Stats cover only that
+// directory, PathPrefix is empty, and Trees, Files, and Symlinks hold only
+// what it references. dirPath takes the same form as in GetFileAtPath. It
+// returns an error if the path does not exist or is not a directory.
+// Duration, CapturedAt, Store, and Metadata are copied from s.
+func (s *Snapshot) Subtree(dirPath string) (*Snapshot, error) {
+	parts := splitPath(dirPath)
+	if prefix := splitPath(s.PathPrefix); len(prefix) > 0 {
+		if len(parts) < len(prefix) || path.Join(parts[:len(prefix)]...) != path.Join(prefix...) {
+			return nil, fmt.Errorf("path not found: %s", dirPath)
+		}
+		parts = parts[len(prefix):]
+	}
+
+	hash := s.RootHash
+	for i, part := range parts {
+		entries, err := s.GetTree(hash)
+		if err != nil {
+			return nil, fmt.Errorf("get tree: %w", err)
+		}
+		var found *TreeEntry
+		for _, entry := range entries {
+			if entry.Name == part {
+				found = &entry
+				break
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("path not found: %s", dirPath)
+		}
+		if found.Kind != EntryKindDirectory {
+			return nil, fmt.Errorf("not a directory: %s", path.Join(parts[:i+1]...))
+		}
+		hash = found.Hash
+	}
+
+	out := &Snapshot{
+		Trees:      make(map[[32]byte][]byte),
+		Files:      make(map[[32]byte]*FileRef),
+		Symlinks:   make(map[[32]byte]string),
+		Store:      s.Store,
+		Metadata:   maps.Clone(s.Metadata),
+		CapturedAt: s.CapturedAt,
+		Stats:      SnapshotStats{Duration: s.Stats.Duration},
+	}
+	keepAll := func(string, TreeEntry) bool { return true }
+	rootHash, _, err := s.filterTree(out, hash, "", keepAll, true)
+	if err != nil {
+		return nil, err
+	}
+	out.RootHash = rootHash
+	return out, nil
+}
+
 // ListFiles returns all file paths in the snapshot.
 func (s *Snapshot) ListFiles() ([]string, error) {
 	var paths []string
@@ -82,12 +380,19 @@ func (s *Snapshot) ListFiles() ([]string, error) {
 }
 
 // GetFileAtPath looks up a file by its path in the snapshot.
-// Returns the TreeEntry and content reader if found.
-func (s *Snapshot) GetFileAtPath(path string) (*TreeEntry, io.ReadCloser, error) {
-	parts := splitPath(path)
+// The path may use either '/' or '\' as separator and must include
+// PathPrefix if one is set. Returns the TreeEntry and content reader if found.
+func (s *Snapshot) GetFileAtPath(filePath string) (*TreeEntry, io.ReadCloser, error) {
+	parts := splitPath(filePath)
 	if len(parts) == 0 {
 		return nil, nil, fmt.Errorf("empty path")
 	}
+	if prefix := splitPath(s.PathPrefix); len(prefix) > 0 {
+		if len(parts) <= len(prefix) || path.Join(parts[:len(prefix)]...) != s.PathPrefix {
+			return nil, nil, fmt.Errorf("path not found: %s", filePath)
+		}
+		parts = parts[len(prefix):]
+	}
 
 	currentHash := s.RootHash
 
@@ -106,7 +411,7 @@ func (s *Snapshot) GetFileAtPath(path string) (*TreeEntry, io.ReadCloser, error)
 		}
 
 		if found == nil {
-			return nil, nil, fmt.Errorf("path not found: %s", path)
+			return nil, nil, fmt.Errorf("path not found: %s", filePath)
 		}
 
 		// Last component
@@ -123,19 +428,19 @@ func (s *Snapshot) GetFileAtPath(path string) (*TreeEntry, io.ReadCloser, error)
 
 		// Navigate into directory
 		if found.Kind != EntryKindDirectory {
-			return nil, nil, fmt.Errorf("not a directory: %s", filepath.Join(parts[:i+1]...))
+			return nil, nil, fmt.Errorf("not a directory: %s", path.Join(parts[:i+1]...))
 		}
 		currentHash = found.Hash
 	}
 
-	return nil, nil, fmt.Errorf("path not found: %s", path)
+	return nil, nil, fmt.Errorf("path not found: %s", filePath)
 }
 
 // splitPath splits a path into components.
-func splitPath(path string) []string {
+func splitPath(p string) []string {
 	// Normalize to forward slashes for cross-platform consistency
-	path = filepath.ToSlash(filepath.Clean(path))
-	if path == "." || path == "" {
+	path := normalizePath(p)
+	if path == "" {
 		return nil
 	}
 
@@ -158,7 +463,13 @@ func splitPath(path string) []string {
 
 // Diff compares two snapshots and returns the differences.
 // old may be nil, in which case all files in s are considered added.
+// Paths are listed in the order DiffStream reports them.
 func (s *Snapshot) Diff(old *Snapshot) (*SnapshotDiff, error) {
+	return s.DiffWithOptions(old, DiffOptions{})
+}
+
+// DiffWithOptions is like Diff, with opts adjusting what counts as a change.
+func (s *Snapshot) DiffWithOptions(old *Snapshot, opts DiffOptions) (*SnapshotDiff, error) {
 	diff := &SnapshotDiff{
 		NewRoot: s.RootHash,
 	}
@@ -167,59 +478,182 @@ func (s *Snapshot) Diff(old *Snapshot) (*SnapshotDiff, error) {
 		diff.OldRoot = old.RootHash
 	}
 
-	// Quick check - if root hashes match, no changes
-	if old != nil && s.RootHash == old.RootHash {
-		return diff, nil
+	err := s.DiffStreamWithOptions(old, opts, func(change DiffChange) error {
+		switch change.Kind {
+		case ChangeAdded:
+			diff.Added = append(diff.Added, change.Path)
+		case ChangeRemoved:
+			diff.Removed = append(diff.Removed, change.Path)
+		case ChangeModified:
+			diff.Modified = append(diff.Modified, change.Path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Collect all paths from new snapshot
-	newPaths := make(map[string][32]byte)
-	if err := s.Walk(func(path string, entry TreeEntry) error {
-		if entry.Kind == EntryKindFile || entry.Kind == EntryKindSymlink {
-			newPaths[path] = entry.Hash
+	return diff, nil
+}
+
+// DiffStream compares s against base and calls fn for each changed file or
+// symlink, without collecting the changes in memory. Both trees are walked
+// together and subtrees with equal hashes are skipped, so the cost is
+// proportional to the size of the change rather than the size of the trees.
+//
+// Changes are reported in Walk order: depth-first, with the entries of each
+// directory sorted by name. A path that changes between a file and a
+// directory is reported as removed and then added. base may be nil, in which
+// case every file in s is reported as added. If the snapshots have different
+// PathPrefix values, every path in base is reported removed, followed by every
+// path in s as added.
+//
+// A file or symlink present in both snapshots is reported modified if its
+// content differs; use DiffStreamWithOptions with DiffOptions.CompareMode to
+// compare modes as well.
+//
+// If fn returns an error, the walk stops and that error is returned.
+func (s *Snapshot) DiffStream(base *Snapshot, fn func(change DiffChange) error) error {
+	return s.DiffStreamWithOptions(base, DiffOptions{}, fn)
+}
+
+// DiffStreamWithOptions is like DiffStream, with opts adjusting what counts
+// as a change.
+func (s *Snapshot) DiffStreamWithOptions(base *Snapshot, opts DiffOptions, fn func(change DiffChange) error) error {
+	if base == nil {
+		return s.diffSide(s.RootHash, s.PathPrefix, ChangeAdded, fn)
+	}
+	if s.PathPrefix != base.PathPrefix {
+		if err := base.diffSide(base.RootHash, base.PathPrefix, ChangeRemoved, fn); err != nil {
+			return err
 		}
+		return s.diffSide(s.RootHash, s.PathPrefix, ChangeAdded, fn)
+	}
+	return s.diffTrees(base, base.RootHash, s.RootHash, s.PathPrefix, opts, fn)
+}
+
+// diffTrees merges the sorted entries of two directories, descending only into
+// subtrees whose hashes differ.
+func (s *Snapshot) diffTrees(base *Snapshot, oldHash, newHash [32]byte, prefix string, opts DiffOptions, fn func(DiffChange) error) error {
+	if oldHash == newHash {
 		return nil
-	}); err != nil {
-		return nil, fmt.Errorf("walk new snapshot: %w", err)
 	}
 
-	// If no old snapshot, everything is added
-	if old == nil {
-		for path := range newPaths {
-			diff.Added = append(diff.Added, path)
+	oldEntries, err := base.GetTree(oldHash)
+	if err != nil {
+		return fmt.Errorf("walk old snapshot: %w", err)
+	}
+	newEntries, err := s.GetTree(newHash)
+	if err != nil {
+		return fmt.Errorf("walk new snapshot: %w", err)
+	}
+
+	i, j := 0, 0
+	for i < len(oldEntries) || j < len(newEntries) {
+		var oldEntry, newEntry *TreeEntry
+		switch {
+		case j == len(newEntries) || (i < len(oldEntries) && oldEntries[i].Name < newEntries[j].Name):
+			oldEntry = &oldEntries[i]
+			i++
+		case i == len(oldEntries) || newEntries[j].Name < oldEntries[i].Name:
+			newEntry = &newEntries[j]
+			j++
+		default:
+			oldEntry, newEntry = &oldEntries[i], &newEntries[j]
+			i++
+			j++
+		}
+
+		if err := s.diffEntry(base, oldEntry, newEntry, prefix, opts, fn); err != nil {
+			return err
 		}
-		return diff, nil
 	}
 
-	// Collect all paths from old snapshot
-	oldPaths := make(map[string][32]byte)
-	if err := old.Walk(func(path string, entry TreeEntry) error {
-		if entry.Kind == EntryKindFile || entry.Kind == EntryKindSymlink {
-			oldPaths[path] = entry.Hash
+	return nil
+}
+
+// diffEntry reports the changes for a single name present in at least one of
+// the two directories being compared.
+func (s *Snapshot) diffEntry(base *Snapshot, oldEntry, newEntry *TreeEntry, prefix string, opts DiffOptions, fn func(DiffChange) error) error {
+	var name string
+	if oldEntry != nil {
+		name = oldEntry.Name
+	} else {
+		name = newEntry.Name
+	}
+	entryPath := name
+	if prefix != "" {
+		entryPath = path.Join(prefix, name)
+	}
+
+	if oldEntry != nil && newEntry != nil {
+		oldDir := oldEntry.Kind == EntryKindDirectory
+		newDir := newEntry.Kind == EntryKindDirectory
+		if oldDir && newDir {
+			return s.diffTrees(base, oldEntry.Hash, newEntry.Hash, entryPath, opts, fn)
+		}
+		if isDiffLeaf(*oldEntry) && isDiffLeaf(*newEntry) {
+			if oldEntry.Hash == newEntry.Hash && (!opts.CompareMode || oldEntry.Mode == newEntry.Mode) {
+				return nil
+			}
+			return fn(DiffChange{Kind: ChangeModified, Path: entryPath, Old: *oldEntry, New: *newEntry})
+		}
+	}
+
+	if oldEntry != nil {
+		if err := base.diffEntrySide(*oldEntry, entryPath, ChangeRemoved, fn); err != nil {
+			return err
+		}
+	}
+	if newEntry != nil {
+		if err := s.diffEntrySide(*newEntry, entryPath, ChangeAdded, fn); err != nil {
+			return err
 		}
-		return nil
-	}); err != nil {
-		return nil, fmt.Errorf("walk old snapshot: %w", err)
 	}
+	return nil
+}
 
-	// Find added and modified
-	for path, newHash := range newPaths {
-		oldHash, exists := oldPaths[path]
-		if !exists {
-			diff.Added = append(diff.Added, path)
-		} else if newHash != oldHash {
-			diff.Modified = append(diff.Modified, path)
+// diffSide reports every file and symlink below the tree hash as kind.
+func (s *Snapshot) diffSide(hash [32]byte, prefix string, kind ChangeKind, fn func(DiffChange) error) error {
+	entries, err := s.GetTree(hash)
+	if err != nil {
+		if kind == ChangeRemoved {
+			return fmt.Errorf("walk old snapshot: %w", err)
 		}
+		return fmt.Errorf("walk new snapshot: %w", err)
 	}
 
-	// Find removed
-	for path := range oldPaths {
-		if _, exists := newPaths[path]; !exists {
-			diff.Removed = append(diff.Removed, path)
+	for _, entry := range entries {
+		entryPath := entry.Name
+		if prefix != "" {
+			entryPath = path.Join(prefix, entry.Name)
+		}
+		if err := s.diffEntrySide(entry, entryPath, kind, fn); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	return diff, nil
+// diffEntrySide reports entry, or everything below it if it is a directory,
+// as kind.
+func (s *Snapshot) diffEntrySide(entry TreeEntry, entryPath string, kind ChangeKind, fn func(DiffChange) error) error {
+	switch {
+	case entry.Kind == EntryKindDirectory:
+		return s.diffSide(entry.Hash, entryPath, kind, fn)
+	case !isDiffLeaf(entry):
+		return nil
+	case kind == ChangeRemoved:
+		return fn(DiffChange{Kind: kind, Path: entryPath, Old: entry})
+	default:
+		return fn(DiffChange{Kind: kind, Path: entryPath, New: entry})
+	}
+}
+
+// isDiffLeaf reports whether an entry's path is tracked by Diff. Special files
+// have no content and are not reported.
+func isDiffLeaf(entry TreeEntry) bool {
+	return entry.Kind == EntryKindFile || entry.Kind == EntryKindSymlink
 }
 
 // IsEmpty returns true if the diff contains no changes.
@@ -231,3 +665,9 @@ func (d *SnapshotDiff) IsEmpty() bool {
 func (d *SnapshotDiff) TotalChanges() int {
 	return len(d.Added) + len(d.Removed) + len(d.Modified)
 }
+
+// String returns a compact one-line summary of the statistics.
+func (s SnapshotStats) String() string {
+	return fmt.Sprintf("files=%d dirs=%d symlinks=%d special=%d bytes=%d duration=%s",
+		s.FileCount, s.DirCount, s.SymlinkCount, s.SpecialCount, s.TotalBytes, s.Duration)
+}
@@ -0,0 +1,178 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package fstree
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/zeebo/blake3"
+)
+
+// ErrContentNotFound is returned by a ContentStore's Get when it holds no
+// content for the requested hash.
+var ErrContentNotFound = errors.New("fstree: content not found")
+
+// ContentStore holds file content addressed by its BLAKE3-256 hash. A
+// snapshot captured with WithContentStore copies every file into the store
+// as it is hashed, and reads file content back from the store instead of
+// from the captured paths, so the snapshot stays readable after the files
+// on disk change or are removed.
+//
+// Implementations must be safe for concurrent use. Put must reject content
+// that does not hash to hash, so a file modified while it is being captured
+// cannot be stored under a stale hash.
+type ContentStore interface {
+	// Put stores the content read from r under hash. Storing a hash that is
+	// already present is not an error.
+	Put(hash [32]byte, r io.Reader) error
+
+	// Get returns a reader for the content stored under hash, or an error
+	// wrapping ErrContentNotFound if there is none. The caller must close it.
+	Get(hash [32]byte) (io.ReadCloser, error)
+
+	// Has reports whether content is stored under hash.
+	Has(hash [32]byte) (bool, error)
+}
+
+// MemoryStore is a ContentStore that keeps content in memory.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	blobs map[[32]byte][]byte
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{blobs: make(map[[32]byte][]byte)}
+}
+
+// Put implements ContentStore.
+func (m *MemoryStore) Put(hash [32]byte, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if blake3.Sum256(data) != hash {
+		return fmt.Errorf("content does not match hash %x", hash[:8])
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blobs[hash] = data
+	return nil
+}
+
+// Get implements ContentStore.
+func (m *MemoryStore) Get(hash [32]byte) (io.ReadCloser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	data, ok := m.blobs[hash]
+	if !ok {
+		return nil, fmt.Errorf("%w: %x", ErrContentNotFound, hash[:8])
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Has implements ContentStore.
+func (m *MemoryStore) Has(hash [32]byte) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.blobs[hash]
+	return ok, nil
+}
+
+// DirStore is a ContentStore that keeps each blob in its own file under a
+// directory, named by the hex hash and fanned out by its first byte, so
+// captures larger than memory can be kept.
+type DirStore struct {
+	dir string
+}
+
+// NewDirStore returns a DirStore rooted at dir, creating the directory if it
+// does not exist. Blobs already in dir from an earlier run are reused.
+func NewDirStore(dir string) (*DirStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create content store: %w", err)
+	}
+	return &DirStore{dir: dir}, nil
+}
+
+func (d *DirStore) path(hash [32]byte) string {
+	name := hex.EncodeToString(hash[:])
+	return filepath.Join(d.dir, name[:2], name[2:])
+}
+
+// Put implements ContentStore. Content is written to a temporary file and
+// renamed into place once its hash is verified, so a blob is never visible
+// half-written.
+func (d *DirStore) Put(hash [32]byte, r io.Reader) error {
+	dst := d.path(hash)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	h := blake3.New()
+	_, err = io.Copy(io.MultiWriter(tmp, h), r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	var got [32]byte
+	copy(got[:], h.Sum(nil))
+	if got != hash {
+		return fmt.Errorf("content does not match hash %x", hash[:8])
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+// Get implements ContentStore.
+func (d *DirStore) Get(hash [32]byte) (io.ReadCloser, error) {
+	f, err := os.Open(d.path(hash))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %x", ErrContentNotFound, hash[:8])
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Has implements ContentStore.
+func (d *DirStore) Has(hash [32]byte) (bool, error) {
+	_, err := os.Stat(d.path(hash))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// storeFile copies the file at absPath into the content store under hash,
+// unless the store already has it.
+func (b *builder) storeFile(absPath string, hash [32]byte) error {
+	store := b.opts.contentStore
+	if ok, err := store.Has(hash); err != nil || ok {
+		return err
+	}
+
+	f, err := os.Open(absPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	return store.Put(hash, f)
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package fstree
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zeebo/blake3"
+)
+
+func TestCapture_ContentStore(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	_ = os.MkdirAll(filepath.Join(tmpDir, "sub"), 0755)
+	_ = os.WriteFile(filepath.Join(tmpDir, "a.txt"), []byte("hello"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "sub", "b.txt"), []byte("world"), 0644)
+
+	store, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirStore failed: %v", err)
+	}
+
+	snap, err := Capture(tmpDir, WithContentStore(store))
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	plain, err := Capture(tmpDir)
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	if snap.RootHash != plain.RootHash {
+		t.Error("content store changed RootHash")
+	}
+
+	for hash := range snap.Files {
+		if ok, err := store.Has(hash); err != nil || !ok {
+			t.Errorf("store missing %x: %v", hash[:8], err)
+		}
+	}
+
+	// Content stays readable after the files on disk are gone.
+	if err := os.RemoveAll(filepath.Join(tmpDir, "sub")); err != nil {
+		t.Fatal(err)
+	}
+	_, r, err := snap.GetFileAtPath("sub/b.txt")
+	if err != nil {
+		t.Fatalf("GetFileAtPath failed: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	_ = r.Close()
+	if string(data) != "world" {
+		t.Errorf("content = %q, want %q", data, "world")
+	}
+
+	// A decoded snapshot reads content once given the store.
+	payload, err := EncodeSnapshot(snap)
+	if err != nil {
+		t.Fatalf("EncodeSnapshot failed: %v", err)
+	}
+	decoded, err := DecodeSnapshot(payload)
+	if err != nil {
+		t.Fatalf("DecodeSnapshot failed: %v", err)
+	}
+	decoded.Store = store
+	var out strings.Builder
+	if err := decoded.DiffText(nil, &out); err != nil {
+		t.Fatalf("DiffText failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "+hello") || !strings.Contains(out.String(), "+world") {
+		t.Errorf("DiffText missing content:\n%s", out.String())
+	}
+}
+
+func TestContentStores(t *testing.T) {
+	dirStore, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirStore failed: %v", err)
+	}
+
+	for name, store := range map[string]ContentStore{
+		"memory": NewMemoryStore(),
+		"dir":    dirStore,
+	} {
+		t.Run(name, func(t *testing.T) {
+			hash := blake3.Sum256([]byte("content"))
+
+			if _, err := store.Get(hash); !errors.Is(err, ErrContentNotFound) {
+				t.Errorf("Get of missing blob = %v, want ErrContentNotFound", err)
+			}
+			if err := store.Put(hash, strings.NewReader("tampered")); err == nil {
+				t.Error("expected Put to reject content that does not match the hash")
+			}
+			if ok, _ := store.Has(hash); ok {
+				t.Error("rejected content was stored")
+			}
+
+			if err := store.Put(hash, strings.NewReader("content")); err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+			if err := store.Put(hash, strings.NewReader("content")); err != nil {
+				t.Fatalf("repeated Put failed: %v", err)
+			}
+			if ok, err := store.Has(hash); err != nil || !ok {
+				t.Errorf("Has = %v, %v; want true, nil", ok, err)
+			}
+			r, err := store.Get(hash)
+			if err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+			data, _ := io.ReadAll(r)
+			_ = r.Close()
+			if string(data) != "content" {
+				t.Errorf("Get = %q, want %q", data, "content")
+			}
+		})
+	}
+}
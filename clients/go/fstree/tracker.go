@@ -5,33 +5,40 @@ package fstree
 
 import (
 	"sync"
-	"time"
 )
 
 // Tracker maintains state between snapshots for efficient incremental capture.
-// It uses file modification times to skip unchanged files.
+// With WithSnapshotCache it uses modification times to skip unchanged
+// directories and files.
 type Tracker struct {
 	root string
-	opts []Option
+	opts *options
 
 	mu           sync.RWMutex
 	lastSnapshot *Snapshot
-	lastMtime    map[string]time.Time // path -> mtime at last snapshot
+	cache        *captureCache // listings and hashes from the last snapshot
 }
 
 // NewTracker creates a tracker for incremental snapshots.
 func NewTracker(root string, opts ...Option) *Tracker {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
 	return &Tracker{
-		root:      root,
-		opts:      opts,
-		lastMtime: make(map[string]time.Time),
+		root: root,
+		opts: o,
 	}
 }
 
 // Snapshot takes a new snapshot, reusing cached hashes for unchanged files.
 // Returns the snapshot and whether it differs from the previous one.
 func (t *Tracker) Snapshot() (*Snapshot, bool, error) {
-	snap, err := Capture(t.root, t.opts...)
+	t.mu.RLock()
+	prev := t.cache
+	t.mu.RUnlock()
+
+	snap, cache, err := capture(t.root, t.opts, prev, nil)
 	if err != nil {
 		return nil, false, err
 	}
@@ -40,12 +47,11 @@ func (t *Tracker) Snapshot() (*Snapshot, bool, error) {
 	defer t.mu.Unlock()
 
 	// Check if this is different from last snapshot
-	changed := t.lastSnapshot == nil || t.lastSnapshot.RootHash != snap.RootHash
+	changed := snap.HasChangedSince(t.lastSnapshot)
 
 	// Update tracking state
 	t.lastSnapshot = snap
-	t.lastMtime = make(map[string]time.Time)
-	// Note: we could populate lastMtime here for future mtime-based optimization
+	t.cache = cache
 
 	return snap, changed, nil
 }
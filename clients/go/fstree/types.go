@@ -33,7 +33,9 @@ package fstree
 
 import "time"
 
-// EntryKind indicates the type of filesystem entry.
+// EntryKind indicates the type of filesystem entry. Its values are encoded
+// in tree objects and so are part of the format: they never change, and the
+// JSON form uses the names returned by String instead.
 type EntryKind uint8
 
 const (
@@ -43,8 +45,14 @@ const (
 	// EntryKindDirectory is a directory.
 	EntryKindDirectory EntryKind = 1
 
-	// EntryKindSymlink is a symbolic link.
+	// EntryKindSymlink is a symbolic link. Links are never followed, so a
+	// link to a directory is a symlink entry too.
 	EntryKindSymlink EntryKind = 2
+
+	// EntryKindSpecial is a named pipe, socket, or device node. Its content is
+	// never read: Size is 0, Hash is zero, and Mode carries the POSIX file type
+	// bits (e.g. 0010000 for a FIFO) in addition to the permission bits.
+	EntryKindSpecial EntryKind = 3
 )
 
 // TreeEntry represents a single entry in a directory.
@@ -53,14 +61,15 @@ type TreeEntry struct {
 	// Name is the filename (no path separators).
 	Name string `msgpack:"1" json:"name"`
 
-	// Kind indicates file, directory, or symlink.
+	// Kind indicates file, directory, symlink, or special file.
 	Kind EntryKind `msgpack:"2" json:"kind"`
 
 	// Mode contains POSIX permission bits (e.g., 0755, 0644).
 	// Only the lower 12 bits are used (no uid/gid for portability).
 	Mode uint32 `msgpack:"3" json:"mode"`
 
-	// Size is the uncompressed size in bytes (files only, 0 for dirs/symlinks).
+	// Size is the uncompressed size in bytes for files and the length of the
+	// target path for symlinks; it is 0 for directories and special files.
 	Size uint64 `msgpack:"4" json:"size"`
 
 	// Hash is the BLAKE3-256 hash:
@@ -68,6 +77,16 @@ type TreeEntry struct {
 	//   - For directories: hash of serialized TreeObject
 	//   - For symlinks: hash of target path bytes
 	Hash [32]byte `msgpack:"5" json:"hash"`
+
+	// Xattrs holds extended attributes (including SELinux labels and, on
+	// Linux, POSIX ACLs) for files and directories when captured with
+	// WithXattrs. It is omitted from the serialized entry when empty.
+	Xattrs map[string][]byte `msgpack:"6,omitempty" json:"xattrs,omitempty"`
+
+	// Truncated marks a directory whose contents were not captured because it
+	// lies at the WithMaxDepth limit. Its Hash is that of an empty tree. It is
+	// omitted from the serialized entry when false.
+	Truncated bool `msgpack:"7,omitempty" json:"truncated,omitempty"`
 }
 
 // TreeObject is a directory listing - a collection of entries.
@@ -82,7 +101,9 @@ type Snapshot struct {
 	RootHash [32]byte
 
 	// Trees maps tree hashes to their serialized TreeObject bytes.
-	// Includes all directory tree objects in the snapshot.
+	// Includes all directory tree objects in the snapshot. Directories with
+	// identical contents hash the same and share a single entry, so there may
+	// be fewer trees than Stats.DirCount.
 	Trees map[[32]byte][]byte
 
 	// Files maps file content hashes to FileRef.
@@ -93,13 +114,53 @@ type Snapshot struct {
 	// Stored separately from Files because the content is the target path, not file content.
 	Symlinks map[[32]byte]string
 
+	// PathPrefix is prepended to every path reported by Walk, ListFiles, and
+	// Diff, and is expected at the front of paths passed to GetFileAtPath. It
+	// is set by WithPathPrefix, always uses forward slashes, and has no
+	// leading or trailing slash. It does not affect RootHash.
+	PathPrefix string
+
+	// Store holds file content when the snapshot was captured with
+	// WithContentStore. When set, file content is read from Store instead of
+	// from FileRef.Path. It may also be set on a decoded snapshot to make its
+	// content readable. It does not affect RootHash.
+	Store ContentStore
+
+	// Metadata holds descriptive key/value pairs set by WithSnapshotMetadata,
+	// such as a name or labels, so stored snapshots can be listed and
+	// selected without a separate index. It travels with EncodeSnapshot and
+	// does not affect RootHash.
+	Metadata map[string]string
+
 	// Stats contains snapshot statistics.
 	Stats SnapshotStats
 
+	// CaptureErrors lists the entries Capture skipped because they could
+	// not be read, in the order they were met. See WithErrorPolicy. It is
+	// not carried by EncodeSnapshot.
+	CaptureErrors []CaptureError
+
 	// CapturedAt is when this snapshot was taken.
 	CapturedAt time.Time
 }
 
+// CaptureError records an entry that Capture could not read.
+type CaptureError struct {
+	// Path is the entry's path, in the form Walk reports it.
+	Path string
+
+	// Err is the error reading the entry.
+	Err error
+}
+
+func (e *CaptureError) Error() string {
+	return "capture " + e.Path + ": " + e.Err.Error()
+}
+
+func (e *CaptureError) Unwrap() error {
+	return e.Err
+}
+
 // FileRef references a file's content without loading it into memory.
 type FileRef struct {
 	// Path is the absolute path to the file.
@@ -123,13 +184,90 @@ type SnapshotStats struct {
 	// SymlinkCount is the number of symbolic links.
 	SymlinkCount int
 
-	// TotalBytes is the total size of all files.
+	// SpecialCount is the number of named pipes, sockets, and device nodes.
+	SpecialCount int
+
+	// TotalBytes is the total size of all files. A file whose content
+	// appears at several paths counts once for each of them.
 	TotalBytes uint64
 
+	// UniqueBytes is the total size of the distinct file contents, counting
+	// content shared by several paths once. It is the amount of content
+	// that Upload or a ContentStore holds, and is at most TotalBytes.
+	UniqueBytes uint64
+
+	// LargestFile is the path, in the form Walk reports it, of the largest
+	// file, and LargestFileSize its size. Of several files with the largest
+	// size, the one whose path sorts first is named. LargestFile is empty if
+	// there are no files.
+	LargestFile     string
+	LargestFileSize uint64
+
 	// Duration is how long the snapshot took.
 	Duration time.Duration
 }
 
+// addFile counts a file of the given size at path. unique reports whether
+// its content is not shared with a file already counted.
+func (s *SnapshotStats) addFile(path string, size uint64, unique bool) {
+	s.FileCount++
+	s.TotalBytes += size
+	if unique {
+		s.UniqueBytes += size
+	}
+	if s.LargestFile == "" || size > s.LargestFileSize || (size == s.LargestFileSize && path < s.LargestFile) {
+		s.LargestFile = path
+		s.LargestFileSize = size
+	}
+}
+
+// ChangeKind indicates how a path differs between two snapshots.
+type ChangeKind uint8
+
+const (
+	// ChangeAdded means the path exists only in the newer snapshot.
+	ChangeAdded ChangeKind = iota + 1
+
+	// ChangeRemoved means the path exists only in the base snapshot.
+	ChangeRemoved
+
+	// ChangeModified means the path exists in both with different content.
+	// With DiffOptions.CompareMode, a different Mode alone also counts.
+	ChangeModified
+)
+
+// DiffChange describes a single changed path reported by DiffStream.
+type DiffChange struct {
+	// Kind is whether the path was added, removed, or modified.
+	Kind ChangeKind
+
+	// Path is the full path, as reported by Walk.
+	Path string
+
+	// Old is the entry in the base snapshot (zero for ChangeAdded).
+	Old TreeEntry
+
+	// New is the entry in the newer snapshot (zero for ChangeRemoved).
+	New TreeEntry
+}
+
+// DiffOptions adjusts what DiffWithOptions and DiffStreamWithOptions count as
+// a change. The zero value gives the behavior of Diff and DiffStream, which
+// compare only the content of entries present in both snapshots.
+//
+// The options do not affect RootHash, Equal, or HasChangedSince, which tell
+// snapshots that differ only in mode apart. Diff does not detect renames: a
+// moved file is reported as removed at its old path and added at its new
+// one, whatever the options.
+type DiffOptions struct {
+	// CompareMode also reports a file or symlink whose Mode differs but whose
+	// content does not. By default Mode is ignored, so permission bits that
+	// differ, as between captures made under different umasks, are not
+	// reported. Directories are not reported either way, so a change to a
+	// directory's mode alone is never reported.
+	CompareMode bool
+}
+
 // SnapshotDiff represents the difference between two snapshots.
 type SnapshotDiff struct {
 	// Added contains paths that exist in New but not Old.
@@ -7,7 +7,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"os"
 
 	cxdb "github.com/strongdm/ai-cxdb/clients/go"
 )
@@ -57,7 +56,7 @@ func (s *Snapshot) Upload(ctx context.Context, client *cxdb.Client) (*UploadResu
 	// Upload all file blobs
 	for hash, ref := range s.Files {
 		// Read file content
-		content, err := readFile(ref.Path)
+		content, err := s.readContent(hash)
 		if err != nil {
 			return nil, fmt.Errorf("read file %s: %w", ref.Path, err)
 		}
@@ -97,14 +96,14 @@ func uploadBlob(ctx context.Context, client *cxdb.Client, hash [32]byte, data []
 	return wasNew, err
 }
 
-// readFile reads the entire contents of a file.
-func readFile(path string) ([]byte, error) {
-	f, err := os.Open(path)
+// readContent reads the entire content of the file with the given hash.
+func (s *Snapshot) readContent(hash [32]byte) ([]byte, error) {
+	r, err := s.GetFile(hash)
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = f.Close() }()
-	return io.ReadAll(f)
+	defer func() { _ = r.Close() }()
+	return io.ReadAll(r)
 }
 
 // UploadAndAttach captures a filesystem snapshot, uploads it, and attaches it to a turn.
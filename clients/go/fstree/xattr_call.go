@@ -0,0 +1,34 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build linux || darwin
+
+package fstree
+
+import (
+	"errors"
+	"syscall"
+)
+
+// xattrCall runs a size-probing xattr syscall, retrying if the value grows
+// between the probe and the read.
+func xattrCall(call func(buf []byte) (int, error)) ([]byte, error) {
+	for {
+		size, err := call(nil)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return []byte{}, nil
+		}
+		buf := make([]byte, size)
+		n, err := call(buf)
+		if errors.Is(err, syscall.ERANGE) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
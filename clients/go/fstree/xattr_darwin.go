@@ -0,0 +1,47 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build darwin
+
+package fstree
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// readXattrs returns the extended attributes of path, following symlinks.
+// Filesystems without xattr support yield no attributes and no error.
+func readXattrs(path string) (map[string][]byte, error) {
+	names, err := xattrCall(func(buf []byte) (int, error) {
+		return unix.Listxattr(path, buf)
+	})
+	if err != nil || len(names) == 0 {
+		if errors.Is(err, unix.ENOTSUP) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	attrs := make(map[string][]byte)
+	for _, name := range bytes.Split(bytes.TrimSuffix(names, []byte{0}), []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		attr := string(name)
+		value, err := xattrCall(func(buf []byte) (int, error) {
+			return unix.Getxattr(path, attr, buf)
+		})
+		if errors.Is(err, unix.ENOATTR) {
+			// Removed between list and get.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		attrs[attr] = value
+	}
+	return attrs, nil
+}
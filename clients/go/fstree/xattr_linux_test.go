@@ -0,0 +1,54 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build linux
+
+package fstree
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestCapture_Xattrs(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "file.txt")
+	_ = os.WriteFile(path, []byte("content"), 0644)
+	if err := syscall.Setxattr(path, "user.cxdb.test", []byte("value"), 0); err != nil {
+		t.Skipf("user xattrs not supported: %v", err)
+	}
+
+	plain, err := Capture(tmpDir)
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	snap, err := Capture(tmpDir, WithXattrs(true))
+	if err != nil {
+		t.Fatalf("Capture with xattrs failed: %v", err)
+	}
+
+	if plain.RootHash == snap.RootHash {
+		t.Error("expected xattrs to change the root hash")
+	}
+
+	entries, err := snap.GetRootEntries()
+	if err != nil {
+		t.Fatalf("GetRootEntries failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	if got := string(entries[0].Xattrs["user.cxdb.test"]); got != "value" {
+		t.Errorf("expected xattr value %q, got %q", "value", got)
+	}
+
+	plainEntries, err := plain.GetRootEntries()
+	if err != nil {
+		t.Fatalf("GetRootEntries failed: %v", err)
+	}
+	if plainEntries[0].Xattrs != nil {
+		t.Errorf("expected no xattrs without WithXattrs, got %v", plainEntries[0].Xattrs)
+	}
+}
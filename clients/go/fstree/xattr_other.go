@@ -0,0 +1,12 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !linux && !darwin
+
+package fstree
+
+// readXattrs reports no extended attributes on platforms where they are not
+// supported, so WithXattrs degrades to a no-op.
+func readXattrs(path string) (map[string][]byte, error) {
+	return nil, nil
+}
@@ -6,6 +6,7 @@ require (
 	github.com/google/uuid v1.6.0
 	github.com/vmihailenco/msgpack/v5 v5.4.1
 	github.com/zeebo/blake3 v0.2.4
+	golang.org/x/sys v0.30.0
 )
 
 require (
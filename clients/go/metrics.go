@@ -0,0 +1,97 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import "sync/atomic"
+
+// Metrics accumulates aggregate counters from SubscribeEvents and FollowTurns.
+//
+// A single Metrics value may be shared by several subscriptions and followers;
+// all updates are atomic. Pass it with WithMetricsSink or WithFollowMetricsSink
+// and read it with Snapshot, for example from an expvar.Func or a Prometheus
+// collector. The zero value is ready to use.
+type Metrics struct {
+	events       atomic.Uint64
+	reconnects   atomic.Uint64
+	decodeErrors atomic.Uint64
+	syncErrors   atomic.Uint64
+	turnsEmitted atomic.Uint64
+	gapsDetected atomic.Uint64
+}
+
+// MetricsSnapshot is a point-in-time copy of Metrics.
+type MetricsSnapshot struct {
+	// Events is the number of SSE events delivered to consumers.
+	Events uint64
+
+	// Reconnects is the number of SSE reconnect attempts after the first connection.
+	Reconnects uint64
+
+	// DecodeErrors is the number of FollowTurns input events (turn_appended,
+	// context_closed, context_created, context_metadata_updated) that failed
+	// to decode.
+	DecodeErrors uint64
+
+	// SyncErrors is the number of failed context syncs in FollowTurns.
+	SyncErrors uint64
+
+	// TurnsEmitted is the number of turns delivered by FollowTurns.
+	TurnsEmitted uint64
+
+	// GapsDetected is the number of depth gaps reported by FollowTurns.
+	GapsDetected uint64
+}
+
+// Snapshot returns the current counter values.
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	if m == nil {
+		return MetricsSnapshot{}
+	}
+	return MetricsSnapshot{
+		Events:       m.events.Load(),
+		Reconnects:   m.reconnects.Load(),
+		DecodeErrors: m.decodeErrors.Load(),
+		SyncErrors:   m.syncErrors.Load(),
+		TurnsEmitted: m.turnsEmitted.Load(),
+		GapsDetected: m.gapsDetected.Load(),
+	}
+}
+
+// The increment helpers tolerate a nil Metrics so call sites need no checks.
+
+func (m *Metrics) incEvents() {
+	if m != nil {
+		m.events.Add(1)
+	}
+}
+
+func (m *Metrics) incReconnects() {
+	if m != nil {
+		m.reconnects.Add(1)
+	}
+}
+
+func (m *Metrics) incDecodeErrors() {
+	if m != nil {
+		m.decodeErrors.Add(1)
+	}
+}
+
+func (m *Metrics) incSyncErrors() {
+	if m != nil {
+		m.syncErrors.Add(1)
+	}
+}
+
+func (m *Metrics) incTurnsEmitted() {
+	if m != nil {
+		m.turnsEmitted.Add(1)
+	}
+}
+
+func (m *Metrics) incGapsDetected() {
+	if m != nil {
+		m.gapsDetected.Add(1)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMetricsSubscribeCounters(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"ok\":true}\n\n"))
+	}))
+	defer srv.Close()
+
+	var m Metrics
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, _ := SubscribeEvents(ctx, srv.URL,
+		WithMetricsSink(&m),
+		WithSubscribeRetryDelay(5*time.Millisecond),
+		WithSubscribeMaxRetryDelay(5*time.Millisecond),
+	)
+
+	deadline := time.After(2 * time.Second)
+	for received := 0; received < 3; {
+		select {
+		case <-events:
+			received++
+		case <-deadline:
+			t.Fatal("timed out waiting for events")
+		}
+	}
+	cancel()
+
+	snap := m.Snapshot()
+	if snap.Events < 3 {
+		t.Fatalf("Events = %d, want at least 3", snap.Events)
+	}
+	if snap.Reconnects < 2 {
+		t.Fatalf("Reconnects = %d, want at least 2", snap.Reconnects)
+	}
+}
+
+func TestMetricsFollowCounters(t *testing.T) {
+	t.Parallel()
+
+	client := newStubTurnClient()
+	client.setContext(1, []TurnRecord{
+		{TurnID: 1, Depth: 0},
+		{TurnID: 2, Depth: 1},
+	})
+
+	events := make(chan Event, 4)
+	events <- makeTurnEvent(1, 2, 1)
+	events <- Event{Type: "turn_appended", Data: []byte(`{"turn_id":"1"}`)}
+	events <- makeTurnEvent(99, 1, 0)
+	close(events)
+
+	var m Metrics
+	out, errs := FollowTurns(context.Background(), events, client, WithFollowMetricsSink(&m))
+	for range out {
+	}
+	for range errs {
+	}
+
+	want := MetricsSnapshot{TurnsEmitted: 2, DecodeErrors: 1, SyncErrors: 1}
+	if got := m.Snapshot(); got != want {
+		t.Fatalf("unexpected metrics: got %+v want %+v", got, want)
+	}
+}
+
+func TestMetricsNilSnapshot(t *testing.T) {
+	t.Parallel()
+
+	var m *Metrics
+	m.incEvents()
+	if got := m.Snapshot(); got != (MetricsSnapshot{}) {
+		t.Fatalf("expected zero snapshot, got %+v", got)
+	}
+}
@@ -456,6 +456,17 @@ func (rc *ReconnectingClient) GetLast(ctx context.Context, contextID uint64, opt
 	return result, err
 }
 
+// GetTurn retrieves a single turn from a context by its ID.
+func (rc *ReconnectingClient) GetTurn(ctx context.Context, contextID, turnID uint64, opts GetTurnOptions) (TurnRecord, error) {
+	var result TurnRecord
+	err := rc.enqueue(ctx, "GetTurn", func(c *Client) error {
+		var opErr error
+		result, opErr = c.GetTurn(ctx, contextID, turnID, opts)
+		return opErr
+	})
+	return result, err
+}
+
 // AttachFs attaches a filesystem tree to a context.
 func (rc *ReconnectingClient) AttachFs(ctx context.Context, req *AttachFsRequest) (*AttachFsResult, error) {
 	var result *AttachFsResult
@@ -522,6 +533,11 @@ func isConnectionError(err error) bool {
 		return false
 	}
 
+	// Keep-alive closed the connection - a new one may work
+	if errors.Is(err, ErrKeepAliveFailed) {
+		return true
+	}
+
 	// Client already closed - not recoverable via reconnect
 	if errors.Is(err, ErrClientClosed) {
 		return false
@@ -0,0 +1,105 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const defaultRecordFlushInterval = time.Second
+
+// RecordEvents tees events to w as JSON lines, in the format read back by
+// ReplayEventsFromReader, while passing every event through on the returned
+// channel. Output is buffered and flushed periodically, when the input channel
+// closes, and when ctx is canceled.
+//
+// Write and encode errors are reported on the error channel but never cause
+// events to be dropped from the pass-through channel. After the first write
+// error the recorder stops writing and only forwards events.
+func RecordEvents(ctx context.Context, events <-chan Event, w io.Writer) (<-chan Event, <-chan error) {
+	out := make(chan Event, defaultEventBuffer)
+	errs := make(chan error, defaultErrorBuffer)
+
+	go func() {
+		defer close(out)
+		defer close(errs)
+
+		bw := bufio.NewWriter(w)
+		writing := true
+		flush := func() {
+			if !writing {
+				return
+			}
+			if err := bw.Flush(); err != nil {
+				writing = false
+				nonBlockingSend(errs, fmt.Errorf("cxdb record: flush: %w", err))
+			}
+		}
+		defer flush()
+
+		ticker := time.NewTicker(defaultRecordFlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				flush()
+			case ev, ok := <-events:
+				if !ok {
+					return
+				}
+				if writing {
+					if err := writeEventRecord(bw, ev); err != nil {
+						nonBlockingSend(errs, err)
+						var writeErr *recordWriteError
+						if errors.As(err, &writeErr) {
+							writing = false
+						}
+					}
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case out <- ev:
+				}
+			}
+		}
+	}()
+
+	return out, errs
+}
+
+// recordWriteError marks failures of the underlying writer, as opposed to
+// per-event encode failures.
+type recordWriteError struct {
+	err error
+}
+
+func (e *recordWriteError) Error() string {
+	return fmt.Sprintf("cxdb record: write: %v", e.err)
+}
+
+func (e *recordWriteError) Unwrap() error {
+	return e.err
+}
+
+func writeEventRecord(w *bufio.Writer, ev Event) error {
+	line, err := json.Marshal(eventRecord{Kind: eventRecordKind, Type: ev.Type, Data: ev.Data, ID: ev.ID})
+	if err != nil {
+		return fmt.Errorf("cxdb record: encode %s event: %w", ev.Type, err)
+	}
+	line = append(line, '\n')
+	if _, err := w.Write(line); err != nil {
+		return &recordWriteError{err: err}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRecordEventsRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	input := []Event{
+		{Type: "context_created", Data: json.RawMessage(`{"context_id":"1"}`)},
+		{Type: "turn_appended", Data: json.RawMessage(`{"context_id":"1","turn_id":"1","depth":0}`), ID: "42"},
+	}
+
+	events := make(chan Event, len(input))
+	for _, ev := range input {
+		events <- ev
+	}
+	close(events)
+
+	var buf bytes.Buffer
+	out, errs := RecordEvents(context.Background(), events, &buf)
+
+	var passed []Event
+	for ev := range out {
+		passed = append(passed, ev)
+	}
+	for err := range errs {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(passed, input) {
+		t.Fatalf("unexpected pass-through events: %#v", passed)
+	}
+
+	replayed, replayErrs := ReplayEventsFromReader(&buf)
+	var got []Event
+	for ev := range replayed {
+		got = append(got, ev)
+	}
+	for err := range replayErrs {
+		t.Fatalf("unexpected replay error: %v", err)
+	}
+	if !reflect.DeepEqual(got, input) {
+		t.Fatalf("unexpected replayed events: %#v", got)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestRecordEventsWriteErrorKeepsPassThrough(t *testing.T) {
+	t.Parallel()
+
+	events := make(chan Event, 3)
+	for i := 0; i < 3; i++ {
+		events <- Event{Type: "message", Data: json.RawMessage(bytes.Repeat([]byte("1"), 8192))}
+	}
+	close(events)
+
+	out, errs := RecordEvents(context.Background(), events, failingWriter{})
+
+	count := 0
+	for range out {
+		count++
+	}
+	if count != 3 {
+		t.Fatalf("expected 3 events passed through, got %d", count)
+	}
+
+	var gotErrs []error
+	for err := range errs {
+		gotErrs = append(gotErrs, err)
+	}
+	if len(gotErrs) != 1 {
+		t.Fatalf("expected a single write error, got %v", gotErrs)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// eventRecord is the JSON-lines shape written by cxdb-subscribe for SSE events.
+type eventRecord struct {
+	Kind string          `json:"kind"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+	ID   string          `json:"id,omitempty"`
+}
+
+const eventRecordKind = "event"
+
+// ReplayEventsFromReader reads a JSON-lines recording of SSE events (the output
+// format of cxdb-subscribe) and streams them as Events. Lines of other kinds,
+// such as decoded turns, are skipped. Malformed lines are reported on the error
+// channel and replay continues with the next line. Both channels are closed once
+// the reader is exhausted.
+func ReplayEventsFromReader(r io.Reader) (<-chan Event, <-chan error) {
+	events := make(chan Event, defaultEventBuffer)
+	errs := make(chan error, defaultErrorBuffer)
+
+	go func() {
+		defer close(events)
+		defer close(errs)
+
+		br := bufio.NewReader(r)
+		lineNo := 0
+		for {
+			line, err := br.ReadBytes('\n')
+			if err != nil && !errors.Is(err, io.EOF) {
+				nonBlockingSend(errs, fmt.Errorf("cxdb replay: read: %w", err))
+				return
+			}
+			if len(line) > 0 {
+				lineNo++
+				ev, ok, decodeErr := decodeEventRecord(line)
+				if decodeErr != nil {
+					nonBlockingSend(errs, fmt.Errorf("cxdb replay: line %d: %w", lineNo, decodeErr))
+				} else if ok {
+					events <- ev
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return events, errs
+}
+
+// decodeEventRecord parses a single recorded line. It returns ok=false for
+// blank lines and records that are not events.
+func decodeEventRecord(line []byte) (Event, bool, error) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return Event{}, false, nil
+	}
+
+	var rec eventRecord
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return Event{}, false, err
+	}
+	if rec.Kind != eventRecordKind {
+		return Event{}, false, nil
+	}
+	if rec.Type == "" {
+		return Event{}, false, errors.New("event record missing type")
+	}
+	return Event{Type: rec.Type, Data: rec.Data, ID: rec.ID}, true, nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReplayEventsFromReader(t *testing.T) {
+	t.Parallel()
+
+	input := `{"kind":"event","type":"context_created","data":{"context_id":"1"}}` + "\n" +
+		`not json` + "\n" +
+		"\n" +
+		`{"kind":"turn","context_id":1,"turn_id":1,"depth":0}` + "\n" +
+		`{"kind":"event","type":"turn_appended","data":{"context_id":"1","turn_id":"1","depth":0},"id":"7"}`
+
+	events, errs := ReplayEventsFromReader(strings.NewReader(input))
+
+	var got []Event
+	for ev := range events {
+		got = append(got, ev)
+	}
+	var gotErrs []error
+	for err := range errs {
+		gotErrs = append(gotErrs, err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 events, got %d: %#v", len(got), got)
+	}
+	if got[0].Type != "context_created" || string(got[0].Data) != `{"context_id":"1"}` {
+		t.Fatalf("unexpected first event: %#v", got[0])
+	}
+	if got[1].Type != "turn_appended" || got[1].ID != "7" {
+		t.Fatalf("unexpected second event: %#v", got[1])
+	}
+	if len(gotErrs) != 1 || !strings.Contains(gotErrs[0].Error(), "line 2") {
+		t.Fatalf("expected one error for line 2, got %v", gotErrs)
+	}
+}
+
+func TestReplayEventsDrivesFollowTurns(t *testing.T) {
+	t.Parallel()
+
+	client := newStubTurnClient()
+	client.setContext(1, []TurnRecord{
+		{TurnID: 1, Depth: 0},
+		{TurnID: 2, Depth: 1},
+	})
+
+	input := `{"kind":"event","type":"turn_appended","data":{"context_id":"1","turn_id":"2","parent_turn_id":"1","depth":1}}` + "\n"
+	events, _ := ReplayEventsFromReader(strings.NewReader(input))
+
+	out, errs := FollowTurns(context.Background(), events, client)
+
+	var got []uint64
+	for turn := range out {
+		got = append(got, turn.Turn.TurnID)
+	}
+	for err := range errs {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []uint64{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected turns: got %v want %v", got, want)
+	}
+}
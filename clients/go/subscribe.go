@@ -12,6 +12,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -20,24 +21,58 @@ type Event struct {
 	Type string
 	Data json.RawMessage
 	ID   string
+
+	// Partial is set when the stream ended before the event's terminating
+	// blank line, so Data may be truncated. Partial events are only delivered
+	// when WithPartialEvents(true) is set.
+	Partial bool
+
+	// ReceivedAt is when the client finished reading the event from the
+	// stream, taken from the client's wall clock. Like any time.Now value it
+	// carries a monotonic reading, so time.Since(ev.ReceivedAt) measures how
+	// long the event waited before being handled even if the wall clock is
+	// adjusted. It is not comparable with server-side timestamps across hosts
+	// unless their clocks are synchronized.
+	ReceivedAt time.Time
 }
 
 const (
-	defaultMaxEventBytes = 2 * 1024 * 1024
-	defaultEventBuffer   = 128
-	defaultErrorBuffer   = 8
-	defaultRetryDelay    = 500 * time.Millisecond
-	defaultMaxRetryDelay = 10 * time.Second
+	defaultMaxEventBytes  = 2 * 1024 * 1024
+	defaultReadBufferSize = 4096
+	defaultEventBuffer    = 128
+	defaultErrorBuffer    = 8
+	defaultRetryDelay     = 500 * time.Millisecond
+	defaultMaxRetryDelay  = 10 * time.Second
+	defaultMaxRedirects   = 10
 )
 
 type subscribeOptions struct {
-	client        *http.Client
-	headers       http.Header
-	maxEventBytes int
-	eventBuffer   int
-	errorBuffer   int
-	retryDelay    time.Duration
-	maxRetryDelay time.Duration
+	client           *http.Client
+	headers          http.Header
+	maxEventBytes    int
+	eventBuffer      int
+	errorBuffer      int
+	retryDelay       time.Duration
+	maxRetryDelay    time.Duration
+	metrics          *Metrics
+	emitPartial      bool
+	handshakeTimeout time.Duration
+	totalByteLimit   int64
+	traceHeaders     func(ctx context.Context) http.Header
+	emitTimeout      time.Duration
+	dedupeWindow     int
+	readBufferSize   int
+	retryableStatus  func(status int) bool
+	interceptor      func(Event) (Event, error)
+	maxRedirects     int
+	lifetime         time.Duration
+	gracefulEOF      bool
+	accept           *string
+	proxy            *string
+	retryBudget      time.Duration
+	forceNewConn     bool
+	maxEventRate     float64
+	clock            clock
 }
 
 // SubscribeOption configures SubscribeEvents behavior.
@@ -92,8 +127,305 @@ func WithSubscribeMaxRetryDelay(d time.Duration) SubscribeOption {
 	}
 }
 
+// WithMetricsSink records aggregate subscription counters (events delivered
+// and reconnect attempts) into m.
+func WithMetricsSink(m *Metrics) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.metrics = m
+	}
+}
+
+// WithPartialEvents controls what happens to an event that is cut off by the
+// connection closing before its terminating blank line. By default such
+// trailing events are discarded, as the SSE specification requires. When
+// enabled they are delivered with Event.Partial set so consumers can decide
+// whether the (possibly truncated) data is usable.
+func WithPartialEvents(emit bool) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.emitPartial = emit
+	}
+}
+
+// WithHandshakeTimeout bounds how long a connection attempt may take to
+// receive response headers. The deadline covers only the request and response
+// headers, not the long-lived stream that follows. When it elapses the attempt
+// fails with ErrHandshakeTimeout and the normal reconnect backoff applies.
+//
+// This is independent of any timeouts configured on a client passed with
+// WithHTTPClient; whichever fires first wins. Note that http.Client.Timeout
+// also bounds reading the body, so it is unsuitable for SSE streams, while
+// http.Transport.ResponseHeaderTimeout overlaps with this option.
+func WithHandshakeTimeout(d time.Duration) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.handshakeTimeout = d
+	}
+}
+
+// WithTraceHeaderFunc sets a function that supplies extra request headers,
+// such as a traceparent header, for each connection attempt. It is called
+// once per attempt with the subscription context, so every reconnect can carry
+// a fresh trace or span ID. Headers it returns are added alongside those from
+// WithHeaders; a header name already set by WithHeaders is left untouched.
+func WithTraceHeaderFunc(fn func(ctx context.Context) http.Header) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.traceHeaders = fn
+	}
+}
+
+// WithEmitTimeout bounds how long the stream parser waits for the consumer to
+// accept each event. By default it waits indefinitely, so a stalled consumer
+// stalls the stream. When the timeout elapses, the pending event is dropped,
+// ErrEmitTimeout is reported, and the connection goes through the normal
+// reconnect path. Events the server sends while the subscription reconnects
+// are lost unless the server replays them.
+func WithEmitTimeout(d time.Duration) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.emitTimeout = d
+	}
+}
+
+// WithReadBufferSize sets the size in bytes of the buffer used to read the
+// stream. The default is 4KB. A larger buffer reads long single-line events,
+// such as a large JSON payload on one data line, in fewer system calls. It
+// is independent of WithMaxEventBytes: lines longer than the buffer are still
+// read whole, and the event size cap applies the same either way. Values of
+// 0 or less select the default.
+func WithReadBufferSize(n int) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.readBufferSize = n
+	}
+}
+
+// withSubscribeClock replaces the clock used for reconnect backoff and emit
+// timeouts. It is intended for tests.
+func withSubscribeClock(c clock) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.clock = c
+	}
+}
+
+// WithTotalByteLimit caps the total event data received over the lifetime of
+// the subscription, across reconnects. Once an event would take the total past
+// n, that event is dropped, ErrByteLimitExceeded is reported, and both channels
+// are closed. Like WithMaxEventBytes, only event data bytes are counted; field
+// names, IDs, comments, and framing are not, so the limit is approximate with
+// respect to bytes on the wire. A value of 0 or less disables the limit.
+func WithTotalByteLimit(n int64) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.totalByteLimit = n
+	}
+}
+
+// WithDedupeWindow drops events whose ID matches one of the last n event IDs
+// delivered, so a boundary event redelivered after a reconnect reaches the
+// consumer only once. It applies to every event type and looks only at
+// Event.ID; events without an ID are always delivered, as are partial events,
+// which are also not remembered since a complete copy may follow. A value of 0
+// or less (the default) disables deduplication.
+func WithDedupeWindow(n int) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.dedupeWindow = n
+	}
+}
+
+// WithRetryableStatusFunc sets the policy for non-200 responses to a
+// connection attempt. When fn returns true for the status the subscription
+// reconnects with the normal backoff; when it returns false the
+// *HTTPStatusError is reported as the final error and both channels are
+// closed. By default every non-200 status is retried.
+func WithRetryableStatusFunc(fn func(status int) bool) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.retryableStatus = fn
+	}
+}
+
+// WithEventInterceptor sets fn to be called on every event just before it is
+// delivered, after partial-event and WithDedupeWindow filtering. The event fn
+// returns is delivered in place of the original, so fn can validate or enrich
+// events. If fn returns an error, the event is dropped and the error is
+// reported on the error channel; the subscription carries on. fn runs in the
+// subscription goroutine, which reads nothing more from the stream until it
+// returns, so it should be fast.
+func WithEventInterceptor(fn func(Event) (Event, error)) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.interceptor = fn
+	}
+}
+
+// WithMaxRedirects caps how many redirects a connection attempt follows
+// before failing with ErrTooManyRedirects. The default is 10; 0 or less
+// disables following, so a 3xx response is reported as an *HTTPStatusError.
+//
+// Every hop carries the headers from WithHeaders and WithTraceHeaderFunc, so
+// a header such as Last-Event-ID survives the redirect. Credentials
+// (Authorization, Cookie, and Proxy-Authorization) are only forwarded while
+// the redirect stays on the original host. A redirect back to a URL already
+// visited in the same attempt fails with ErrRedirectLoop. Any CheckRedirect
+// on a client passed with WithHTTPClient still runs after these checks.
+func WithMaxRedirects(n int) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.maxRedirects = n
+	}
+}
+
+// WithLifetime stops the subscription d after it starts, whether or not
+// events are arriving. When the lifetime elapses the current connection is
+// closed, ErrSubscriptionExpired is reported as the final error, and both
+// channels are closed. Canceling ctx first stops the subscription as usual,
+// without the error. A value of 0 or less (the default) sets no lifetime.
+func WithLifetime(d time.Duration) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.lifetime = d
+	}
+}
+
+// WithGracefulEOF treats a server closing the stream cleanly as the end of
+// the subscription: both channels are closed without an error and without
+// reconnecting. A close is clean when it falls between events, after the
+// blank line that ends the last one. A stream that ends in the middle of an
+// event is still reported as an error and reconnected, as it is by default,
+// where every close is.
+func WithGracefulEOF(enabled bool) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.gracefulEOF = enabled
+	}
+}
+
+// WithAccept sets the Accept header of every connection attempt, including
+// reconnects and redirected requests, for deployments that negotiate the
+// stream's media type. It takes precedence over an Accept header given with
+// WithHeaders or WithTraceHeaderFunc. An empty value is rejected: the
+// subscription reports an error and closes both channels without connecting.
+func WithAccept(mediaType string) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.accept = &mediaType
+	}
+}
+
+// WithProxy sends the SSE connection, and every reconnect, through the proxy
+// at proxyURL, which may use the http, https, or socks5 scheme. Hosts listed
+// in the NO_PROXY (or no_proxy) environment variable, read when the
+// subscription starts, and localhost and loopback addresses are connected to
+// directly, as with http.ProxyFromEnvironment. An https endpoint is reached
+// through a CONNECT tunnel.
+//
+// WithProxy takes precedence over any proxy configured on a client passed
+// with WithHTTPClient: the client's transport, which must be an
+// *http.Transport (nil means http.DefaultTransport), is cloned with the
+// proxy set, and its other settings are kept. An invalid proxyURL or a client
+// with another kind of transport is reported as an error and the
+// subscription closes both channels without connecting.
+func WithProxy(proxyURL string) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.proxy = &proxyURL
+	}
+}
+
+// WithRetryBudget caps the total time the subscription spends failing to
+// connect. The budget runs from the first connection attempt, or from the
+// moment a successful connection is lost, and counts both failed attempts and
+// the backoff between them; every attempt that receives a 200 response
+// resets it. A retry that would start after the budget has run out is not
+// made: ErrRetryBudgetExhausted is reported as the final error and both
+// channels are closed, so a subscription against an unreachable server gives
+// up within d. A value of 0 or less (the default) sets no budget.
+func WithRetryBudget(d time.Duration) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.retryBudget = d
+	}
+}
+
+// WithForceNewConnection makes every connection attempt, including each
+// reconnect, open a new TCP connection instead of reusing an idle keep-alive
+// one, so a load balancer can route the reconnect to a different node. It is
+// meant for balancers that pin a connection to a node that may stop
+// producing events.
+//
+// The client's transport, from WithHTTPClient or http.DefaultTransport, is
+// cloned with DisableKeepAlives set when it is an *http.Transport, leaving
+// the caller's transport untouched. Any other transport is used as is, and
+// its idle connections are closed before each reconnect instead; since the
+// transport is the caller's, that also closes idle connections it holds for
+// other requests.
+func WithForceNewConnection(enabled bool) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.forceNewConn = enabled
+	}
+}
+
+// WithMaxEventRate caps delivery at rps events per second, spacing events at
+// least 1/rps apart with no bursts. When events arrive faster, the
+// subscription goroutine waits before delivering each one and reads nothing
+// more from the stream meanwhile, so the backpressure reaches the server
+// through TCP flow control. The limit holds across reconnects and counts only
+// delivered events, not those dropped by partial-event or WithDedupeWindow
+// filtering.
+//
+// It protects a consumer from a flood, but a server that keeps sending faster
+// than rps will see its send buffers fill and may buffer events itself or
+// drop the connection; events lost that way are gone unless the server
+// replays them. A value of 0 or less (the default) sets no limit.
+func WithMaxEventRate(rps float64) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.maxEventRate = rps
+	}
+}
+
 // SubscribeEvents subscribes to a CXDB SSE endpoint and streams events until the context is canceled.
+// It is shorthand for NewSubscriber for callers that need only the channels.
 func SubscribeEvents(ctx context.Context, url string, opts ...SubscribeOption) (<-chan Event, <-chan error) {
+	s := NewSubscriber(ctx, url, opts...)
+	return s.Events(), s.Errors()
+}
+
+// Subscriber is a running SSE subscription started by NewSubscriber. Besides
+// the event and error channels it reports the subscription's reconnect state.
+// All methods are safe to call from any goroutine, during and after the
+// subscription.
+type Subscriber struct {
+	events     chan Event
+	errs       chan error
+	reconnects atomic.Int64
+	lastErr    atomic.Pointer[error]
+}
+
+// Events returns the channel on which events are delivered. It is closed
+// when the subscription stops.
+func (s *Subscriber) Events() <-chan Event {
+	return s.events
+}
+
+// Errors returns the channel on which errors are reported. It is closed when
+// the subscription stops.
+func (s *Subscriber) Errors() <-chan error {
+	return s.errs
+}
+
+// ReconnectCount returns the number of times the subscription has
+// reconnected after its first connection attempt.
+func (s *Subscriber) ReconnectCount() int {
+	return int(s.reconnects.Load())
+}
+
+// LastError returns the most recent error reported by the subscription, or
+// nil if there has been none. It is updated even when the error channel is
+// full and the error itself is dropped.
+func (s *Subscriber) LastError() error {
+	if err := s.lastErr.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+// report records err as the last error and sends it without blocking.
+func (s *Subscriber) report(err error) {
+	s.lastErr.Store(&err)
+	nonBlockingSend(s.errs, err)
+}
+
+// NewSubscriber subscribes to a CXDB SSE endpoint like SubscribeEvents and
+// returns a handle for the subscription. It accepts the same options.
+func NewSubscriber(ctx context.Context, url string, opts ...SubscribeOption) *Subscriber {
 	options := subscribeOptions{
 		client:        http.DefaultClient,
 		maxEventBytes: defaultMaxEventBytes,
@@ -101,35 +433,98 @@ func SubscribeEvents(ctx context.Context, url string, opts ...SubscribeOption) (
 		errorBuffer:   defaultErrorBuffer,
 		retryDelay:    defaultRetryDelay,
 		maxRetryDelay: defaultMaxRetryDelay,
+		maxRedirects:  defaultMaxRedirects,
+		clock:         realClock{},
 	}
 	for _, opt := range opts {
 		opt(&options)
 	}
 
-	events := make(chan Event, options.eventBuffer)
-	errs := make(chan error, options.errorBuffer)
+	var err error
+	switch {
+	case strings.TrimSpace(url) == "":
+		err = fmt.Errorf("cxdb subscribe: url is required")
+	case options.accept != nil && strings.TrimSpace(*options.accept) == "":
+		err = fmt.Errorf("cxdb subscribe: accept media type is empty")
+	case options.proxy != nil:
+		options.client, err = proxyClient(options.client, *options.proxy, envNoProxy())
+	}
+	if err != nil {
+		// The error channel gets room for the error even with
+		// WithErrorBuffer(0), so it is delivered without blocking the caller.
+		s := &Subscriber{
+			events: make(chan Event),
+			errs:   make(chan error, 1),
+		}
+		s.report(err)
+		close(s.events)
+		close(s.errs)
+		return s
+	}
 
-	if strings.TrimSpace(url) == "" {
-		err := fmt.Errorf("cxdb subscribe: url is required")
-		errs <- err
-		close(events)
-		close(errs)
-		return events, errs
+	s := &Subscriber{
+		events: make(chan Event, options.eventBuffer),
+		errs:   make(chan error, options.errorBuffer),
+	}
+	events, errs := s.events, s.errs
+	closeIdle := false
+	if options.forceNewConn {
+		options.client, closeIdle = freshConnClient(options.client)
 	}
+	options.client = redirectClient(options.client, options.maxRedirects)
 
 	go func() {
 		defer close(events)
 		defer close(errs)
 
+		if options.lifetime > 0 {
+			var cancel context.CancelCauseFunc
+			ctx, cancel = context.WithCancelCause(ctx)
+			defer cancel(nil)
+			timer := options.clock.NewTimer(options.lifetime)
+			defer timer.Stop()
+			go func() {
+				select {
+				case <-timer.C():
+					cancel(ErrSubscriptionExpired)
+				case <-ctx.Done():
+				}
+			}()
+			defer func() {
+				if errors.Is(context.Cause(ctx), ErrSubscriptionExpired) {
+					s.report(fmt.Errorf("cxdb subscribe: %w after %s", ErrSubscriptionExpired, options.lifetime))
+				}
+			}()
+		}
+
 		retryDelay := options.retryDelay
+		failingSince := options.clock.Now()
+		state := &subscribeState{}
+		if options.dedupeWindow > 0 {
+			state.seenIDs = newIDWindow(options.dedupeWindow)
+		}
 		for {
 			if ctx.Err() != nil {
 				return
 			}
 
-			err := subscribeOnce(ctx, url, options, events)
-			if err != nil && !errors.Is(err, context.Canceled) {
-				nonBlockingSend(errs, err)
+			err := subscribeOnce(ctx, url, options, events, s.report, state)
+			if state.connected {
+				state.connected = false
+				failingSince = options.clock.Now()
+			}
+			if errors.Is(err, errStreamEnded) {
+				return
+			}
+			if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, ErrSubscriptionExpired) {
+				s.report(err)
+			}
+			if errors.Is(err, ErrByteLimitExceeded) {
+				return
+			}
+			var statusErr *HTTPStatusError
+			if errors.As(err, &statusErr) && options.retryableStatus != nil && !options.retryableStatus(statusErr.StatusCode) {
+				return
 			}
 
 			if ctx.Err() != nil {
@@ -142,24 +537,113 @@ func SubscribeEvents(ctx context.Context, url string, opts ...SubscribeOption) (
 			if options.maxRetryDelay > 0 && retryDelay > options.maxRetryDelay {
 				retryDelay = options.maxRetryDelay
 			}
+			if options.retryBudget > 0 && options.clock.Now().Sub(failingSince)+retryDelay > options.retryBudget {
+				s.report(fmt.Errorf("cxdb subscribe: %w after %s", ErrRetryBudgetExhausted, options.retryBudget))
+				return
+			}
 
-			timer := time.NewTimer(retryDelay)
+			timer := options.clock.NewTimer(retryDelay)
 			select {
 			case <-ctx.Done():
 				timer.Stop()
 				return
-			case <-timer.C:
+			case <-timer.C():
 			}
 
 			retryDelay = nextRetryDelay(retryDelay, options.maxRetryDelay)
+			if closeIdle {
+				options.client.CloseIdleConnections()
+			}
+			options.metrics.incReconnects()
+			s.reconnects.Add(1)
 		}
 	}()
 
-	return events, errs
+	return s
+}
+
+// credentialHeaders are dropped from a redirect that leaves the original
+// host.
+var credentialHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+// redirectClient returns a shallow copy of client whose CheckRedirect applies
+// the WithMaxRedirects policy before deferring to client's own.
+func redirectClient(client *http.Client, max int) *http.Client {
+	c := *client
+	next := client.CheckRedirect
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if max <= 0 {
+			return http.ErrUseLastResponse
+		}
+		if len(via) > max {
+			return fmt.Errorf("cxdb subscribe: %w (%d)", ErrTooManyRedirects, max)
+		}
+		for _, prev := range via {
+			if prev.URL.String() == req.URL.String() {
+				return fmt.Errorf("cxdb subscribe: %w at %s", ErrRedirectLoop, req.URL.Redacted())
+			}
+		}
+
+		first := via[0]
+		for key, values := range first.Header {
+			req.Header[key] = append([]string(nil), values...)
+		}
+		if req.URL.Host != first.URL.Host {
+			for _, key := range credentialHeaders {
+				req.Header.Del(key)
+			}
+		}
+
+		if next != nil {
+			return next(req, via)
+		}
+		return nil
+	}
+	return &c
 }
 
-func subscribeOnce(ctx context.Context, url string, options subscribeOptions, events chan<- Event) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// freshConnClient returns a copy of client that does not reuse connections,
+// for WithForceNewConnection. If client's transport is not an
+// *http.Transport it is returned as is, and the second result is true: the
+// caller must close idle connections before each attempt itself.
+func freshConnClient(client *http.Client) (*http.Client, bool) {
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	transport, ok := base.(*http.Transport)
+	if !ok {
+		return client, true
+	}
+	transport = transport.Clone()
+	transport.DisableKeepAlives = true
+
+	c := *client
+	c.Transport = transport
+	return &c, false
+}
+
+// subscribeState is carried across the connection attempts of one
+// subscription.
+type subscribeState struct {
+	received int64     // event data bytes, for WithTotalByteLimit
+	seenIDs  *idWindow // recently delivered IDs, nil without WithDedupeWindow
+
+	// connected is set when an attempt receives a 200 response, for
+	// WithRetryBudget.
+	connected bool
+
+	// nextEmit is the earliest time the next event may be delivered, for
+	// WithMaxEventRate.
+	nextEmit time.Time
+}
+
+// subscribeOnce runs a single connection attempt.
+func subscribeOnce(ctx context.Context, url string, options subscribeOptions, events chan<- Event, report func(error), state *subscribeState) error {
+	reqCtx, cancelReq := context.WithCancel(ctx)
+	defer cancelReq()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, url, nil)
 	if err != nil {
 		return fmt.Errorf("cxdb subscribe: build request: %w", err)
 	}
@@ -168,8 +652,35 @@ func subscribeOnce(ctx context.Context, url string, options subscribeOptions, ev
 			req.Header.Add(key, v)
 		}
 	}
+	if options.traceHeaders != nil {
+		for key, values := range options.traceHeaders(ctx) {
+			if len(req.Header.Values(key)) > 0 {
+				continue
+			}
+			for _, v := range values {
+				req.Header.Add(key, v)
+			}
+		}
+	}
+	if options.accept != nil {
+		req.Header.Set("Accept", *options.accept)
+	}
 
+	var handshakeTimer *time.Timer
+	var timedOut atomic.Bool
+	if options.handshakeTimeout > 0 {
+		handshakeTimer = time.AfterFunc(options.handshakeTimeout, func() {
+			timedOut.Store(true)
+			cancelReq()
+		})
+	}
 	resp, err := options.client.Do(req)
+	if handshakeTimer != nil && !handshakeTimer.Stop() && timedOut.Load() {
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		return fmt.Errorf("cxdb subscribe: %w after %s", ErrHandshakeTimeout, options.handshakeTimeout)
+	}
 	if err != nil {
 		return fmt.Errorf("cxdb subscribe: request failed: %w", err)
 	}
@@ -179,40 +690,148 @@ func subscribeOnce(ctx context.Context, url string, options subscribeOptions, ev
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		return fmt.Errorf("cxdb subscribe: unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return &HTTPStatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
 	}
+	state.connected = true
 
-	err = readEventStream(ctx, resp.Body, options.maxEventBytes, func(ev Event) error {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case events <- ev:
+	err = readEventStream(ctx, resp.Body, options.maxEventBytes, options.readBufferSize, options.clock, func(ev Event) error {
+		if options.totalByteLimit > 0 {
+			state.received += int64(len(ev.Data))
+			if state.received > options.totalByteLimit {
+				return fmt.Errorf("cxdb subscribe: %w (%d bytes)", ErrByteLimitExceeded, options.totalByteLimit)
+			}
+		}
+		if ev.Partial && !options.emitPartial {
+			return nil
+		}
+		id := ev.ID
+		remember := state.seenIDs != nil && id != "" && !ev.Partial
+		if remember && state.seenIDs.contains(id) {
 			return nil
 		}
+		if options.interceptor != nil {
+			intercepted, err := options.interceptor(ev)
+			if err != nil {
+				report(fmt.Errorf("cxdb subscribe: event interceptor: %w", err))
+				return nil
+			}
+			ev = intercepted
+		}
+		if options.maxEventRate > 0 {
+			if err := waitForRate(ctx, options, state); err != nil {
+				return err
+			}
+		}
+		var err error
+		if options.emitTimeout > 0 {
+			err = emitWithTimeout(ctx, events, ev, options)
+		} else {
+			select {
+			case <-ctx.Done():
+				err = ctx.Err()
+			case events <- ev:
+				options.metrics.incEvents()
+			}
+		}
+		if err == nil && remember {
+			state.seenIDs.add(id)
+		}
+		return err
 	})
 	if err == nil || errors.Is(err, context.Canceled) {
 		return err
 	}
 	if errors.Is(err, io.EOF) {
+		if options.gracefulEOF && !errors.Is(err, errTruncatedEOF) {
+			return errStreamEnded
+		}
 		return fmt.Errorf("cxdb subscribe: stream closed")
 	}
 	return err
 }
 
-func readEventStream(ctx context.Context, reader io.Reader, maxEventBytes int, emit func(Event) error) error {
-	br := bufio.NewReader(reader)
+var (
+	// errTruncatedEOF is returned by readEventStream when the stream ends in
+	// the middle of an event. It wraps io.EOF.
+	errTruncatedEOF = fmt.Errorf("%w: stream ended mid-event", io.EOF)
+
+	// errStreamEnded is returned by subscribeOnce when the server closed the
+	// stream cleanly and WithGracefulEOF is set.
+	errStreamEnded = errors.New("cxdb subscribe: stream ended")
+)
+
+// waitForRate blocks until WithMaxEventRate allows the next event and
+// reserves its slot.
+func waitForRate(ctx context.Context, options subscribeOptions, state *subscribeState) error {
+	interval := time.Duration(float64(time.Second) / options.maxEventRate)
+	now := options.clock.Now()
+	if wait := state.nextEmit.Sub(now); wait > 0 {
+		timer := options.clock.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C():
+		}
+		now = state.nextEmit
+	}
+	state.nextEmit = now.Add(interval)
+	return nil
+}
+
+// emitWithTimeout delivers ev, failing with ErrEmitTimeout if the consumer
+// does not accept it within options.emitTimeout.
+func emitWithTimeout(ctx context.Context, events chan<- Event, ev Event, options subscribeOptions) error {
+	select {
+	case events <- ev:
+		options.metrics.incEvents()
+		return nil
+	default:
+	}
+
+	timer := options.clock.NewTimer(options.emitTimeout)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case events <- ev:
+		options.metrics.incEvents()
+		return nil
+	case <-timer.C():
+		return fmt.Errorf("cxdb subscribe: %w after %s", ErrEmitTimeout, options.emitTimeout)
+	}
+}
+
+// readEventStream parses an SSE stream and calls emit for every event, stamped
+// with clk.Now() as its ReceivedAt. An event still being assembled when the
+// stream ends is emitted with Partial set. When the stream ends it returns
+// io.EOF, or errTruncatedEOF if it ended in the middle of an event.
+//
+// As the SSE spec requires, the values of an event's data lines are joined
+// with "\n", with no trailing newline, and empty data lines count: "data:"
+// followed by "data: x" yields "\nx", and two empty data lines yield "\n". An
+// event whose data is empty, including one with a single empty data line, is
+// not emitted, since an empty payload is never valid JSON.
+func readEventStream(ctx context.Context, reader io.Reader, maxEventBytes, bufSize int, clk clock, emit func(Event) error) error {
+	if bufSize <= 0 {
+		bufSize = defaultReadBufferSize
+	}
+	br := bufio.NewReaderSize(reader, bufSize)
 
 	reset := func() (string, []string, string, int) {
 		return "", nil, "", 0
 	}
 
 	eventType, dataLines, lastID, dataSize := reset()
-	flush := func() error {
+	lineNum := 0
+	flush := func(partial bool) error {
 		if len(dataLines) == 0 && eventType == "" && lastID == "" {
 			eventType, dataLines, lastID, dataSize = reset()
 			return nil
 		}
 
+		// The spec builds the data as value+"\n" per line and then drops the
+		// final "\n", which is the same as joining on "\n".
 		data := strings.Join(dataLines, "\n")
 		if data == "" {
 			eventType, dataLines, lastID, dataSize = reset()
@@ -224,15 +843,30 @@ func readEventStream(ctx context.Context, reader io.Reader, maxEventBytes int, e
 		}
 
 		event := Event{
-			Type: eventType,
-			Data: json.RawMessage(data),
-			ID:   lastID,
+			Type:       eventType,
+			Data:       json.RawMessage(data),
+			ID:         lastID,
+			Partial:    partial,
+			ReceivedAt: clk.Now(),
 		}
 		err := emit(event)
 		eventType, dataLines, lastID, dataSize = reset()
 		return err
 	}
 
+	// endOfStream flushes any event still being assembled as partial and
+	// reports whether the stream ended cleanly between events.
+	endOfStream := func() error {
+		truncated := len(dataLines) > 0 || eventType != "" || lastID != ""
+		if flushErr := flush(true); flushErr != nil {
+			return flushErr
+		}
+		if truncated {
+			return errTruncatedEOF
+		}
+		return io.EOF
+	}
+
 	for {
 		if ctx.Err() != nil {
 			return ctx.Err()
@@ -242,15 +876,18 @@ func readEventStream(ctx context.Context, reader io.Reader, maxEventBytes int, e
 		if err != nil && !errors.Is(err, io.EOF) {
 			return err
 		}
+		if len(line) > 0 {
+			lineNum++
+		}
 
 		if len(line) == 0 && errors.Is(err, io.EOF) {
-			return io.EOF
+			return endOfStream()
 		}
 
 		line = strings.TrimRight(line, "\r\n")
 
 		if line == "" {
-			if flushErr := flush(); flushErr != nil {
+			if flushErr := flush(false); flushErr != nil {
 				return flushErr
 			}
 			if errors.Is(err, io.EOF) {
@@ -261,7 +898,7 @@ func readEventStream(ctx context.Context, reader io.Reader, maxEventBytes int, e
 
 		if strings.HasPrefix(line, ":") {
 			if errors.Is(err, io.EOF) {
-				return io.EOF
+				return endOfStream()
 			}
 			continue
 		}
@@ -272,7 +909,7 @@ func readEventStream(ctx context.Context, reader io.Reader, maxEventBytes int, e
 			value = ""
 		}
 		if field == "" || strings.ContainsAny(field, " \t") {
-			return fmt.Errorf("cxdb subscribe: malformed field %q", field)
+			return &StreamParseError{Line: lineNum, Field: field, Raw: line}
 		}
 		value = strings.TrimPrefix(value, " ")
 
@@ -280,6 +917,11 @@ func readEventStream(ctx context.Context, reader io.Reader, maxEventBytes int, e
 		case "event":
 			eventType = value
 		case "data":
+			// Count the "\n" joining this line to the previous one, so empty
+			// data lines cannot grow an event past maxEventBytes.
+			if len(dataLines) > 0 {
+				dataSize++
+			}
 			dataLines = append(dataLines, value)
 			dataSize += len(value)
 			if maxEventBytes > 0 && dataSize > maxEventBytes {
@@ -292,14 +934,48 @@ func readEventStream(ctx context.Context, reader io.Reader, maxEventBytes int, e
 		}
 
 		if errors.Is(err, io.EOF) {
-			if flushErr := flush(); flushErr != nil {
+			if flushErr := flush(true); flushErr != nil {
 				return flushErr
 			}
-			return io.EOF
+			return errTruncatedEOF
 		}
 	}
 }
 
+// idWindow remembers the most recent n distinct IDs added to it.
+type idWindow struct {
+	ids  []string // ring buffer, oldest at next once full
+	next int
+	set  map[string]struct{}
+}
+
+func newIDWindow(n int) *idWindow {
+	return &idWindow{
+		ids: make([]string, 0, n),
+		set: make(map[string]struct{}, n),
+	}
+}
+
+func (w *idWindow) contains(id string) bool {
+	_, ok := w.set[id]
+	return ok
+}
+
+// add records id, forgetting the oldest ID once the window is full.
+func (w *idWindow) add(id string) {
+	if w.contains(id) {
+		return
+	}
+	if len(w.ids) < cap(w.ids) {
+		w.ids = append(w.ids, id)
+	} else {
+		delete(w.set, w.ids[w.next])
+		w.ids[w.next] = id
+		w.next = (w.next + 1) % len(w.ids)
+	}
+	w.set[id] = struct{}{}
+}
+
 func nextRetryDelay(current, max time.Duration) time.Duration {
 	if current <= 0 {
 		return defaultRetryDelay
@@ -0,0 +1,154 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// proxyClient returns a shallow copy of client whose transport sends requests
+// through proxyURL, except for hosts matched by noProxy. client's transport
+// must be an *http.Transport, or nil for http.DefaultTransport; it is cloned,
+// so the caller's transport is left untouched.
+func proxyClient(client *http.Client, proxyURL, noProxy string) (*http.Client, error) {
+	proxy, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("cxdb subscribe: invalid proxy url: %w", err)
+	}
+	switch proxy.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("cxdb subscribe: unsupported proxy scheme %q", proxy.Scheme)
+	}
+	if proxy.Host == "" {
+		return nil, fmt.Errorf("cxdb subscribe: proxy url has no host: %s", proxy.Redacted())
+	}
+
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	transport, ok := base.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("cxdb subscribe: proxy requires an *http.Transport, got %T", base)
+	}
+	transport = transport.Clone()
+
+	bypass := parseNoProxy(noProxy)
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		if bypass(req.URL) {
+			return nil, nil
+		}
+		return proxy, nil
+	}
+
+	c := *client
+	c.Transport = transport
+	return &c, nil
+}
+
+// envNoProxy returns the NO_PROXY environment variable, falling back to
+// no_proxy.
+func envNoProxy() string {
+	if v := os.Getenv("NO_PROXY"); v != "" {
+		return v
+	}
+	return os.Getenv("no_proxy")
+}
+
+// parseNoProxy returns a function reporting whether a request to u should
+// bypass the proxy, following the NO_PROXY conventions of net/http: a
+// comma-separated list of host names, which also match their subdomains,
+// domain suffixes with a leading dot, which match only subdomains, IP
+// addresses, and CIDR ranges, each optionally with a port, or "*" for every
+// host. Requests to localhost and loopback addresses always bypass the
+// proxy.
+func parseNoProxy(list string) func(u *url.URL) bool {
+	type rule struct {
+		domain  string // lower case, without a leading dot
+		subOnly bool   // the entry had a leading dot: match subdomains only
+		ip      net.IP
+		cidr    *net.IPNet
+		port    string
+	}
+
+	var rules []rule
+	all := false
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			all = true
+			continue
+		}
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			rules = append(rules, rule{cidr: cidr})
+			continue
+		}
+
+		var r rule
+		if host, port, err := net.SplitHostPort(entry); err == nil {
+			entry, r.port = host, port
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			r.ip = ip
+		} else {
+			entry = strings.TrimPrefix(entry, "*")
+			r.subOnly = strings.HasPrefix(entry, ".")
+			r.domain = strings.TrimPrefix(entry, ".")
+		}
+		rules = append(rules, r)
+	}
+
+	return func(u *url.URL) bool {
+		if all {
+			return true
+		}
+		host := strings.ToLower(u.Hostname())
+		port := u.Port()
+		if port == "" {
+			switch u.Scheme {
+			case "https":
+				port = "443"
+			default:
+				port = "80"
+			}
+		}
+		if host == "localhost" {
+			return true
+		}
+		ip := net.ParseIP(host)
+		if ip != nil && ip.IsLoopback() {
+			return true
+		}
+
+		for _, r := range rules {
+			if r.port != "" && r.port != port {
+				continue
+			}
+			switch {
+			case r.cidr != nil:
+				if ip != nil && r.cidr.Contains(ip) {
+					return true
+				}
+			case r.ip != nil:
+				if ip != nil && r.ip.Equal(ip) {
+					return true
+				}
+			case r.domain != "" && strings.HasSuffix(host, "."+r.domain):
+				return true
+			case r.domain != "" && !r.subOnly && host == r.domain:
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -7,9 +7,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
 	"strings"
 	"sync/atomic"
 	"testing"
@@ -24,7 +28,7 @@ func TestReadEventStreamMultiLine(t *testing.T) {
 		"data: {\"b\":2}\n\n"
 
 	var events []Event
-	err := readEventStream(context.Background(), strings.NewReader(input), 1024, func(ev Event) error {
+	err := readEventStream(context.Background(), strings.NewReader(input), 1024, 0, realClock{}, func(ev Event) error {
 		events = append(events, ev)
 		return nil
 	})
@@ -43,6 +47,54 @@ func TestReadEventStreamMultiLine(t *testing.T) {
 	}
 }
 
+func TestReadEventStreamDataLineSpec(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"empty line then value", "data:\ndata: x\n\n", []string{"\nx"}},
+		{"value then empty line", "data: x\ndata:\n\n", []string{"x\n"}},
+		{"two empty lines", "data:\ndata\n\n", []string{"\n"}},
+		{"single empty line dropped", "data:\n\ndata: 1\n\n", []string{"1"}},
+		{"no space after colon", "data:1\ndata:  2\n\n", []string{"1\n 2"}},
+		{"crlf line endings", "data: 1\r\ndata:\r\n\r\n", []string{"1\n"}},
+		{"trailing newline kept at end of stream", "data: 1\ndata:\n", []string{"1\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []string
+			err := readEventStream(context.Background(), strings.NewReader(tt.input), 1024, 0, realClock{}, func(ev Event) error {
+				got = append(got, string(ev.Data))
+				return nil
+			})
+			if !errors.Is(err, io.EOF) {
+				t.Fatalf("expected EOF, got %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %q want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadEventStreamEmptyDataLinesCountTowardMax(t *testing.T) {
+	t.Parallel()
+
+	input := "data: x\n" + strings.Repeat("data:\n", 20) + "\n"
+	err := readEventStream(context.Background(), strings.NewReader(input), 10, 0, realClock{}, func(ev Event) error {
+		return nil
+	})
+	if err == nil || errors.Is(err, io.EOF) {
+		t.Fatalf("expected oversize error, got %v", err)
+	}
+}
+
 func TestReadEventStreamDefaultTypeAndComments(t *testing.T) {
 	t.Parallel()
 
@@ -50,7 +102,7 @@ func TestReadEventStreamDefaultTypeAndComments(t *testing.T) {
 		"data: {\"ok\":true}\n\n"
 
 	var events []Event
-	err := readEventStream(context.Background(), strings.NewReader(input), 1024, func(ev Event) error {
+	err := readEventStream(context.Background(), strings.NewReader(input), 1024, 0, realClock{}, func(ev Event) error {
 		events = append(events, ev)
 		return nil
 	})
@@ -68,13 +120,39 @@ func TestReadEventStreamDefaultTypeAndComments(t *testing.T) {
 	}
 }
 
+func TestReadEventStreamReceivedAt(t *testing.T) {
+	t.Parallel()
+
+	clk := newFakeClock()
+	start := clk.Now()
+	input := "data: 1\n\ndata: 2\n\ndata: 3"
+
+	var events []Event
+	err := readEventStream(context.Background(), strings.NewReader(input), 1024, 0, clk, func(ev Event) error {
+		events = append(events, ev)
+		clk.Advance(time.Second)
+		return nil
+	})
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	for i, ev := range events {
+		if want := start.Add(time.Duration(i) * time.Second); !ev.ReceivedAt.Equal(want) {
+			t.Fatalf("event %d ReceivedAt = %v, want %v", i, ev.ReceivedAt, want)
+		}
+	}
+}
+
 func TestReadEventStreamOversize(t *testing.T) {
 	t.Parallel()
 
 	input := "event: big\n" +
 		"data: " + strings.Repeat("x", 20) + "\n\n"
 
-	err := readEventStream(context.Background(), strings.NewReader(input), 10, func(ev Event) error {
+	err := readEventStream(context.Background(), strings.NewReader(input), 10, 0, realClock{}, func(ev Event) error {
 		return nil
 	})
 	if err == nil {
@@ -82,16 +160,126 @@ func TestReadEventStreamOversize(t *testing.T) {
 	}
 }
 
+func TestReadEventStreamBufferSizeIndependentOfEventCap(t *testing.T) {
+	t.Parallel()
+
+	payload := `"` + strings.Repeat("y", 100) + `"`
+	input := "data: " + payload + "\n\n"
+
+	for _, bufSize := range []int{16, 1 << 16} {
+		var events []Event
+		err := readEventStream(context.Background(), strings.NewReader(input), 1024, bufSize, realClock{}, func(ev Event) error {
+			events = append(events, ev)
+			return nil
+		})
+		if !errors.Is(err, io.EOF) {
+			t.Fatalf("bufSize=%d: expected EOF, got %v", bufSize, err)
+		}
+		if len(events) != 1 || string(events[0].Data) != payload {
+			t.Fatalf("bufSize=%d: unexpected events %v", bufSize, events)
+		}
+
+		err = readEventStream(context.Background(), strings.NewReader(input), 50, bufSize, realClock{}, func(ev Event) error {
+			return nil
+		})
+		if err == nil || errors.Is(err, io.EOF) {
+			t.Fatalf("bufSize=%d: expected oversize error, got %v", bufSize, err)
+		}
+	}
+}
+
 func TestReadEventStreamMalformedField(t *testing.T) {
 	t.Parallel()
 
-	input := "bad field\n\n"
-	err := readEventStream(context.Background(), strings.NewReader(input), 1024, func(ev Event) error {
+	input := "event: ok\ndata: {}\n\nbad field: x\n\n"
+	err := readEventStream(context.Background(), strings.NewReader(input), 1024, 0, realClock{}, func(ev Event) error {
 		return nil
 	})
 	if err == nil {
 		t.Fatal("expected error for malformed field")
 	}
+	var parseErr *StreamParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected *StreamParseError, got %T: %v", err, err)
+	}
+	if parseErr.Line != 4 || parseErr.Field != "bad field" || parseErr.Raw != "bad field: x" {
+		t.Fatalf("unexpected parse error fields: %+v", parseErr)
+	}
+}
+
+func TestReadEventStreamPartialTrailingEvent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"mid line", "event: a\ndata: {\"x\":1}\n\nevent: b\ndata: {\"trunc"},
+		{"missing terminator", "event: a\ndata: {\"x\":1}\n\nevent: b\ndata: {\"y\":2}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var events []Event
+			err := readEventStream(context.Background(), strings.NewReader(tt.input), 1024, 0, realClock{}, func(ev Event) error {
+				events = append(events, ev)
+				return nil
+			})
+			if !errors.Is(err, io.EOF) {
+				t.Fatalf("expected EOF, got %v", err)
+			}
+			if len(events) != 2 {
+				t.Fatalf("expected 2 events, got %d", len(events))
+			}
+			if events[0].Partial {
+				t.Fatal("expected first event to be complete")
+			}
+			if !events[1].Partial || events[1].Type != "b" {
+				t.Fatalf("expected partial event b, got %#v", events[1])
+			}
+		})
+	}
+}
+
+func TestSubscribeEventsPartialEvents(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("event: a\ndata: {\"x\":1}\n\nevent: b\ndata: {\"trunc"))
+	}))
+	defer srv.Close()
+
+	receive := func(t *testing.T, opts ...SubscribeOption) []Event {
+		t.Helper()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		opts = append(opts, WithSubscribeRetryDelay(5*time.Millisecond))
+		events, _ := SubscribeEvents(ctx, srv.URL, opts...)
+		var got []Event
+		for len(got) < 2 {
+			select {
+			case ev := <-events:
+				got = append(got, ev)
+			case <-time.After(2 * time.Second):
+				t.Fatalf("timed out waiting for events, got %d", len(got))
+			}
+		}
+		return got
+	}
+
+	got := receive(t)
+	if got[0].Type != "a" || got[1].Type != "a" {
+		t.Fatalf("expected partial event to be discarded, got %#v", got)
+	}
+
+	got = receive(t, WithPartialEvents(true))
+	if got[1].Type != "b" || !got[1].Partial {
+		t.Fatalf("expected partial event b, got %#v", got[1])
+	}
 }
 
 func TestSubscribeEventsReconnect(t *testing.T) {
@@ -150,55 +338,1175 @@ func TestSubscribeEventsReconnect(t *testing.T) {
 	}
 }
 
-func TestSubscribeEventsInvalidURL(t *testing.T) {
+func TestSubscribeEventsForceNewConnection(t *testing.T) {
 	t.Parallel()
 
-	events, errs := SubscribeEvents(context.Background(), "")
-	if _, ok := <-events; ok {
-		t.Fatal("expected events channel to close")
+	// countConnections subscribes until three events arrive, each on its own
+	// request, and returns how many TCP connections the server accepted.
+	countConnections := func(t *testing.T, opts ...SubscribeOption) int32 {
+		var conns int32
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: {}\n\n"))
+		}))
+		srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				atomic.AddInt32(&conns, 1)
+			}
+		}
+		srv.Start()
+		defer srv.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		client := &http.Client{Transport: &http.Transport{}}
+		defer client.CloseIdleConnections()
+		opts = append([]SubscribeOption{WithHTTPClient(client), WithSubscribeRetryDelay(time.Millisecond)}, opts...)
+		events, _ := SubscribeEvents(ctx, srv.URL, opts...)
+		for i := 0; i < 3; i++ {
+			select {
+			case <-events:
+			case <-time.After(2 * time.Second):
+				t.Fatalf("timed out waiting for event %d", i+1)
+			}
+		}
+		return atomic.LoadInt32(&conns)
 	}
-	err := <-errs
-	if err == nil {
-		t.Fatal("expected error")
+
+	if n := countConnections(t); n != 1 {
+		t.Fatalf("reconnects without the option used %d connections, want 1 reused", n)
+	}
+	if n := countConnections(t, WithForceNewConnection(true)); n < 3 {
+		t.Fatalf("reconnects with WithForceNewConnection used %d connections, want one each", n)
 	}
 }
 
-func TestSubscribeEventsHeadersAndCancel(t *testing.T) {
+func TestSubscriberReconnectState(t *testing.T) {
 	t.Parallel()
 
-	var sawHeader int32
+	var connections int32
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Header.Get("X-Test-Header") == "ok" {
-			atomic.StoreInt32(&sawHeader, 1)
-		}
-		w.Header().Set("Content-Type", "text/event-stream")
-		flusher, ok := w.(http.Flusher)
-		if !ok {
+		if atomic.AddInt32(&connections, 1) == 1 {
+			http.Error(w, "starting up", http.StatusServiceUnavailable)
 			return
 		}
-		_, _ = w.Write([]byte("data: {\"ok\":true}\n\n"))
-		flusher.Flush()
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: 1\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
 	}))
 	defer srv.Close()
 
 	ctx, cancel := context.WithCancel(context.Background())
-	events, errs := SubscribeEvents(ctx, srv.URL, WithHeaders(http.Header{"X-Test-Header": []string{"ok"}}))
+	defer cancel()
+
+	s := NewSubscriber(ctx, srv.URL, WithSubscribeRetryDelay(5*time.Millisecond))
+	if s.ReconnectCount() != 0 {
+		t.Fatalf("ReconnectCount = %d before any reconnect", s.ReconnectCount())
+	}
 
 	select {
-	case <-events:
+	case <-s.Events():
 	case <-time.After(2 * time.Second):
 		t.Fatal("timed out waiting for event")
 	}
 
-	cancel()
+	if got := s.ReconnectCount(); got != 1 {
+		t.Fatalf("ReconnectCount = %d, want 1", got)
+	}
+	var statusErr *HTTPStatusError
+	if !errors.As(s.LastError(), &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("LastError = %v, want 503 status error", s.LastError())
+	}
+	if err := <-s.Errors(); !errors.Is(err, s.LastError()) {
+		t.Fatalf("Errors() delivered %v, want %v", err, s.LastError())
+	}
+}
+
+func TestSubscribeEventsGracefulEOF(t *testing.T) {
+	t.Parallel()
+
+	var connections int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&connections, 1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: 1\n\ndata: 2\n\ndata: 3\n\n"))
+	}))
+	defer srv.Close()
+
+	events, errs := SubscribeEvents(context.Background(), srv.URL, WithGracefulEOF(true))
+
+	var got []string
+	deadline := time.After(2 * time.Second)
+	for events != nil || errs != nil {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			got = append(got, string(ev.Data))
+		case err, ok := <-errs:
+			if ok {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			errs = nil
+		case <-deadline:
+			t.Fatal("subscription did not end after the stream closed")
+		}
+	}
+	if want := []string{"1", "2", "3"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if n := atomic.LoadInt32(&connections); n != 1 {
+		t.Fatalf("expected no reconnect, got %d connections", n)
+	}
+}
+
+func TestSubscribeEventsGracefulEOFTruncated(t *testing.T) {
+	t.Parallel()
+
+	var connections int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&connections, 1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: 1\n\ndata: 2\n"))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, errs := SubscribeEvents(ctx, srv.URL, WithGracefulEOF(true), WithSubscribeRetryDelay(5*time.Millisecond))
 
 	select {
-	case <-errs:
+	case err := <-errs:
+		if err == nil {
+			t.Fatal("expected an error for a stream cut off mid-event")
+		}
 	case <-time.After(2 * time.Second):
-		t.Fatal("timed out waiting for error channel close")
+		t.Fatal("timed out waiting for error")
 	}
 
-	if atomic.LoadInt32(&sawHeader) == 0 {
-		t.Fatal("expected header to be passed")
+	deadline := time.After(2 * time.Second)
+	for atomic.LoadInt32(&connections) < 2 {
+		select {
+		case <-deadline:
+			t.Fatal("expected a reconnect after a truncated stream")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	for input, want := range map[string]error{
+		"data: 1\n\n":          io.EOF,
+		"data: 1\n\n: bye":     io.EOF,
+		"data: 1\n\ndata: 2\n": errTruncatedEOF,
+		"data: 1\n\nid: 2":     errTruncatedEOF,
+	} {
+		err := readEventStream(context.Background(), strings.NewReader(input), 1024, 0, realClock{}, func(Event) error { return nil })
+		if err != want {
+			t.Errorf("readEventStream(%q) = %v, want %v", input, err, want)
+		}
+	}
+}
+
+func TestSubscribeEventsTotalByteLimit(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: \"12345678\"\n\n"))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, errs := SubscribeEvents(ctx, srv.URL,
+		WithTotalByteLimit(25),
+		WithSubscribeRetryDelay(5*time.Millisecond),
+	)
+
+	var got int
+	deadline := time.After(2 * time.Second)
+	for events != nil {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			got++
+		case <-deadline:
+			t.Fatal("timed out waiting for events channel to close")
+		}
+	}
+	if got != 2 {
+		t.Fatalf("expected 2 events within the byte limit, got %d", got)
+	}
+
+	var sawLimit bool
+	for err := range errs {
+		if errors.Is(err, ErrByteLimitExceeded) {
+			sawLimit = true
+		}
+	}
+	if !sawLimit {
+		t.Fatal("expected ErrByteLimitExceeded")
+	}
+}
+
+func TestSubscribeEventsRetryableStatusFunc(t *testing.T) {
+	t.Parallel()
+
+	var connections int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&connections, 1) {
+		case 1:
+			http.Error(w, "short and stout", http.StatusTeapot)
+		case 2:
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: \"ok\"\n\n"))
+		default:
+			http.Error(w, "gateway busy", http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, errs := SubscribeEvents(ctx, srv.URL,
+		WithSubscribeRetryDelay(5*time.Millisecond),
+		WithRetryableStatusFunc(func(status int) bool {
+			return status == http.StatusTeapot
+		}),
+	)
+
+	var got int
+	deadline := time.After(2 * time.Second)
+	for events != nil {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			got++
+		case <-deadline:
+			t.Fatal("timed out waiting for events channel to close")
+		}
+	}
+	if got != 1 {
+		t.Fatalf("expected 1 event, got %d", got)
+	}
+	if n := atomic.LoadInt32(&connections); n != 3 {
+		t.Fatalf("expected 3 connection attempts, got %d", n)
+	}
+
+	var statuses []int
+	for err := range errs {
+		var statusErr *HTTPStatusError
+		if errors.As(err, &statusErr) {
+			statuses = append(statuses, statusErr.StatusCode)
+		}
+	}
+	if !reflect.DeepEqual(statuses, []int{http.StatusTeapot, http.StatusServiceUnavailable}) {
+		t.Fatalf("unexpected status errors: %v", statuses)
+	}
+}
+
+func TestSubscribeEventsDedupeWindow(t *testing.T) {
+	t.Parallel()
+
+	var connections int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch atomic.AddInt32(&connections, 1) {
+		case 1:
+			_, _ = w.Write([]byte("id: 1\ndata: \"a\"\n\nid: 2\ndata: \"b\"\n\ndata: \"no id\"\n\n"))
+		case 2:
+			// The server replays the boundary event after the reconnect.
+			_, _ = w.Write([]byte("id: 2\ndata: \"b\"\n\ndata: \"no id\"\n\nid: 3\ndata: \"c\"\n\n"))
+		default:
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, _ := SubscribeEvents(ctx, srv.URL,
+		WithDedupeWindow(2),
+		WithSubscribeRetryDelay(5*time.Millisecond),
+	)
+
+	var got []string
+	deadline := time.After(2 * time.Second)
+	for len(got) < 5 {
+		select {
+		case ev := <-events:
+			got = append(got, ev.ID+"="+string(ev.Data))
+		case <-deadline:
+			t.Fatalf("timed out waiting for events, got %v", got)
+		}
+	}
+
+	want := []string{`1="a"`, `2="b"`, `="no id"`, `="no id"`, `3="c"`}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected extra event %+v", ev)
+	case <-time.After(50 * time.Millisecond):
 	}
 }
+
+func TestSubscribeEventsInterceptor(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("event: turn_appended\ndata: 1\n\nevent: bogus\ndata: 2\n\nevent: turn_appended\ndata: 3\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rejected := errors.New("unknown event type")
+	events, errs := SubscribeEvents(ctx, srv.URL, WithEventInterceptor(func(ev Event) (Event, error) {
+		if ev.Type != "turn_appended" {
+			return ev, rejected
+		}
+		ev.ID = "seen-" + string(ev.Data)
+		return ev, nil
+	}))
+
+	var got []string
+	deadline := time.After(2 * time.Second)
+	for len(got) < 2 {
+		select {
+		case ev := <-events:
+			got = append(got, ev.ID)
+		case <-deadline:
+			t.Fatalf("timed out waiting for events, got %v", got)
+		}
+	}
+	if want := []string{"seen-1", "seen-3"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, rejected) {
+			t.Fatalf("expected interceptor error, got %v", err)
+		}
+	case <-deadline:
+		t.Fatal("timed out waiting for interceptor error")
+	}
+}
+
+func TestSubscribeEventsLifetime(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: 1\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	clk := newFakeClock()
+	events, errs := SubscribeEvents(context.Background(), srv.URL, WithLifetime(time.Minute), withSubscribeClock(clk))
+
+	select {
+	case <-events:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+
+	clk.Advance(time.Minute)
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrSubscriptionExpired) {
+			t.Fatalf("expected ErrSubscriptionExpired, got %v", err)
+		}
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expiry should not look like a context error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for expiry")
+	}
+
+	deadline := time.After(2 * time.Second)
+	for events != nil || errs != nil {
+		select {
+		case _, ok := <-events:
+			if ok {
+				t.Fatal("unexpected event after expiry")
+			}
+			events = nil
+		case err, ok := <-errs:
+			if ok {
+				t.Fatalf("unexpected error after expiry: %v", err)
+			}
+			errs = nil
+		case <-deadline:
+			t.Fatal("channels not closed after expiry")
+		}
+	}
+}
+
+func TestIDWindowEvictsOldest(t *testing.T) {
+	t.Parallel()
+
+	w := newIDWindow(2)
+	w.add("a")
+	w.add("b")
+	w.add("a") // already present: does not refresh or evict
+	w.add("c") // evicts a
+	if w.contains("a") || !w.contains("b") || !w.contains("c") {
+		t.Fatalf("unexpected window contents: %v", w.ids)
+	}
+	w.add("d") // evicts b
+	if w.contains("b") || !w.contains("c") || !w.contains("d") {
+		t.Fatalf("unexpected window contents: %v", w.ids)
+	}
+}
+
+func TestSubscribeEventsBackoffSchedule(t *testing.T) {
+	t.Parallel()
+
+	var connections int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&connections, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clk := newFakeClock()
+	_, errs := SubscribeEvents(ctx, srv.URL,
+		WithSubscribeRetryDelay(100*time.Millisecond),
+		WithSubscribeMaxRetryDelay(300*time.Millisecond),
+		withSubscribeClock(clk),
+	)
+	go func() {
+		for range errs {
+		}
+	}()
+
+	waitConnections := func(n int32) {
+		t.Helper()
+		deadline := time.After(2 * time.Second)
+		for atomic.LoadInt32(&connections) < n {
+			select {
+			case <-deadline:
+				t.Fatalf("timed out waiting for connection %d", n)
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}
+
+	waitConnections(1)
+	for i, delay := range []time.Duration{100, 200, 300, 300} {
+		delay *= time.Millisecond
+		clk.waitForTimers(t, 1)
+
+		clk.Advance(delay - time.Millisecond)
+		if n := atomic.LoadInt32(&connections); n != int32(i+1) {
+			t.Fatalf("retry %d: reconnected before %s elapsed (%d connections)", i+1, delay, n)
+		}
+
+		clk.Advance(time.Millisecond)
+		waitConnections(int32(i + 2))
+	}
+}
+
+func TestSubscribeEventsRetryBudget(t *testing.T) {
+	t.Parallel()
+
+	var connections int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&connections, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	clk := newFakeClock()
+	events, errs := SubscribeEvents(context.Background(), srv.URL,
+		WithSubscribeRetryDelay(300*time.Millisecond),
+		WithSubscribeMaxRetryDelay(300*time.Millisecond),
+		WithRetryBudget(time.Second),
+		WithErrorBuffer(16),
+		withSubscribeClock(clk),
+	)
+
+	// Attempts start at 0, 300ms, 600ms, and 900ms; a fifth at 1.2s would
+	// overrun the budget, so it is not made.
+	for i := 0; i < 3; i++ {
+		clk.waitForTimers(t, 1)
+		clk.Advance(300 * time.Millisecond)
+	}
+
+	var last error
+	deadline := time.After(2 * time.Second)
+	for errs != nil {
+		select {
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			last = err
+		case <-deadline:
+			t.Fatal("subscription did not stop after the retry budget ran out")
+		}
+	}
+	if !errors.Is(last, ErrRetryBudgetExhausted) {
+		t.Fatalf("final error = %v, want ErrRetryBudgetExhausted", last)
+	}
+	if n := atomic.LoadInt32(&connections); n != 4 {
+		t.Fatalf("made %d connection attempts, want 4", n)
+	}
+	if _, ok := <-events; ok {
+		t.Fatal("events channel not closed")
+	}
+}
+
+func TestSubscribeEventsRetryBudgetResetsOnConnect(t *testing.T) {
+	t.Parallel()
+
+	// Every third attempt succeeds and then drops, so the subscription never
+	// fails for long enough to exhaust the budget.
+	var connections int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&connections, 1)%3 != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: 1\n\n"))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clk := newFakeClock()
+	events, errs := SubscribeEvents(ctx, srv.URL,
+		WithSubscribeRetryDelay(300*time.Millisecond),
+		WithSubscribeMaxRetryDelay(300*time.Millisecond),
+		WithRetryBudget(time.Second),
+		withSubscribeClock(clk),
+	)
+	go func() {
+		for range events {
+		}
+	}()
+
+	for i := 0; i < 8; i++ {
+		clk.waitForTimers(t, 1)
+		clk.Advance(300 * time.Millisecond)
+	}
+	clk.waitForTimers(t, 1)
+	cancel()
+
+	for err := range errs {
+		if errors.Is(err, ErrRetryBudgetExhausted) {
+			t.Fatalf("budget exhausted despite successful connections: %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&connections); n != 9 {
+		t.Fatalf("made %d connection attempts, want 9", n)
+	}
+}
+
+func TestSubscribeEventsMaxEventRate(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < 5; i++ {
+			_, _ = fmt.Fprintf(w, "data: %d\n\n", i)
+		}
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clk := newFakeClock()
+	start := clk.Now()
+	events, _ := SubscribeEvents(ctx, srv.URL, WithMaxEventRate(10), withSubscribeClock(clk))
+
+	receive := func(want string) {
+		t.Helper()
+		select {
+		case ev := <-events:
+			if string(ev.Data) != want {
+				t.Fatalf("got event %s, want %s", ev.Data, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for event %s", want)
+		}
+	}
+
+	// The whole burst arrives at once but is spaced 100ms apart.
+	receive("0")
+	for i := 1; i < 5; i++ {
+		clk.waitForTimers(t, 1)
+		clk.Advance(99 * time.Millisecond)
+		select {
+		case ev := <-events:
+			t.Fatalf("event %s delivered early", ev.Data)
+		default:
+		}
+		clk.Advance(time.Millisecond)
+		receive(fmt.Sprint(i))
+	}
+	if elapsed := clk.Now().Sub(start); elapsed < 400*time.Millisecond {
+		t.Fatalf("5 events delivered in %s, faster than 10/s", elapsed)
+	}
+}
+
+func TestSubscribeEventsInvalidURL(t *testing.T) {
+	t.Parallel()
+
+	events, errs := SubscribeEvents(context.Background(), "")
+	if _, ok := <-events; ok {
+		t.Fatal("expected events channel to close")
+	}
+	err := <-errs
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestNewSubscriberInvalidOptionsUnbuffered(t *testing.T) {
+	t.Parallel()
+
+	done := make(chan *Subscriber, 1)
+	go func() {
+		done <- NewSubscriber(context.Background(), "http://cxdb.invalid/v1/events",
+			WithErrorBuffer(0),
+			WithProxy("ftp://proxy.example"),
+		)
+	}()
+
+	var s *Subscriber
+	select {
+	case s = <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("NewSubscriber blocked on a validation error")
+	}
+	if _, ok := <-s.Events(); ok {
+		t.Fatal("expected events channel to close")
+	}
+	if err := <-s.Errors(); err == nil || !strings.Contains(err.Error(), "proxy") {
+		t.Fatalf("expected proxy error, got %v", err)
+	}
+	if s.LastError() == nil {
+		t.Fatal("LastError = nil, want the validation error")
+	}
+}
+
+func TestSubscribeEventsAccept(t *testing.T) {
+	t.Parallel()
+
+	accepts := make(chan []string, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case accepts <- r.Header.Values("Accept"):
+		default:
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: 1\n\n"))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const mediaType = "application/vnd.cxdb.events+event-stream"
+	SubscribeEvents(ctx, srv.URL,
+		WithHeaders(http.Header{"Accept": []string{"text/plain"}}),
+		WithAccept(mediaType),
+		WithSubscribeRetryDelay(5*time.Millisecond),
+	)
+
+	// The server closes each stream, so the second request is a reconnect.
+	for i := 0; i < 2; i++ {
+		select {
+		case got := <-accepts:
+			if want := []string{mediaType}; !reflect.DeepEqual(got, want) {
+				t.Fatalf("request %d: Accept = %v, want %v", i+1, got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for request %d", i+1)
+		}
+	}
+
+	events, errs := SubscribeEvents(context.Background(), srv.URL, WithAccept(" "))
+	if _, ok := <-events; ok {
+		t.Fatal("expected events channel to close")
+	}
+	if err := <-errs; err == nil || !strings.Contains(err.Error(), "accept") {
+		t.Fatalf("expected empty accept error, got %v", err)
+	}
+}
+
+func TestSubscribeEventsProxy(t *testing.T) {
+	t.Parallel()
+
+	requests := make(chan string, 4)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case requests <- r.Method + " " + r.RequestURI:
+		default:
+		}
+		if r.Method == http.MethodConnect {
+			http.Error(w, "tunnels not allowed", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: 1\n\n"))
+	}))
+	defer proxy.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The endpoint's host does not resolve, so only the proxy can serve it.
+	events, _ := SubscribeEvents(ctx, "http://cxdb.invalid/v1/events",
+		WithProxy(proxy.URL),
+		WithHTTPClient(&http.Client{Transport: &http.Transport{}}),
+		WithSubscribeRetryDelay(5*time.Millisecond),
+	)
+
+	// The proxy closes each stream, so the second request is a reconnect.
+	for i := 0; i < 2; i++ {
+		select {
+		case got := <-requests:
+			if want := "GET http://cxdb.invalid/v1/events"; got != want {
+				t.Fatalf("request %d: proxy saw %q, want %q", i+1, got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for request %d", i+1)
+		}
+		if ev := <-events; string(ev.Data) != "1" {
+			t.Fatalf("event %d: data = %q", i+1, ev.Data)
+		}
+	}
+	cancel()
+
+	_, errs := SubscribeEvents(context.Background(), "https://cxdb.invalid/v1/events",
+		WithProxy(proxy.URL),
+		WithRetryableStatusFunc(func(int) bool { return false }),
+	)
+	select {
+	case got := <-requests:
+		if want := "CONNECT cxdb.invalid:443"; got != want {
+			t.Fatalf("proxy saw %q, want %q", got, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for CONNECT")
+	}
+	if err := <-errs; err == nil {
+		t.Fatal("expected an error for the refused tunnel")
+	}
+
+	for _, opts := range [][]SubscribeOption{
+		{WithProxy("ftp://proxy.example")},
+		{WithProxy("http://")},
+		{WithProxy(proxy.URL), WithHTTPClient(&http.Client{Transport: roundTripFunc(nil)})},
+	} {
+		events, errs := SubscribeEvents(context.Background(), "http://cxdb.invalid/v1/events", opts...)
+		if _, ok := <-events; ok {
+			t.Fatal("expected events channel to close")
+		}
+		if err := <-errs; err == nil || !strings.Contains(err.Error(), "proxy") {
+			t.Fatalf("expected a proxy error, got %v", err)
+		}
+	}
+}
+
+func TestParseNoProxy(t *testing.T) {
+	t.Parallel()
+
+	bypass := parseNoProxy("example.com, .internal, 10.0.0.0/8, 192.168.1.1, cxdb.test:8080")
+	for rawURL, want := range map[string]bool{
+		"http://example.com/":          true,
+		"http://api.example.com/":      true,
+		"http://notexample.com/":       false,
+		"http://internal/":             false,
+		"http://db.internal/":          true,
+		"http://10.1.2.3/":             true,
+		"http://11.1.2.3/":             false,
+		"http://192.168.1.1:9000/":     true,
+		"http://cxdb.test:8080/":       true,
+		"http://cxdb.test/":            false,
+		"http://localhost:9010/":       true,
+		"http://127.0.0.1:9010/":       true,
+		"https://cxdb.example.org/v1/": false,
+	} {
+		u, _ := url.Parse(rawURL)
+		if got := bypass(u); got != want {
+			t.Errorf("bypass(%s) = %v, want %v", rawURL, got, want)
+		}
+	}
+
+	u, _ := url.Parse("http://anything.example/")
+	if !parseNoProxy("*")(u) {
+		t.Error("expected * to bypass every host")
+	}
+}
+
+func TestSubscribeEventsHeadersAndCancel(t *testing.T) {
+	t.Parallel()
+
+	var sawHeader int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Test-Header") == "ok" {
+			atomic.StoreInt32(&sawHeader, 1)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			return
+		}
+		_, _ = w.Write([]byte("data: {\"ok\":true}\n\n"))
+		flusher.Flush()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, errs := SubscribeEvents(ctx, srv.URL, WithHeaders(http.Header{"X-Test-Header": []string{"ok"}}))
+
+	select {
+	case <-events:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+
+	cancel()
+
+	select {
+	case <-errs:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for error channel close")
+	}
+
+	if atomic.LoadInt32(&sawHeader) == 0 {
+		t.Fatal("expected header to be passed")
+	}
+}
+
+func TestSubscribeEventsFollowsRedirects(t *testing.T) {
+	t.Parallel()
+
+	seen := make(chan http.Header, 1)
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case seen <- r.Header.Clone():
+		default:
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"ok\":true}\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer target.Close()
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/events":
+			http.Redirect(w, r, "/moved", http.StatusFound)
+		default:
+			http.Redirect(w, r, target.URL+"/v1/events", http.StatusTemporaryRedirect)
+		}
+	}))
+	defer origin.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, _ := SubscribeEvents(ctx, origin.URL+"/v1/events", WithHeaders(http.Header{
+		"X-Test-Header": []string{"ok"},
+		"Last-Event-Id": []string{"42"},
+		"Authorization": []string{"Bearer secret"},
+	}))
+
+	select {
+	case ev := <-events:
+		if string(ev.Data) != `{"ok":true}` {
+			t.Fatalf("unexpected event data %s", ev.Data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+
+	h := <-seen
+	if h.Get("X-Test-Header") != "ok" || h.Get("Last-Event-ID") != "42" {
+		t.Fatalf("headers not preserved across redirects: %v", h)
+	}
+	if h.Get("Authorization") != "" {
+		t.Fatalf("credentials forwarded to another host: %v", h)
+	}
+}
+
+func TestSubscribeEventsRedirectErrors(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a":
+			http.Redirect(w, r, "/b", http.StatusFound)
+		case "/b":
+			http.Redirect(w, r, "/a", http.StatusFound)
+		default:
+			// /chain/N redirects to /chain/N+1 forever.
+			var n int
+			_, _ = fmt.Sscanf(r.URL.Path, "/chain/%d", &n)
+			http.Redirect(w, r, fmt.Sprintf("/chain/%d", n+1), http.StatusFound)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		path string
+		opts []SubscribeOption
+		want error
+	}{
+		{name: "loop", path: "/a", want: ErrRedirectLoop},
+		{name: "too many", path: "/chain/0", opts: []SubscribeOption{WithMaxRedirects(3)}, want: ErrTooManyRedirects},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			_, errs := SubscribeEvents(ctx, srv.URL+tt.path, tt.opts...)
+			select {
+			case err := <-errs:
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("expected %v, got %v", tt.want, err)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("timed out waiting for redirect error")
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		_, errs := SubscribeEvents(ctx, srv.URL+"/a", WithMaxRedirects(0))
+		select {
+		case err := <-errs:
+			var statusErr *HTTPStatusError
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusFound {
+				t.Fatalf("expected 302 status error, got %v", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for status error")
+		}
+	})
+}
+
+func TestSubscribeEventsTraceHeaderFunc(t *testing.T) {
+	t.Parallel()
+
+	seen := make(chan http.Header, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen <- r.Header.Clone()
+		w.Header().Set("Content-Type", "text/event-stream")
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var attempts int32
+	_, _ = SubscribeEvents(ctx, srv.URL,
+		WithHeaders(http.Header{"X-Static": []string{"static"}}),
+		WithTraceHeaderFunc(func(ctx context.Context) http.Header {
+			n := atomic.AddInt32(&attempts, 1)
+			return http.Header{
+				"Traceparent": []string{fmt.Sprintf("trace-%d", n)},
+				"X-Static":    []string{"clobbered"},
+			}
+		}),
+		WithSubscribeRetryDelay(5*time.Millisecond),
+	)
+
+	for i := 1; i <= 2; i++ {
+		select {
+		case h := <-seen:
+			if got, want := h.Get("Traceparent"), fmt.Sprintf("trace-%d", i); got != want {
+				t.Fatalf("attempt %d: expected traceparent %q, got %q", i, want, got)
+			}
+			if got := h.Values("X-Static"); len(got) != 1 || got[0] != "static" {
+				t.Fatalf("attempt %d: expected static header to win, got %v", i, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for attempt %d", i)
+		}
+	}
+}
+
+func TestSubscribeEventsEmitTimeout(t *testing.T) {
+	t.Parallel()
+
+	var connections int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&connections, 1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for i := 0; i < 3; i++ {
+			_, _ = w.Write([]byte("data: {\"ok\":true}\n\n"))
+		}
+		flusher.Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Nobody reads events, so the second event cannot be handed off.
+	_, errs := SubscribeEvents(ctx, srv.URL,
+		WithEventBuffer(1),
+		WithEmitTimeout(20*time.Millisecond),
+		WithSubscribeRetryDelay(5*time.Millisecond),
+	)
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrEmitTimeout) {
+			t.Fatalf("expected ErrEmitTimeout, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for emit timeout")
+	}
+
+	deadline := time.After(2 * time.Second)
+	for atomic.LoadInt32(&connections) < 2 {
+		select {
+		case <-deadline:
+			t.Fatal("expected a reconnect after emit timeout")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+func TestSubscribeEventsHandshakeTimeout(t *testing.T) {
+	t.Parallel()
+
+	var connections int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&connections, 1)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, errs := SubscribeEvents(ctx, srv.URL,
+		WithHandshakeTimeout(20*time.Millisecond),
+		WithSubscribeRetryDelay(5*time.Millisecond),
+	)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errs:
+			if !errors.Is(err, ErrHandshakeTimeout) {
+				t.Fatalf("expected ErrHandshakeTimeout, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for handshake timeout")
+		}
+	}
+
+	if n := atomic.LoadInt32(&connections); n < 2 {
+		t.Fatalf("expected a reconnect after handshake timeout, got %d connections", n)
+	}
+}
+
+func TestSubscribeEventsHandshakeTimeoutDoesNotLimitStream(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		flusher.Flush()
+		time.Sleep(50 * time.Millisecond)
+		_, _ = w.Write([]byte("data: {\"ok\":true}\n\n"))
+		flusher.Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, errs := SubscribeEvents(ctx, srv.URL, WithHandshakeTimeout(10*time.Millisecond))
+
+	select {
+	case ev := <-events:
+		if string(ev.Data) != `{"ok":true}` {
+			t.Fatalf("unexpected event: %#v", ev)
+		}
+	case err := <-errs:
+		t.Fatalf("unexpected error: %v", err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+}
+
+// BenchmarkReadEventStreamLargeLines parses events whose data is a single
+// 256KB line, with the default and a larger read buffer.
+func BenchmarkReadEventStreamLargeLines(b *testing.B) {
+	const events = 16
+	line := "data: \"" + strings.Repeat("z", 256*1024) + "\"\n\n"
+	input := strings.Repeat(line, events)
+
+	for _, bufSize := range []int{0, 64 * 1024, 512 * 1024} {
+		b.Run(fmt.Sprintf("buf=%d", bufSize), func(b *testing.B) {
+			b.SetBytes(int64(len(input)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				err := readEventStream(context.Background(), strings.NewReader(input), defaultMaxEventBytes, bufSize, realClock{}, func(ev Event) error {
+					return nil
+				})
+				if !errors.Is(err, io.EOF) {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -4,10 +4,14 @@
 package cxdb
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/binary"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"time"
 
 	"github.com/zeebo/blake3"
 )
@@ -37,7 +41,7 @@ type AppendRequest struct {
 	Encoding uint32
 
 	// Compression specifies payload compression. Defaults to CompressionNone.
-	Compression uint32
+	Compression CompressionID
 }
 
 // TurnRecord represents a turn returned from the server.
@@ -48,17 +52,54 @@ type TurnRecord struct {
 	TypeID      string
 	TypeVersion uint32
 	Encoding    uint32
-	Compression uint32
+	Compression CompressionID
 	PayloadHash [32]byte
 	Payload     []byte // Only populated if requested
 }
 
+// Equal reports whether two turn records are identical: same turn and parent
+// IDs, depth, declared type and version, encoding, compression, payload hash,
+// and payload bytes. Records fetched without payloads compare equal only to
+// other records without payloads.
+func (t TurnRecord) Equal(other TurnRecord) bool {
+	return t.TurnID == other.TurnID &&
+		t.ParentID == other.ParentID &&
+		t.Depth == other.Depth &&
+		t.TypeID == other.TypeID &&
+		t.TypeVersion == other.TypeVersion &&
+		t.Encoding == other.Encoding &&
+		t.Compression == other.Compression &&
+		t.PayloadHash == other.PayloadHash &&
+		bytes.Equal(t.Payload, other.Payload)
+}
+
+// ContentHash returns the hex-encoded BLAKE3-256 hash of the payload bytes as
+// received. Unlike PayloadHash, which is reported by the server, it is computed
+// locally, so a mismatch between the two or a changed ContentHash for the same
+// TurnID indicates the payload changed in transit or on the server.
+func (t TurnRecord) ContentHash() string {
+	sum := blake3.Sum256(t.Payload)
+	return hex.EncodeToString(sum[:])
+}
+
 // AppendResult contains the result of an append operation.
 type AppendResult struct {
 	ContextID   uint64
 	TurnID      uint64
 	Depth       uint32
 	PayloadHash [32]byte
+
+	// CommittedAt is when the server stored the turn. It is zero if the
+	// server does not report it.
+	CommittedAt time.Time
+}
+
+// Head returns the context head after the append. The server always moves
+// the head to the new turn, so this is the new turn, and a writer can update
+// its view of the context without calling GetHead. See also
+// WithAppendedTurns.
+func (r *AppendResult) Head() *ContextHead {
+	return &ContextHead{ContextID: r.ContextID, HeadTurnID: r.TurnID, HeadDepth: r.Depth}
 }
 
 // AppendTurn appends a new turn to a context.
@@ -98,20 +139,41 @@ func (c *Client) AppendTurn(ctx context.Context, req *AppendRequest) (*AppendRes
 		return nil, fmt.Errorf("append turn: %w", err)
 	}
 
-	if len(resp.payload) < 52 {
-		return nil, fmt.Errorf("%w: append response too short (%d bytes)", ErrInvalidResponse, len(resp.payload))
+	return parseAppendResult(resp.payload)
+}
+
+// parseAppendResult parses an APPEND_TURN response. The commit time is a
+// trailing field that older servers omit.
+func parseAppendResult(payload []byte) (*AppendResult, error) {
+	if len(payload) < 52 {
+		return nil, fmt.Errorf("%w: append response too short (%d bytes)", ErrInvalidResponse, len(payload))
 	}
 
 	result := &AppendResult{
-		ContextID: binary.LittleEndian.Uint64(resp.payload[0:8]),
-		TurnID:    binary.LittleEndian.Uint64(resp.payload[8:16]),
-		Depth:     binary.LittleEndian.Uint32(resp.payload[16:20]),
+		ContextID: binary.LittleEndian.Uint64(payload[0:8]),
+		TurnID:    binary.LittleEndian.Uint64(payload[8:16]),
+		Depth:     binary.LittleEndian.Uint32(payload[16:20]),
+	}
+	copy(result.PayloadHash[:], payload[20:52])
+	if len(payload) >= 60 {
+		result.CommittedAt = time.UnixMilli(int64(binary.LittleEndian.Uint64(payload[52:60])))
 	}
-	copy(result.PayloadHash[:], resp.payload[20:52])
 
 	return result, nil
 }
 
+// TurnOrder selects the order in which GetLast returns turns.
+type TurnOrder uint8
+
+const (
+	// OrderAscending returns turns oldest first, in ascending depth. It is the
+	// default.
+	OrderAscending TurnOrder = iota
+
+	// OrderDescending returns turns newest first, starting at the head.
+	OrderDescending
+)
+
 // GetLastOptions configures GetLast behavior.
 type GetLastOptions struct {
 	// Limit is the maximum number of turns to return.
@@ -119,10 +181,64 @@ type GetLastOptions struct {
 
 	// IncludePayload controls whether to include turn payloads.
 	IncludePayload bool
+
+	// Order is the order of the returned turns. The selection is the same
+	// either way: the Limit turns nearest the head. The server always sends
+	// them ascending, so OrderDescending is applied by the client. StreamLast
+	// does not support OrderDescending.
+	Order TurnOrder
+
+	// TypeID, if set, keeps only turns whose declared type is TypeID, and
+	// TypeVersion, if not 0, only those of that type version. The binary
+	// protocol has no type filter, so the client applies it to the Limit
+	// turns the server returns: fewer than Limit turns may be returned, and
+	// with IncludePayload the payloads of the dropped turns are still
+	// transferred, though never decoded.
+	TypeID      string
+	TypeVersion uint32
+}
+
+// matchesType reports whether turn passes the TypeID and TypeVersion filter.
+func (o GetLastOptions) matchesType(turn TurnRecord) bool {
+	if o.TypeID != "" && turn.TypeID != o.TypeID {
+		return false
+	}
+	return o.TypeVersion == 0 || turn.TypeVersion == o.TypeVersion
 }
 
 // GetLast retrieves the last N turns from a context, walking back from the head.
+// The server returns them oldest first, in ascending depth; the client passes
+// them through in the order received unless opts.Order is OrderDescending.
+// It returns an error wrapping a *ContextNotFoundError if the context does not
+// exist.
 func (c *Client) GetLast(ctx context.Context, contextID uint64, opts GetLastOptions) ([]TurnRecord, error) {
+	resp, err := c.sendRequest(ctx, msgGetLast, getLastPayload(contextID, opts))
+	if err != nil {
+		return nil, fmt.Errorf("get last: %w", contextNotFound(contextID, err))
+	}
+
+	turns, err := parseTurnRecords(resp.payload)
+	if err != nil {
+		return nil, err
+	}
+	if opts.TypeID != "" || opts.TypeVersion != 0 {
+		kept := turns[:0]
+		for _, turn := range turns {
+			if opts.matchesType(turn) {
+				kept = append(kept, turn)
+			}
+		}
+		turns = kept
+	}
+	if opts.Order == OrderDescending {
+		for i, j := 0, len(turns)-1; i < j; i, j = i+1, j-1 {
+			turns[i], turns[j] = turns[j], turns[i]
+		}
+	}
+	return turns, nil
+}
+
+func getLastPayload(contextID uint64, opts GetLastOptions) []byte {
 	limit := opts.Limit
 	if limit == 0 {
 		limit = 10
@@ -136,13 +252,148 @@ func (c *Client) GetLast(ctx context.Context, contextID uint64, opts GetLastOpti
 		includePayload = 1
 	}
 	_ = binary.Write(payload, binary.LittleEndian, includePayload)
+	return payload.Bytes()
+}
+
+// StreamLast is like GetLast but decodes turns straight off the connection
+// and sends each one on the returned channel as soon as it is read, so a large
+// window is never held in memory at once. Both channels are closed when the
+// response has been consumed. If the response is cut short or malformed, the
+// turns decoded so far are delivered before the error.
+//
+// The client's connection is held for the whole stream, so other requests on
+// the same Client wait until it finishes, and the request timeout bounds the
+// entire stream. Consumers should read promptly. If ctx is canceled the rest
+// of the response is discarded to keep the connection usable. Turns are always
+// sent oldest first; requesting OrderDescending fails.
+func (c *Client) StreamLast(ctx context.Context, contextID uint64, opts GetLastOptions) (<-chan TurnRecord, <-chan error) {
+	out := make(chan TurnRecord, defaultEventBuffer)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(out)
+		defer close(errs)
+
+		if opts.Order != OrderAscending {
+			errs <- fmt.Errorf("stream last: only OrderAscending is supported")
+			return
+		}
+		if err := c.streamLast(ctx, contextID, opts, out); err != nil {
+			errs <- fmt.Errorf("stream last: %w", err)
+		}
+	}()
+
+	return out, errs
+}
+
+func (c *Client) streamLast(ctx context.Context, contextID uint64, opts GetLastOptions, out chan<- TurnRecord) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClientClosed
+	}
+	if c.broken != nil {
+		return c.broken
+	}
+	defer func() { c.lastUsed = time.Now() }()
+
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return fmt.Errorf("set deadline: %w", err)
+	}
+	defer func() { _ = c.conn.SetDeadline(time.Time{}) }()
+
+	reqID := c.reqID.Add(1)
+	if err := c.writeFrame(msgGetLast, reqID, getLastPayload(contextID, opts)); err != nil {
+		return err
+	}
+
+	msgType, _, length, err := c.readFrameHeader()
+	if err != nil {
+		return err
+	}
+	body := &io.LimitedReader{R: c.conn, N: int64(length)}
+	// Consume whatever is left so the next frame starts at a header.
+	defer func() { _, _ = io.Copy(io.Discard, body) }()
+
+	if msgType == msgError {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return fmt.Errorf("read payload: %w", err)
+		}
+		return contextNotFound(contextID, parseServerError(data))
+	}
+
+	br := bufio.NewReader(body)
+	var count uint32
+	if err := binary.Read(br, binary.LittleEndian, &count); err != nil {
+		return fmt.Errorf("%w: turn records too short", ErrInvalidResponse)
+	}
+	for i := uint32(0); i < count; i++ {
+		rec, err := readTurnRecord(br)
+		if err != nil {
+			return fmt.Errorf("%w: turn %d of %d: %v", ErrInvalidResponse, i+1, count, err)
+		}
+		if !opts.matchesType(rec) {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case out <- rec:
+		}
+	}
+	return nil
+}
+
+// msgGetTurn fetches a single turn by ID. The request carries the context ID,
+// the turn ID, and an include_payload flag; the response uses the same record
+// encoding as GET_LAST with a count of one, and a missing turn is a 404 error.
+const msgGetTurn uint16 = 12
+
+// GetTurnOptions configures GetTurn behavior.
+type GetTurnOptions struct {
+	// IncludePayload controls whether to include the turn payload.
+	IncludePayload bool
+}
+
+// GetTurn retrieves a single turn from a context by its ID. The turn must be
+// on the context's chain from its head, which for a fork includes the turns it
+// shares with its base. It returns an error wrapping ErrTurnNotFound if the
+// context does not exist or the turn is not in it.
+func (c *Client) GetTurn(ctx context.Context, contextID, turnID uint64, opts GetTurnOptions) (TurnRecord, error) {
+	payload := &bytes.Buffer{}
+	_ = binary.Write(payload, binary.LittleEndian, contextID)
+	_ = binary.Write(payload, binary.LittleEndian, turnID)
+	var includePayload uint32
+	if opts.IncludePayload {
+		includePayload = 1
+	}
+	_ = binary.Write(payload, binary.LittleEndian, includePayload)
 
-	resp, err := c.sendRequest(ctx, msgGetLast, payload.Bytes())
+	resp, err := c.sendRequest(ctx, msgGetTurn, payload.Bytes())
 	if err != nil {
-		return nil, fmt.Errorf("get last: %w", err)
+		if IsServerError(err, 404) {
+			return TurnRecord{}, fmt.Errorf("get turn %d in context %d: %w", turnID, contextID, ErrTurnNotFound)
+		}
+		return TurnRecord{}, fmt.Errorf("get turn: %w", err)
 	}
 
-	return parseTurnRecords(resp.payload)
+	records, err := parseTurnRecords(resp.payload)
+	if err != nil {
+		return TurnRecord{}, fmt.Errorf("get turn: %w", err)
+	}
+	if len(records) == 0 {
+		return TurnRecord{}, fmt.Errorf("get turn %d in context %d: %w", turnID, contextID, ErrTurnNotFound)
+	}
+	if records[0].TurnID != turnID {
+		return TurnRecord{}, fmt.Errorf("%w: get turn returned turn %d, want %d", ErrInvalidResponse, records[0].TurnID, turnID)
+	}
+	return records[0], nil
 }
 
 func parseTurnRecords(data []byte) ([]TurnRecord, error) {
@@ -158,57 +409,66 @@ func parseTurnRecords(data []byte) ([]TurnRecord, error) {
 
 	records := make([]TurnRecord, 0, count)
 	for i := uint32(0); i < count; i++ {
-		var rec TurnRecord
-
-		if err := binary.Read(cursor, binary.LittleEndian, &rec.TurnID); err != nil {
-			return nil, err
-		}
-		if err := binary.Read(cursor, binary.LittleEndian, &rec.ParentID); err != nil {
-			return nil, err
-		}
-		if err := binary.Read(cursor, binary.LittleEndian, &rec.Depth); err != nil {
+		rec, err := readTurnRecord(cursor)
+		if err != nil {
 			return nil, err
 		}
+		records = append(records, rec)
+	}
 
-		var typeLen uint32
-		if err := binary.Read(cursor, binary.LittleEndian, &typeLen); err != nil {
-			return nil, err
-		}
-		typeBytes := make([]byte, typeLen)
-		if _, err := cursor.Read(typeBytes); err != nil {
-			return nil, err
-		}
-		rec.TypeID = string(typeBytes)
+	return records, nil
+}
 
-		if err := binary.Read(cursor, binary.LittleEndian, &rec.TypeVersion); err != nil {
-			return nil, err
-		}
-		if err := binary.Read(cursor, binary.LittleEndian, &rec.Encoding); err != nil {
-			return nil, err
-		}
-		if err := binary.Read(cursor, binary.LittleEndian, &rec.Compression); err != nil {
-			return nil, err
-		}
+// readTurnRecord decodes a single record in the GET_LAST response layout.
+func readTurnRecord(r io.Reader) (TurnRecord, error) {
+	var rec TurnRecord
 
-		var uncompressedLen uint32
-		if err := binary.Read(cursor, binary.LittleEndian, &uncompressedLen); err != nil {
-			return nil, err
-		}
-		if _, err := cursor.Read(rec.PayloadHash[:]); err != nil {
-			return nil, err
-		}
+	if err := binary.Read(r, binary.LittleEndian, &rec.TurnID); err != nil {
+		return TurnRecord{}, err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &rec.ParentID); err != nil {
+		return TurnRecord{}, err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &rec.Depth); err != nil {
+		return TurnRecord{}, err
+	}
 
-		var payloadLen uint32
-		if err := binary.Read(cursor, binary.LittleEndian, &payloadLen); err != nil {
-			return nil, err
-		}
-		rec.Payload = make([]byte, payloadLen)
-		if _, err := cursor.Read(rec.Payload); err != nil {
-			return nil, err
-		}
+	var typeLen uint32
+	if err := binary.Read(r, binary.LittleEndian, &typeLen); err != nil {
+		return TurnRecord{}, err
+	}
+	typeBytes := make([]byte, typeLen)
+	if _, err := io.ReadFull(r, typeBytes); err != nil {
+		return TurnRecord{}, err
+	}
+	rec.TypeID = string(typeBytes)
 
-		records = append(records, rec)
+	if err := binary.Read(r, binary.LittleEndian, &rec.TypeVersion); err != nil {
+		return TurnRecord{}, err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &rec.Encoding); err != nil {
+		return TurnRecord{}, err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &rec.Compression); err != nil {
+		return TurnRecord{}, err
 	}
 
-	return records, nil
+	var uncompressedLen uint32
+	if err := binary.Read(r, binary.LittleEndian, &uncompressedLen); err != nil {
+		return TurnRecord{}, err
+	}
+	if _, err := io.ReadFull(r, rec.PayloadHash[:]); err != nil {
+		return TurnRecord{}, err
+	}
+
+	var payloadLen uint32
+	if err := binary.Read(r, binary.LittleEndian, &payloadLen); err != nil {
+		return TurnRecord{}, err
+	}
+	rec.Payload = make([]byte, payloadLen)
+	if _, err := io.ReadFull(r, rec.Payload); err != nil {
+		return TurnRecord{}, err
+	}
+
+	return rec, nil
 }
@@ -0,0 +1,417 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zeebo/blake3"
+)
+
+func TestTurnRecordEqual(t *testing.T) {
+	t.Parallel()
+
+	base := TurnRecord{
+		TurnID:      2,
+		ParentID:    1,
+		Depth:       1,
+		TypeID:      "com.example.Message",
+		TypeVersion: 1,
+		Encoding:    EncodingMsgpack,
+		Payload:     []byte{0x81, 0x01, 0xa1, 0x61},
+	}
+	base.PayloadHash = blake3.Sum256(base.Payload)
+
+	same := base
+	same.Payload = append([]byte{}, base.Payload...)
+	if !base.Equal(same) {
+		t.Fatal("expected copies to be equal")
+	}
+
+	changes := map[string]func(*TurnRecord){
+		"turn id":      func(r *TurnRecord) { r.TurnID++ },
+		"depth":        func(r *TurnRecord) { r.Depth++ },
+		"type id":      func(r *TurnRecord) { r.TypeID = "other" },
+		"type version": func(r *TurnRecord) { r.TypeVersion++ },
+		"payload":      func(r *TurnRecord) { r.Payload = []byte{0x80} },
+	}
+	for name, change := range changes {
+		other := base
+		change(&other)
+		if base.Equal(other) {
+			t.Errorf("expected records differing in %s to be unequal", name)
+		}
+	}
+}
+
+func TestTurnRecordContentHash(t *testing.T) {
+	t.Parallel()
+
+	a := TurnRecord{TurnID: 1, Payload: []byte("hello")}
+	b := TurnRecord{TurnID: 2, Payload: []byte("hello")}
+	c := TurnRecord{TurnID: 1, Payload: []byte("changed")}
+
+	if a.ContentHash() != b.ContentHash() {
+		t.Fatal("expected identical payloads to hash equally")
+	}
+	if a.ContentHash() == c.ContentHash() {
+		t.Fatal("expected different payloads to hash differently")
+	}
+	if len(a.ContentHash()) != 64 {
+		t.Fatalf("expected 64 hex characters, got %d", len(a.ContentHash()))
+	}
+}
+
+func TestStreamLast(t *testing.T) {
+	t.Parallel()
+
+	const total = 5000
+	records := make([]TurnRecord, total)
+	for i := range records {
+		records[i] = TurnRecord{TurnID: uint64(i + 1), Depth: uint32(i), TypeID: "t", Payload: []byte{byte(i)}}
+	}
+
+	addr := startStubServer(t, func(msgType uint16, payload []byte) (uint16, []byte) {
+		if msgType != msgGetLast {
+			return msgError, encodeServerError(422, "bad request")
+		}
+		switch binary.LittleEndian.Uint64(payload[0:8]) {
+		case 1:
+			return msgGetLast, encodeTurnRecords(records...)
+		case 2:
+			// Claims more records than it carries.
+			resp := encodeTurnRecords(records[:3]...)
+			binary.LittleEndian.PutUint32(resp[0:4], 10)
+			return msgGetLast, resp
+		default:
+			return msgError, encodeServerError(404, "context")
+		}
+	})
+
+	client, err := Dial(addr)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+
+	out, errs := client.StreamLast(ctx, 1, GetLastOptions{Limit: total, IncludePayload: true})
+	var n int
+	for rec := range out {
+		if !rec.Equal(records[n]) {
+			t.Fatalf("record %d = %+v, want %+v", n, rec, records[n])
+		}
+		n++
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("StreamLast: %v", err)
+	}
+	if n != total {
+		t.Fatalf("expected %d records, got %d", total, n)
+	}
+
+	out, errs = client.StreamLast(ctx, 2, GetLastOptions{Limit: 10})
+	n = 0
+	for range out {
+		n++
+	}
+	if err := <-errs; !errors.Is(err, ErrInvalidResponse) {
+		t.Fatalf("expected ErrInvalidResponse for a truncated response, got %v", err)
+	}
+	if n != 3 {
+		t.Fatalf("expected 3 records before the failure, got %d", n)
+	}
+
+	out, errs = client.StreamLast(ctx, 3, GetLastOptions{})
+	for range out {
+		t.Fatal("unexpected record for a missing context")
+	}
+	if err := <-errs; !IsServerError(err, 404) {
+		t.Fatalf("expected server 404, got %v", err)
+	}
+
+	// The connection stays usable after each stream.
+	if _, err := client.GetLast(ctx, 1, GetLastOptions{Limit: total}); err != nil {
+		t.Fatalf("GetLast after StreamLast: %v", err)
+	}
+}
+
+func TestAppendTurnResult(t *testing.T) {
+	t.Parallel()
+
+	committed := time.UnixMilli(1_760_000_000_123)
+	var legacy atomic.Bool
+	addr := startStubServer(t, func(msgType uint16, payload []byte) (uint16, []byte) {
+		if msgType != msgAppend {
+			return msgError, encodeServerError(422, "unexpected")
+		}
+		// Echo the context ID and content hash from the request.
+		ack := append([]byte(nil), payload[0:8]...)
+		ack = binary.LittleEndian.AppendUint64(ack, 42)
+		ack = binary.LittleEndian.AppendUint32(ack, 7)
+		typeLen := binary.LittleEndian.Uint32(payload[16:20])
+		hashAt := 20 + int(typeLen) + 4 + 4 + 4 + 4
+		ack = append(ack, payload[hashAt:hashAt+32]...)
+		if !legacy.Load() {
+			ack = binary.LittleEndian.AppendUint64(ack, uint64(committed.UnixMilli()))
+		}
+		return msgAppend, ack
+	})
+
+	client, err := Dial(addr)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+
+	req := &AppendRequest{ContextID: 3, TypeID: "test.Message", TypeVersion: 1, Payload: []byte("hello")}
+	got, err := client.AppendTurn(context.Background(), req)
+	if err != nil {
+		t.Fatalf("AppendTurn: %v", err)
+	}
+	want := &AppendResult{
+		ContextID:   3,
+		TurnID:      42,
+		Depth:       7,
+		PayloadHash: blake3.Sum256(req.Payload),
+		CommittedAt: committed,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("AppendTurn = %+v, want %+v", got, want)
+	}
+	if head := got.Head(); *head != (ContextHead{ContextID: 3, HeadTurnID: 42, HeadDepth: 7}) {
+		t.Fatalf("Head() = %+v", head)
+	}
+
+	// Servers that predate the commit time send a 52-byte ack.
+	legacy.Store(true)
+	got, err = client.AppendTurn(context.Background(), req)
+	if err != nil {
+		t.Fatalf("AppendTurn legacy: %v", err)
+	}
+	if !got.CommittedAt.IsZero() || got.TurnID != 42 {
+		t.Fatalf("legacy AppendTurn = %+v", got)
+	}
+}
+
+func TestGetLastOrder(t *testing.T) {
+	t.Parallel()
+
+	records := []TurnRecord{
+		{TurnID: 11, Depth: 0, TypeID: "t"},
+		{TurnID: 12, ParentID: 11, Depth: 1, TypeID: "t"},
+		{TurnID: 13, ParentID: 12, Depth: 2, TypeID: "t"},
+	}
+	addr := startStubServer(t, func(msgType uint16, payload []byte) (uint16, []byte) {
+		return msgGetLast, encodeTurnRecords(records...)
+	})
+
+	client, err := Dial(addr)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	for _, tc := range []struct {
+		order TurnOrder
+		want  []uint64
+	}{
+		{OrderAscending, []uint64{11, 12, 13}},
+		{OrderDescending, []uint64{13, 12, 11}},
+	} {
+		turns, err := client.GetLast(ctx, 1, GetLastOptions{Limit: 3, Order: tc.order})
+		if err != nil {
+			t.Fatalf("GetLast(order=%d): %v", tc.order, err)
+		}
+		var got []uint64
+		for _, turn := range turns {
+			got = append(got, turn.TurnID)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("GetLast(order=%d) = %v, want %v", tc.order, got, tc.want)
+		}
+	}
+
+	out, errs := client.StreamLast(ctx, 1, GetLastOptions{Order: OrderDescending})
+	for range out {
+		t.Fatal("unexpected record for a descending stream")
+	}
+	if err := <-errs; err == nil {
+		t.Fatal("expected StreamLast to reject OrderDescending")
+	}
+}
+
+func TestGetLastTypeFilter(t *testing.T) {
+	t.Parallel()
+
+	records := []TurnRecord{
+		{TurnID: 21, Depth: 0, TypeID: "cxdb.ConversationItem", TypeVersion: 1},
+		{TurnID: 22, ParentID: 21, Depth: 1, TypeID: "cxdb.ToolCall", TypeVersion: 1},
+		{TurnID: 23, ParentID: 22, Depth: 2, TypeID: "cxdb.ConversationItem", TypeVersion: 2},
+		{TurnID: 24, ParentID: 23, Depth: 3, TypeID: "cxdb.ToolCall", TypeVersion: 2},
+	}
+	addr := startStubServer(t, func(msgType uint16, payload []byte) (uint16, []byte) {
+		return msgGetLast, encodeTurnRecords(records...)
+	})
+
+	client, err := Dial(addr)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	for _, tc := range []struct {
+		opts GetLastOptions
+		want []uint64
+	}{
+		{GetLastOptions{Limit: 4}, []uint64{21, 22, 23, 24}},
+		{GetLastOptions{Limit: 4, TypeID: "cxdb.ToolCall"}, []uint64{22, 24}},
+		{GetLastOptions{Limit: 4, TypeID: "cxdb.ConversationItem", TypeVersion: 2}, []uint64{23}},
+		{GetLastOptions{Limit: 4, TypeVersion: 1}, []uint64{21, 22}},
+		{GetLastOptions{Limit: 4, TypeID: "cxdb.ToolCall", Order: OrderDescending}, []uint64{24, 22}},
+		{GetLastOptions{Limit: 4, TypeID: "other"}, nil},
+	} {
+		turns, err := client.GetLast(ctx, 1, tc.opts)
+		if err != nil {
+			t.Fatalf("GetLast(%+v): %v", tc.opts, err)
+		}
+		var got []uint64
+		for _, turn := range turns {
+			got = append(got, turn.TurnID)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("GetLast(%+v) = %v, want %v", tc.opts, got, tc.want)
+		}
+	}
+
+	out, errs := client.StreamLast(ctx, 1, GetLastOptions{Limit: 4, TypeID: "cxdb.ToolCall"})
+	var streamed []uint64
+	for turn := range out {
+		streamed = append(streamed, turn.TurnID)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("StreamLast: %v", err)
+	}
+	if !reflect.DeepEqual(streamed, []uint64{22, 24}) {
+		t.Fatalf("StreamLast = %v, want [22 24]", streamed)
+	}
+}
+
+func TestContextNotFoundError(t *testing.T) {
+	t.Parallel()
+
+	addr := startStubServer(t, func(msgType uint16, payload []byte) (uint16, []byte) {
+		if binary.LittleEndian.Uint64(payload[0:8]) == 1 {
+			return msgError, encodeServerError(404, "head turn")
+		}
+		return msgError, encodeServerError(404, "context")
+	})
+
+	client, err := Dial(addr)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+
+	_, headErr := client.GetHead(ctx, 7)
+	_, lastErr := client.GetLast(ctx, 8, GetLastOptions{Limit: 1})
+	for _, tc := range []struct {
+		err  error
+		want uint64
+	}{{headErr, 7}, {lastErr, 8}} {
+		var notFound *ContextNotFoundError
+		if !errors.Is(tc.err, ErrContextNotFound) || !errors.As(tc.err, &notFound) {
+			t.Fatalf("expected a *ContextNotFoundError, got %v", tc.err)
+		}
+		if notFound.ContextID != tc.want {
+			t.Fatalf("ContextID = %d, want %d", notFound.ContextID, tc.want)
+		}
+		if !IsServerError(tc.err, 404) {
+			t.Fatalf("expected the server error to stay wrapped, got %v", tc.err)
+		}
+	}
+
+	// A 404 for something other than the context is not a missing context.
+	if _, err := client.GetHead(ctx, 1); err == nil || errors.Is(err, ErrContextNotFound) {
+		t.Fatalf("expected a plain server error, got %v", err)
+	}
+}
+
+func TestGetTurn(t *testing.T) {
+	t.Parallel()
+
+	want := TurnRecord{
+		TurnID:      42,
+		ParentID:    41,
+		Depth:       7,
+		TypeID:      "com.example.Message",
+		TypeVersion: 2,
+		Encoding:    EncodingMsgpack,
+		Payload:     []byte("hello"),
+	}
+	want.PayloadHash = blake3.Sum256(want.Payload)
+
+	addr := startStubServer(t, func(msgType uint16, payload []byte) (uint16, []byte) {
+		if msgType != msgGetTurn || len(payload) != 20 {
+			return msgError, encodeServerError(422, "bad request")
+		}
+		contextID := binary.LittleEndian.Uint64(payload[0:8])
+		turnID := binary.LittleEndian.Uint64(payload[8:16])
+		includePayload := binary.LittleEndian.Uint32(payload[16:20]) != 0
+		switch {
+		case contextID == 1 && turnID == want.TurnID:
+			rec := want
+			if !includePayload {
+				rec.Payload = nil
+			}
+			return msgGetTurn, encodeTurnRecords(rec)
+		case contextID == 1:
+			return msgGetTurn, encodeTurnRecords()
+		default:
+			return msgError, encodeServerError(404, "turn")
+		}
+	})
+
+	client, err := Dial(addr)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+
+	got, err := client.GetTurn(ctx, 1, 42, GetTurnOptions{IncludePayload: true})
+	if err != nil {
+		t.Fatalf("GetTurn: %v", err)
+	}
+	if !got.Equal(want) {
+		t.Fatalf("GetTurn = %+v, want %+v", got, want)
+	}
+
+	got, err = client.GetTurn(ctx, 1, 42, GetTurnOptions{})
+	if err != nil {
+		t.Fatalf("GetTurn without payload: %v", err)
+	}
+	if len(got.Payload) != 0 || got.PayloadHash != want.PayloadHash {
+		t.Fatalf("GetTurn without payload = %+v", got)
+	}
+
+	if _, err := client.GetTurn(ctx, 1, 99, GetTurnOptions{}); !errors.Is(err, ErrTurnNotFound) {
+		t.Fatalf("GetTurn missing turn error = %v, want ErrTurnNotFound", err)
+	}
+	if _, err := client.GetTurn(ctx, 2, 42, GetTurnOptions{}); !errors.Is(err, ErrTurnNotFound) {
+		t.Fatalf("GetTurn server 404 error = %v, want ErrTurnNotFound", err)
+	}
+}
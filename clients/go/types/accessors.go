@@ -0,0 +1,113 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+// =============================================================================
+// Roles
+// =============================================================================
+
+// Role identifies who produced a conversation item.
+type Role string
+
+const (
+	// RoleUser is a message from the user.
+	RoleUser Role = "user"
+
+	// RoleAssistant is output from the model, including tool invocation requests.
+	RoleAssistant Role = "assistant"
+
+	// RoleTool is the output of a tool invocation.
+	RoleTool Role = "tool"
+
+	// RoleSystem is a system-level message.
+	RoleSystem Role = "system"
+)
+
+// =============================================================================
+// Accessors
+// =============================================================================
+//
+// The accessors below interpret an item according to its ItemType. They return
+// ok=false when the item is nil, when its type has no such shape, or when the
+// variant named by ItemType is missing.
+
+// Role reports who produced the item. Handoffs have no role.
+func (item *ConversationItem) Role() (Role, bool) {
+	if item == nil {
+		return "", false
+	}
+	switch item.ItemType {
+	case ItemTypeUserInput:
+		return RoleUser, item.UserInput != nil
+	case ItemTypeAssistantTurn:
+		return RoleAssistant, item.Turn != nil
+	case ItemTypeAssistant:
+		return RoleAssistant, item.Assistant != nil
+	case ItemTypeToolCall:
+		return RoleAssistant, item.ToolCall != nil
+	case ItemTypeToolResult:
+		return RoleTool, item.ToolResult != nil
+	case ItemTypeSystem:
+		return RoleSystem, item.System != nil
+	}
+	return "", false
+}
+
+// TextContent returns the item's primary text: the user's input, the
+// assistant's response, a system message's content, or a legacy tool result's
+// output. Tool calls and handoffs have no text content.
+func (item *ConversationItem) TextContent() (string, bool) {
+	if item == nil {
+		return "", false
+	}
+	switch item.ItemType {
+	case ItemTypeUserInput:
+		if item.UserInput != nil {
+			return item.UserInput.Text, true
+		}
+	case ItemTypeAssistantTurn:
+		if item.Turn != nil {
+			return item.Turn.Text, true
+		}
+	case ItemTypeAssistant:
+		if item.Assistant != nil {
+			return item.Assistant.Text, true
+		}
+	case ItemTypeToolResult:
+		if item.ToolResult != nil {
+			return item.ToolResult.Content, true
+		}
+	case ItemTypeSystem:
+		if item.System != nil {
+			return item.System.Content, true
+		}
+	}
+	return "", false
+}
+
+// ToolCalls returns the tool invocations carried by the item. For an assistant
+// turn this is its nested ToolCalls, which may be empty. A legacy tool_call item
+// is converted to a single ToolCallItem with the same ID, name, arguments and
+// description; its status and result are not known and are left unset.
+func (item *ConversationItem) ToolCalls() ([]ToolCallItem, bool) {
+	if item == nil {
+		return nil, false
+	}
+	switch item.ItemType {
+	case ItemTypeAssistantTurn:
+		if item.Turn != nil {
+			return item.Turn.ToolCalls, true
+		}
+	case ItemTypeToolCall:
+		if item.ToolCall != nil {
+			return []ToolCallItem{{
+				ID:          item.ToolCall.CallID,
+				Name:        item.ToolCall.Name,
+				Args:        item.ToolCall.Args,
+				Description: item.ToolCall.Description,
+			}}, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConversationItemRole(t *testing.T) {
+	tests := []struct {
+		name   string
+		item   *ConversationItem
+		want   Role
+		wantOK bool
+	}{
+		{"user input", NewUserInput("hi"), RoleUser, true},
+		{"assistant turn", NewAssistantTurn("hello"), RoleAssistant, true},
+		{"legacy assistant", NewAssistant("hello"), RoleAssistant, true},
+		{"legacy tool call", NewToolCall("c1", "ls", "{}"), RoleAssistant, true},
+		{"legacy tool result", NewToolResult("c1", "out", false), RoleTool, true},
+		{"system", NewSystemInfo("note"), RoleSystem, true},
+		{"handoff", NewHandoff("a", "b"), "", false},
+		{"missing variant", &ConversationItem{ItemType: ItemTypeUserInput}, RoleUser, false},
+		{"unknown type", &ConversationItem{ItemType: "future"}, "", false},
+		{"nil", nil, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := tt.item.Role()
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("%s: Role() = %q, %v; want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestConversationItemTextContent(t *testing.T) {
+	tests := []struct {
+		name   string
+		item   *ConversationItem
+		want   string
+		wantOK bool
+	}{
+		{"user input", NewUserInput("hi", "a.go"), "hi", true},
+		{"assistant turn", BuildAssistantTurn("hello").WithReasoning("hmm").Build(), "hello", true},
+		{"empty assistant turn", NewAssistantTurn(""), "", true},
+		{"legacy assistant", NewAssistant("legacy"), "legacy", true},
+		{"legacy tool result", NewToolResult("c1", "out", true), "out", true},
+		{"system", NewSystemWarning("careful"), "careful", true},
+		{"legacy tool call", NewToolCall("c1", "ls", "{}"), "", false},
+		{"handoff", NewHandoff("a", "b"), "", false},
+		{"mismatched variant", &ConversationItem{ItemType: ItemTypeSystem, UserInput: &UserInput{Text: "x"}}, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := tt.item.TextContent()
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("%s: TextContent() = %q, %v; want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestConversationItemToolCalls(t *testing.T) {
+	call := BuildToolCallItem("c1", "shell", `{"cmd":"ls"}`).WithStatus(ToolCallStatusComplete).Build()
+	turn := BuildAssistantTurn("running").WithToolCall(call).Build()
+
+	got, ok := turn.ToolCalls()
+	if !ok || !reflect.DeepEqual(got, []ToolCallItem{call}) {
+		t.Errorf("assistant turn ToolCalls() = %+v, %v", got, ok)
+	}
+
+	got, ok = NewAssistantTurn("no tools").ToolCalls()
+	if !ok || len(got) != 0 {
+		t.Errorf("assistant turn without calls ToolCalls() = %+v, %v; want empty, true", got, ok)
+	}
+
+	legacy := BuildToolCall("c2", "read", `{"path":"a"}`).WithDescription("Reading a").Build()
+	got, ok = legacy.ToolCalls()
+	want := []ToolCallItem{{ID: "c2", Name: "read", Args: `{"path":"a"}`, Description: "Reading a"}}
+	if !ok || !reflect.DeepEqual(got, want) {
+		t.Errorf("legacy tool call ToolCalls() = %+v, %v; want %+v", got, ok, want)
+	}
+
+	for _, item := range []*ConversationItem{NewUserInput("hi"), NewToolResult("c1", "out", false), NewSystemInfo("x"), nil} {
+		if got, ok := item.ToolCalls(); ok || got != nil {
+			t.Errorf("ToolCalls() on %+v = %+v, %v; want nil, false", item, got, ok)
+		}
+	}
+}
@@ -18,12 +18,12 @@ use cxdb_server::metrics::SessionTracker;
 use cxdb_server::protocol::{
     encode_append_ack, encode_attach_fs_resp, encode_ctx_create_resp, encode_error,
     encode_hello_resp, encode_put_blob_resp, parse_append_turn, parse_attach_fs, parse_ctx_create,
-    parse_ctx_fork, parse_get_blob, parse_get_head, parse_get_last, parse_hello, parse_put_blob,
-    read_frame, write_frame, MsgType,
+    parse_ctx_fork, parse_get_blob, parse_get_head, parse_get_last, parse_get_turn, parse_hello,
+    parse_put_blob, read_frame, write_frame, MsgType,
 };
 use cxdb_server::registry::Registry;
 use cxdb_server::s3_sync::{S3Sync, S3SyncConfig, S3SyncHandle};
-use cxdb_server::store::Store;
+use cxdb_server::store::{Store, TurnWithMeta};
 
 fn main() -> Result<()> {
     // Create tokio runtime for async S3 operations
@@ -313,6 +313,7 @@ fn handle_client(
                     record.turn_id,
                     record.depth,
                     &record.payload_hash,
+                    record.created_at_unix_ms,
                 )?;
                 Ok((MsgType::AppendTurn as u16, resp))
             }
@@ -341,39 +342,16 @@ fn handle_client(
                 let mut store = store.lock().unwrap();
                 let items = store.get_last(req.context_id, req.limit, req.include_payload != 0)?;
                 metrics.record_get_last(op_start.elapsed());
-                let mut resp = Vec::new();
-                resp.write_u32::<byteorder::LittleEndian>(items.len() as u32)?;
-                for item in items {
-                    resp.write_u64::<byteorder::LittleEndian>(item.record.turn_id)?;
-                    resp.write_u64::<byteorder::LittleEndian>(item.record.parent_turn_id)?;
-                    resp.write_u32::<byteorder::LittleEndian>(item.record.depth)?;
-                    resp.write_u32::<byteorder::LittleEndian>(
-                        item.meta.declared_type_id.len() as u32
-                    )?;
-                    resp.extend_from_slice(item.meta.declared_type_id.as_bytes());
-                    resp.write_u32::<byteorder::LittleEndian>(item.meta.declared_type_version)?;
-                    resp.write_u32::<byteorder::LittleEndian>(item.meta.encoding)?;
-                    // always return raw payload when included
-                    let compression = if item.payload.is_some() {
-                        0
-                    } else {
-                        item.meta.compression
-                    };
-                    resp.write_u32::<byteorder::LittleEndian>(compression)?;
-                    let uncompressed_len = item
-                        .payload
-                        .as_ref()
-                        .map(|p| p.len() as u32)
-                        .unwrap_or(item.meta.uncompressed_len);
-                    resp.write_u32::<byteorder::LittleEndian>(uncompressed_len)?;
-                    resp.extend_from_slice(&item.record.payload_hash);
-                    if let Some(payload) = item.payload {
-                        resp.write_u32::<byteorder::LittleEndian>(payload.len() as u32)?;
-                        resp.extend_from_slice(&payload);
-                    }
-                }
+                let resp = encode_turn_records(items)?;
                 Ok((MsgType::GetLast as u16, resp))
             }
+            x if x == MsgType::GetTurn as u16 => {
+                let req = parse_get_turn(&payload)?;
+                let mut store = store.lock().unwrap();
+                let item = store.get_turn(req.context_id, req.turn_id, req.include_payload != 0)?;
+                let resp = encode_turn_records(vec![item])?;
+                Ok((MsgType::GetTurn as u16, resp))
+            }
             x if x == MsgType::GetBlob as u16 => {
                 let hash = parse_get_blob(&payload)?;
                 let mut store = store.lock().unwrap();
@@ -429,6 +407,40 @@ fn unix_ms() -> u64 {
         .unwrap_or(0)
 }
 
+/// Encodes turns in the GET_LAST response layout, which GET_TURN shares.
+fn encode_turn_records(items: Vec<TurnWithMeta>) -> Result<Vec<u8>> {
+    let mut resp = Vec::new();
+    resp.write_u32::<byteorder::LittleEndian>(items.len() as u32)?;
+    for item in items {
+        resp.write_u64::<byteorder::LittleEndian>(item.record.turn_id)?;
+        resp.write_u64::<byteorder::LittleEndian>(item.record.parent_turn_id)?;
+        resp.write_u32::<byteorder::LittleEndian>(item.record.depth)?;
+        resp.write_u32::<byteorder::LittleEndian>(item.meta.declared_type_id.len() as u32)?;
+        resp.extend_from_slice(item.meta.declared_type_id.as_bytes());
+        resp.write_u32::<byteorder::LittleEndian>(item.meta.declared_type_version)?;
+        resp.write_u32::<byteorder::LittleEndian>(item.meta.encoding)?;
+        // always return raw payload when included
+        let compression = if item.payload.is_some() {
+            0
+        } else {
+            item.meta.compression
+        };
+        resp.write_u32::<byteorder::LittleEndian>(compression)?;
+        let uncompressed_len = item
+            .payload
+            .as_ref()
+            .map(|p| p.len() as u32)
+            .unwrap_or(item.meta.uncompressed_len);
+        resp.write_u32::<byteorder::LittleEndian>(uncompressed_len)?;
+        resp.extend_from_slice(&item.record.payload_hash);
+        if let Some(payload) = item.payload {
+            resp.write_u32::<byteorder::LittleEndian>(payload.len() as u32)?;
+            resp.extend_from_slice(&payload);
+        }
+    }
+    Ok(resp)
+}
+
 fn map_error(err: &StoreError) -> (u32, String) {
     match err {
         StoreError::NotFound(msg) => (404, msg.clone()),
@@ -27,6 +27,7 @@ pub enum MsgType {
     GetBlob = 9,
     AttachFs = 10,
     PutBlob = 11,
+    GetTurn = 12,
     Error = 255,
 }
 
@@ -76,6 +77,13 @@ pub struct GetLastRequest {
     pub include_payload: u32,
 }
 
+#[derive(Debug, Clone)]
+pub struct GetTurnRequest {
+    pub context_id: u64,
+    pub turn_id: u64,
+    pub include_payload: u32,
+}
+
 pub fn read_frame<R: Read>(reader: &mut R) -> Result<(FrameHeader, Vec<u8>)> {
     let len = match reader.read_u32::<LittleEndian>() {
         Ok(v) => v,
@@ -143,6 +151,15 @@ pub fn parse_get_last(payload: &[u8]) -> Result<GetLastRequest> {
     })
 }
 
+pub fn parse_get_turn(payload: &[u8]) -> Result<GetTurnRequest> {
+    let mut cursor = std::io::Cursor::new(payload);
+    Ok(GetTurnRequest {
+        context_id: cursor.read_u64::<LittleEndian>()?,
+        turn_id: cursor.read_u64::<LittleEndian>()?,
+        include_payload: cursor.read_u32::<LittleEndian>()?,
+    })
+}
+
 pub fn parse_get_blob(payload: &[u8]) -> Result<[u8; 32]> {
     if payload.len() != 32 {
         return Err(StoreError::InvalidInput("invalid blob hash length".into()));
@@ -270,12 +287,14 @@ pub fn encode_append_ack(
     new_turn_id: u64,
     new_depth: u32,
     hash: &[u8; 32],
+    committed_at_unix_ms: u64,
 ) -> Result<Vec<u8>> {
-    let mut buf = Vec::with_capacity(8 + 8 + 4 + 32);
+    let mut buf = Vec::with_capacity(8 + 8 + 4 + 32 + 8);
     buf.write_u64::<LittleEndian>(context_id)?;
     buf.write_u64::<LittleEndian>(new_turn_id)?;
     buf.write_u32::<LittleEndian>(new_depth)?;
     buf.extend_from_slice(hash);
+    buf.write_u64::<LittleEndian>(committed_at_unix_ms)?;
     Ok(buf)
 }
 
@@ -348,3 +367,39 @@ pub fn encode_hello_resp(session_id: u64, protocol_version: u16) -> Result<Vec<u
     buf.write_u16::<LittleEndian>(protocol_version)?;
     Ok(buf)
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_append_ack_layout() {
+        let hash = [7u8; 32];
+        let buf = encode_append_ack(1, 2, 3, &hash, 1_700_000_000_123).unwrap();
+        assert_eq!(buf.len(), 60);
+
+        let mut r = &buf[..];
+        assert_eq!(r.read_u64::<LittleEndian>().unwrap(), 1);
+        assert_eq!(r.read_u64::<LittleEndian>().unwrap(), 2);
+        assert_eq!(r.read_u32::<LittleEndian>().unwrap(), 3);
+        let mut got = [0u8; 32];
+        r.read_exact(&mut got).unwrap();
+        assert_eq!(got, hash);
+        assert_eq!(r.read_u64::<LittleEndian>().unwrap(), 1_700_000_000_123);
+    }
+
+    #[test]
+    fn test_parse_get_turn() {
+        let mut buf = Vec::new();
+        buf.write_u64::<LittleEndian>(5).unwrap();
+        buf.write_u64::<LittleEndian>(42).unwrap();
+        buf.write_u32::<LittleEndian>(1).unwrap();
+
+        let req = parse_get_turn(&buf).unwrap();
+        assert_eq!(req.context_id, 5);
+        assert_eq!(req.turn_id, 42);
+        assert_eq!(req.include_payload, 1);
+
+        assert!(parse_get_turn(&buf[..16]).is_err());
+    }
+}
@@ -306,6 +306,26 @@ impl Store {
         Ok(out)
     }
 
+    pub fn get_turn(
+        &mut self,
+        context_id: u64,
+        turn_id: u64,
+        include_payload: bool,
+    ) -> Result<TurnWithMeta> {
+        let record = self.turn_store.get_turn_in_context(context_id, turn_id)?;
+        let meta = self.turn_store.get_turn_meta(record.turn_id)?;
+        let payload = if include_payload {
+            Some(self.blob_store.get(&record.payload_hash)?)
+        } else {
+            None
+        };
+        Ok(TurnWithMeta {
+            record,
+            meta,
+            payload,
+        })
+    }
+
     pub fn get_blob(&mut self, hash: &[u8; 32]) -> Result<Vec<u8>> {
         self.blob_store.get(hash)
     }
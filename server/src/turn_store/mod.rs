@@ -555,6 +555,37 @@ impl TurnStore {
         Ok(results)
     }
 
+    /// Get a turn by ID, if it is on the context's chain from its head.
+    pub fn get_turn_in_context(&self, context_id: u64, turn_id: u64) -> Result<TurnRecord> {
+        let head = self
+            .heads
+            .get(&context_id)
+            .ok_or_else(|| StoreError::NotFound("context".into()))?;
+        let turn = self
+            .turns
+            .get(&turn_id)
+            .ok_or_else(|| StoreError::NotFound("turn".into()))?;
+
+        // Walk back from head to the turn's depth; the turn is in the context
+        // only if the chain passes through it.
+        let mut current = head.head_turn_id;
+        while current != 0 {
+            let rec = self
+                .turns
+                .get(&current)
+                .ok_or_else(|| StoreError::NotFound("turn".into()))?;
+            if rec.depth <= turn.depth {
+                if rec.turn_id == turn_id {
+                    return Ok(rec.clone());
+                }
+                break;
+            }
+            current = rec.parent_turn_id;
+        }
+
+        Err(StoreError::NotFound("turn".into()))
+    }
+
     /// Get the first turn (depth=0) of a context, if it exists.
     pub fn get_first_turn(&self, context_id: u64) -> Result<TurnRecord> {
         let head = self
@@ -157,6 +157,56 @@ fn indexes_parent_child_context_lineage() {
     assert_eq!(descendants, vec![grandchild.context_id, child.context_id]);
 }
 
+#[test]
+fn get_turn_is_scoped_to_context() {
+    let dir = tempdir().expect("tempdir");
+    let mut store = Store::open(dir.path()).expect("open store");
+
+    let ctx = store.create_context(0).expect("create context");
+    let payload = b"turn".to_vec();
+    let hash = blake3::hash(&payload);
+    let append = |store: &mut Store, context_id: u64| {
+        store
+            .append_turn(
+                context_id,
+                0,
+                "com.example.Test".to_string(),
+                1,
+                1,
+                0,
+                payload.len() as u32,
+                *hash.as_bytes(),
+                &payload,
+            )
+            .expect("append turn")
+            .0
+    };
+    let first = append(&mut store, ctx.context_id);
+    let second = append(&mut store, ctx.context_id);
+    let fork = store.fork_context(first.turn_id).expect("fork context");
+    let forked = append(&mut store, fork.context_id);
+
+    let got = store
+        .get_turn(ctx.context_id, first.turn_id, true)
+        .expect("get first turn");
+    assert_eq!(got.record.turn_id, first.turn_id);
+    assert_eq!(got.payload.as_deref(), Some(&payload[..]));
+
+    let got = store
+        .get_turn(fork.context_id, first.turn_id, false)
+        .expect("get shared turn from fork");
+    assert_eq!(got.record.turn_id, first.turn_id);
+    assert!(got.payload.is_none());
+
+    assert!(store
+        .get_turn(fork.context_id, second.turn_id, false)
+        .is_err());
+    assert!(store
+        .get_turn(ctx.context_id, forked.turn_id, false)
+        .is_err());
+    assert!(store.get_turn(ctx.context_id, 9999, false).is_err());
+}
+
 fn encode_context_metadata_payload(
     parent_context_id: Option<u64>,
     root_context_id: Option<u64>,